@@ -0,0 +1,233 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+	"gitlab.com/tuneverse/toolkit/config"
+	"gitlab.com/tuneverse/toolkit/core/logger"
+	"gitlab.com/tuneverse/toolkit/core/queue"
+	"gitlab.com/tuneverse/toolkit/internal/consts"
+	"gitlab.com/tuneverse/toolkit/internal/consumers"
+	"gitlab.com/tuneverse/toolkit/internal/controllers"
+	"gitlab.com/tuneverse/toolkit/internal/entities"
+	"gitlab.com/tuneverse/toolkit/internal/middlewares"
+	"gitlab.com/tuneverse/toolkit/internal/repo"
+	"gitlab.com/tuneverse/toolkit/internal/usecases"
+)
+
+// Run initializes environment configuration, logging, log storage, and API routing.
+// It sets up the necessary components and routes for the collector and launches it.
+func Run() {
+	// init the env config
+	cfg, err := config.LoadConfig(consts.AppName)
+	if err != nil {
+		panic(err)
+	}
+
+	// the collector logs to the console only, shipping its own logs to
+	// itself would loop
+	logger.InitLogger(&logger.ClientOptions{
+		Service:      consts.AppName,
+		LogLevel:     "info",
+		JSONFormater: true,
+	})
+
+	// storage of the log records
+	logRepo, err := repo.NewLogRepo(context.Background(), cfg.Storage)
+	if err != nil {
+		log.Fatalf("unable to open the log storage: %s", err)
+	}
+	// object store of the records past their retention
+	archiveRepo, err := repo.NewArchiveRepo(cfg.Retention.Archive)
+	if err != nil {
+		log.Fatalf("unable to open the log archive: %s", err)
+	}
+
+	// here initalizing the router
+	router := initRouter()
+	if !cfg.Debug {
+		gin.SetMode(gin.ReleaseMode)
+	}
+
+	// middleware initialization
+	m := middlewares.NewMiddlewares(cfg)
+	api := router.Group("/")
+	api.Use(m.Authenticate())
+
+	// live streams of the stored records
+	hub := usecases.NewHub(consts.StreamBufferSize, consts.MaxStreams)
+
+	// alerting on the received records, off without rules
+	var alertUseCase usecases.AlertUseCaseImply
+	if cfg.Alerts.Rules != "" {
+		webhookRepo, err := repo.NewWebhookRepo(cfg.Alerts.WebhookURL)
+		if err != nil {
+			log.Fatalf("unable to configure the alert webhook: %s", err)
+		}
+		alertUseCase, err = usecases.NewAlertUseCases(context.Background(),
+			repo.NewFileRulesRepo(cfg.Alerts.Rules), webhookRepo)
+		if err != nil {
+			log.Fatalf("unable to load the alert rules: %s", err)
+		}
+	}
+
+	var stopJobs []func()
+	{
+		// initilizing usecases
+		logUseCase := usecases.NewLogUseCases(logRepo, hub, alertUseCase)
+
+		// initalizing controllers
+		logController := controllers.NewLogController(api, logUseCase, cfg)
+
+		// init the routes
+		logController.InitRoutes()
+
+		// batches published to the queue by the CloudMode queue transport
+		if cfg.Queue.Type != "" {
+			logQueue, err := repo.NewLogQueue(cfg.Queue)
+			if err != nil {
+				log.Fatalf("unable to connect to the log queue: %s", err)
+			}
+			stopJobs = append(stopJobs, startConsumer(consumers.NewLogConsumer(logQueue, logUseCase, cfg.Queue.Consumers), logQueue))
+		}
+
+		retentionUseCase := usecases.NewRetentionUseCases(logRepo, archiveRepo, cfg.Retention)
+		controllers.NewRetentionController(api, retentionUseCase).InitRoutes()
+		stopJobs = append(stopJobs, startJob(cfg.Retention.Interval, func(ctx context.Context) {
+			// the failures are logged by the job and retried by the next run
+			_, _ = retentionUseCase.ApplyRetention(ctx)
+		}))
+
+		if alertUseCase != nil {
+			controllers.NewAlertController(api, alertUseCase).InitRoutes()
+			stopJobs = append(stopJobs, startJob(cfg.Alerts.Interval, func(ctx context.Context) {
+				// the failed notifications are logged and retried by the next run
+				_ = alertUseCase.Evaluate(ctx)
+			}))
+		}
+	}
+
+	// run the app
+	launch(cfg, router, logRepo, hub, stopJobs)
+}
+
+// startJob runs job at startup and then every interval until the returned
+// function is called, which waits for a running job
+func startJob(interval time.Duration, job func(ctx context.Context)) func() {
+	if interval <= 0 {
+		return func() {}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			job(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+// startConsumer consumes the log queue until the returned function is called,
+// which waits for the batches being stored and closes the queue. A consumer
+// losing its broker stops the collector, its messages are delivered to the
+// other instances.
+func startConsumer(consumer *consumers.LogConsumer, logQueue queue.Queue) func() {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if err := consumer.Run(ctx); err != nil {
+			log.Fatalf("log queue consumer stopped: %s", err)
+		}
+	}()
+	return func() {
+		cancel()
+		<-done
+		if err := logQueue.Close(); err != nil {
+			log.Println("Log queue close:", err)
+		}
+	}
+}
+
+func initRouter() *gin.Engine {
+	router := gin.Default()
+	gin.SetMode(gin.DebugMode)
+
+	// CORS
+	// - PUT and PATCH methods
+	// - Origin header
+	// - Credentials share
+	// - Preflight requests cached for 12 hours
+	router.Use(cors.New(cors.Config{
+		AllowOrigins:     []string{"*"},
+		AllowMethods:     []string{"PUT", "PATCH", "POST", "DELETE", "GET", "OPTIONS"},
+		AllowHeaders:     []string{"*"},
+		ExposeHeaders:    []string{"Content-Length"},
+		AllowCredentials: true,
+		MaxAge:           12 * time.Hour,
+	}))
+
+	return router
+}
+
+// launch runs the server until SIGINT or SIGTERM, then ends the live streams,
+// lets the running requests and background jobs finish and closes the storage
+func launch(cfg *entities.EnvConfig, router *gin.Engine, logRepo repo.LogRepoImply, hub *usecases.Hub, stopJobs []func()) {
+	srv := &http.Server{
+		Addr:    fmt.Sprintf(":%v", cfg.Port),
+		Handler: router,
+	}
+	// the streams never end by themselves, Shutdown would wait for them
+	srv.RegisterOnShutdown(hub.Close)
+
+	go func() {
+		// service connections
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatalf("listen: %s\n", err)
+		}
+	}()
+	log.Println("Server listening in...", cfg.Port)
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+	log.Println("Shutdown Server ...")
+
+	ctx, cancel := context.WithTimeout(context.Background(), consts.ShutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		log.Println("Server Shutdown:", err)
+	}
+	for _, stop := range stopJobs {
+		stop()
+	}
+	// the storage gets its own deadline, the shutdown and the drain of the
+	// consumers may have used the whole one of the requests
+	closeCtx, closeCancel := context.WithTimeout(context.Background(), consts.StorageCloseTimeout)
+	defer closeCancel()
+	if err := logRepo.Close(closeCtx); err != nil {
+		log.Println("Log storage close:", err)
+	}
+
+	log.Println("Server exiting")
+}
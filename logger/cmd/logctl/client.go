@@ -0,0 +1,218 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"gitlab.com/tuneverse/toolkit/internal/consts"
+	"gitlab.com/tuneverse/toolkit/internal/entities"
+	"gitlab.com/tuneverse/toolkit/utils"
+)
+
+// tokenLifetime is the validity of the tokens signed with the secret
+const tokenLifetime = 5 * time.Minute
+
+// errNoRecords is returned when the collector answers 204 or 404
+var errNoRecords = errors.New("no records found")
+
+// client calls the collector API
+type client struct {
+	url    string
+	token  string
+	secret string
+	http   *http.Client
+}
+
+// envelope is the response of the collector, api.Response with the data
+// left raw
+type envelope struct {
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data"`
+	Errors  interface{}     `json:"errors"`
+}
+
+// searchPage is the data of GET /logs
+type searchPage struct {
+	Metadata struct {
+		Total       int64 `json:"total"`
+		CurrentPage int32 `json:"current_page"`
+		PerPage     int32 `json:"per_page"`
+	} `json:"metadata"`
+	Records []entities.Log `json:"records"`
+}
+
+// authorization returns the token, a short lived one is signed with the
+// secret shared with the collector when no token is given
+func (c *client) authorization() (string, error) {
+	if c.token != "" {
+		return c.token, nil
+	}
+	if c.secret == "" {
+		return "", errors.New("a token or the collector secret is required, see --token and --secret")
+	}
+	now := time.Now()
+	return utils.GenerateJWTAuthToken(c.secret, map[string]interface{}{
+		"iat": now.Unix(),
+		"exp": now.Add(tokenLifetime).Unix(),
+	})
+}
+
+// get calls path with the query and checks the status. The caller closes
+// the body.
+func (c *client) get(ctx context.Context, path string, query url.Values) (*http.Response, error) {
+	token, err := c.authorization()
+	if err != nil {
+		return nil, err
+	}
+	endpoint := strings.TrimRight(c.url, "/") + path
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", token)
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("calling the collector failed: %w", err)
+	}
+	switch {
+	case resp.StatusCode == http.StatusNoContent, resp.StatusCode == http.StatusNotFound:
+		resp.Body.Close()
+		return nil, errNoRecords
+	case resp.StatusCode >= 300:
+		defer resp.Body.Close()
+		var body envelope
+		if err := json.NewDecoder(resp.Body).Decode(&body); err != nil || body.Message == "" {
+			return nil, fmt.Errorf("the collector answered %d", resp.StatusCode)
+		}
+		if body.Errors != nil {
+			return nil, fmt.Errorf("the collector answered %d: %s %v", resp.StatusCode, body.Message, body.Errors)
+		}
+		return nil, fmt.Errorf("the collector answered %d: %s", resp.StatusCode, body.Message)
+	}
+	return resp, nil
+}
+
+// getData calls path and decodes the data of the response into data
+func (c *client) getData(ctx context.Context, path string, query url.Values, data interface{}) error {
+	resp, err := c.get(ctx, path, query)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	var body envelope
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return fmt.Errorf("decoding the response failed: %w", err)
+	}
+	return json.Unmarshal(body.Data, data)
+}
+
+// search returns a page of the matching records, newest first
+func (c *client) search(ctx context.Context, query url.Values) (*searchPage, error) {
+	var page searchPage
+	if err := c.getData(ctx, "/logs", query, &page); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+// export calls fn with every matching record, oldest first
+func (c *client) export(ctx context.Context, query url.Values, fn func(entities.Log) error) error {
+	query.Set("format", consts.FormatNDJSON)
+	resp, err := c.get(ctx, "/logs", query)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	decoder := json.NewDecoder(resp.Body)
+	for {
+		var log entities.Log
+		if err := decoder.Decode(&log); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("decoding the records failed: %w", err)
+		}
+		if err := fn(log); err != nil {
+			return err
+		}
+	}
+}
+
+// stream calls fn with the records stored from now on until ctx is done or
+// the collector ends the stream
+func (c *client) stream(ctx context.Context, query url.Values, fn func(entities.Log) error) error {
+	resp, err := c.get(ctx, "/logs/stream", query)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var (
+		scanner = bufio.NewScanner(resp.Body)
+		event   string
+		data    strings.Builder
+	)
+	scanner.Buffer(make([]byte, 64*1024), consts.MaxBodySize)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, ":"):
+			// heartbeat
+		case strings.HasPrefix(line, "event:"):
+			event = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			data.WriteString(strings.TrimPrefix(line, "data:"))
+		case line == "":
+			if err := dispatch(event, data.String(), fn); err != nil {
+				return err
+			}
+			event = ""
+			data.Reset()
+		}
+	}
+	if ctx.Err() != nil {
+		return nil
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("reading the stream failed: %w", err)
+	}
+	return errors.New("the collector closed the stream")
+}
+
+// dispatch handles an event of the stream
+func dispatch(event, data string, fn func(entities.Log) error) error {
+	switch event {
+	case consts.StreamLogEvent:
+		var log entities.Log
+		if err := json.Unmarshal([]byte(data), &log); err != nil {
+			return fmt.Errorf("decoding a record failed: %w", err)
+		}
+		return fn(log)
+	case consts.StreamEndEvent:
+		var end struct {
+			Reason string `json:"reason"`
+		}
+		_ = json.Unmarshal([]byte(data), &end)
+		return fmt.Errorf("the collector ended the stream: %s", end.Reason)
+	}
+	return nil
+}
+
+// timeline returns the timeline of a request or trace id
+func (c *client) timeline(ctx context.Context, id string, query url.Values) (*entities.Timeline, error) {
+	var timeline entities.Timeline
+	if err := c.getData(ctx, "/logs/timeline/"+url.PathEscape(id), query, &timeline); err != nil {
+		return nil, err
+	}
+	return &timeline, nil
+}
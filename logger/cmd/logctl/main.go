@@ -0,0 +1,352 @@
+// Command logctl searches, tails and traces the records of the log
+// collector:
+//
+//	logctl search --service partner --level error --since 1h
+//	logctl tail --req-id 7f0c2a9e-2b1d-4c55-9d47-0a1bbf6e1c3d
+//	logctl trace 4bf92f3577b34da6a3ce929d0e0e4736
+//
+// The collector is reached at --url, LOGCTL_URL by default. Requests are
+// signed with a short lived HS256 token built from the secret shared with the
+// collector, --secret or LOGGER_SECRET, the scheme of the services; a token
+// can be given instead with --token or LOGCTL_TOKEN.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+const usage = `Usage: logctl <command> [flags]
+
+Commands:
+  search   list the matching records, oldest first
+  tail     follow the matching records as they are received
+  trace    show the timeline of a request or trace id
+
+Run logctl <command> -h for the flags of a command.
+`
+
+// defaultURL is the collector when neither --url nor LOGCTL_URL is set
+const defaultURL = "http://localhost:8080"
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	os.Exit(run(ctx, os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run executes a command and returns the exit code: 0 on success, 1 on a
+// failure or when no record matched, 2 on a usage error
+func run(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+	var command func(context.Context, []string, io.Writer, io.Writer) error
+	switch args[0] {
+	case "search":
+		command = search
+	case "tail":
+		command = tail
+	case "trace":
+		command = trace
+	case "help", "-h", "--help":
+		fmt.Fprint(stdout, usage)
+		return 0
+	default:
+		fmt.Fprintf(stderr, "logctl: unknown command %q\n\n%s", args[0], usage)
+		return 2
+	}
+
+	err := command(ctx, args[1:], stdout, stderr)
+	var usageErr usageError
+	switch {
+	case err == nil:
+		return 0
+	case errors.Is(err, flag.ErrHelp):
+		return 0
+	case errors.As(err, &usageErr):
+		fmt.Fprintf(stderr, "logctl %s: %s\n", args[0], err)
+		return 2
+	default:
+		fmt.Fprintf(stderr, "logctl %s: %s\n", args[0], err)
+		return 1
+	}
+}
+
+// usageError is a wrong use of the flags
+type usageError struct {
+	error
+}
+
+// options are the flags shared by the commands
+type options struct {
+	url     string
+	token   string
+	secret  string
+	output  string
+	noColor bool
+}
+
+// register adds the shared flags to fs
+func (o *options) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.url, "url", envOr("LOGCTL_URL", defaultURL), "collector URL, LOGCTL_URL")
+	fs.StringVar(&o.token, "token", os.Getenv("LOGCTL_TOKEN"), "token sent as is, LOGCTL_TOKEN")
+	fs.StringVar(&o.secret, "secret", os.Getenv("LOGGER_SECRET"), "secret shared with the collector, LOGGER_SECRET")
+	fs.StringVar(&o.output, "output", "text", "output format, text or json")
+	fs.StringVar(&o.output, "o", "text", "shorthand for --output")
+	fs.BoolVar(&o.noColor, "no-color", os.Getenv("NO_COLOR") != "", "disable the colours, NO_COLOR")
+}
+
+// client returns the client of the collector
+func (o *options) client() *client {
+	return &client{
+		url:    o.url,
+		token:  o.token,
+		secret: o.secret,
+		http:   &http.Client{},
+	}
+}
+
+// printer returns the printer of the output, colours are only written to a
+// terminal
+func (o *options) printer(stdout io.Writer) (*printer, error) {
+	if o.output != "text" && o.output != "json" {
+		return nil, usageError{fmt.Errorf("unknown output %q, text or json", o.output)}
+	}
+	return &printer{
+		w:     stdout,
+		json:  o.output == "json",
+		color: !o.noColor && isTerminal(stdout),
+	}, nil
+}
+
+// filter are the record filters of search and tail
+type filter struct {
+	service  string
+	level    string
+	reqID    string
+	traceID  string
+	endpoint string
+	status   int
+	query    string
+}
+
+// register adds the filter flags to fs
+func (f *filter) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.service, "service", "", "service of the records")
+	fs.StringVar(&f.level, "level", "", "level of the records, e.g. error")
+	fs.StringVar(&f.reqID, "req-id", "", "request id")
+	fs.StringVar(&f.traceID, "trace-id", "", "trace id")
+	fs.StringVar(&f.endpoint, "endpoint", "", "route template, e.g. /:version/partners/:partner_id")
+	fs.IntVar(&f.status, "status", 0, "response code")
+	fs.StringVar(&f.query, "q", "", "case insensitive part of the message")
+}
+
+// values returns the query parameters of the filter
+func (f *filter) values() url.Values {
+	values := url.Values{}
+	set := func(key, value string) {
+		if value != "" {
+			values.Set(key, value)
+		}
+	}
+	set("service", f.service)
+	set("log_level", f.level)
+	set("req_id", f.reqID)
+	set("trace_id", f.traceID)
+	set("endpoint", f.endpoint)
+	set("q", f.query)
+	if f.status != 0 {
+		values.Set("response_code", strconv.Itoa(f.status))
+	}
+	return values
+}
+
+// timeRange are the --since, --from and --to flags
+type timeRange struct {
+	since time.Duration
+	from  string
+	to    string
+}
+
+// register adds the time range flags to fs
+func (r *timeRange) register(fs *flag.FlagSet) {
+	fs.DurationVar(&r.since, "since", 0, "records of the last duration, e.g. 1h")
+	fs.StringVar(&r.from, "from", "", "records from this time, RFC 3339")
+	fs.StringVar(&r.to, "to", "", "records before this time, RFC 3339")
+}
+
+// set adds the range to the query parameters
+func (r *timeRange) set(values url.Values) error {
+	if r.since > 0 && r.from != "" {
+		return usageError{errors.New("--since and --from are exclusive")}
+	}
+	for key, value := range map[string]string{"from": r.from, "to": r.to} {
+		if value == "" {
+			continue
+		}
+		if _, err := time.Parse(time.RFC3339, value); err != nil {
+			return usageError{fmt.Errorf("--%s must be an RFC 3339 time: %w", key, err)}
+		}
+		values.Set(key, value)
+	}
+	if r.since > 0 {
+		values.Set("from", time.Now().Add(-r.since).UTC().Format(time.RFC3339))
+	}
+	return nil
+}
+
+// parse parses the flags, -h is returned as flag.ErrHelp and the other
+// failures as usage errors
+func parse(fs *flag.FlagSet, arguments []string, stderr io.Writer) error {
+	fs.SetOutput(stderr)
+	if err := fs.Parse(arguments); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return usageError{err}
+	}
+	return nil
+}
+
+// search lists the matching records. A page is requested at a time and
+// printed oldest first; --all exports every matching record instead.
+func search(ctx context.Context, arguments []string, stdout, stderr io.Writer) error {
+	var (
+		fs      = flag.NewFlagSet("search", flag.ContinueOnError)
+		opts    options
+		filters filter
+		period  timeRange
+		limit   int
+		page    int
+		all     bool
+	)
+	opts.register(fs)
+	filters.register(fs)
+	period.register(fs)
+	fs.IntVar(&limit, "limit", 50, "records per page, at most 100")
+	fs.IntVar(&page, "page", 1, "page, 1 holds the newest records")
+	fs.BoolVar(&all, "all", false, "every matching record instead of a page")
+	if err := parse(fs, arguments, stderr); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return usageError{fmt.Errorf("unexpected arguments %v", fs.Args())}
+	}
+	out, err := opts.printer(stdout)
+	if err != nil {
+		return err
+	}
+	query := filters.values()
+	if err := period.set(query); err != nil {
+		return err
+	}
+
+	if all {
+		return opts.client().export(ctx, query, out.record)
+	}
+	query.Set("limit", strconv.Itoa(limit))
+	query.Set("page", strconv.Itoa(page))
+	result, err := opts.client().search(ctx, query)
+	if err != nil {
+		return err
+	}
+	for i := len(result.Records) - 1; i >= 0; i-- {
+		if err := out.record(result.Records[i]); err != nil {
+			return err
+		}
+	}
+	if !out.json {
+		fmt.Fprintf(stderr, "%d of %d records, page %d\n", len(result.Records), result.Metadata.Total, result.Metadata.CurrentPage)
+	}
+	return nil
+}
+
+// tail follows the matching records until interrupted
+func tail(ctx context.Context, arguments []string, stdout, stderr io.Writer) error {
+	var (
+		fs      = flag.NewFlagSet("tail", flag.ContinueOnError)
+		opts    options
+		filters filter
+	)
+	opts.register(fs)
+	filters.register(fs)
+	if err := parse(fs, arguments, stderr); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return usageError{fmt.Errorf("unexpected arguments %v", fs.Args())}
+	}
+	out, err := opts.printer(stdout)
+	if err != nil {
+		return err
+	}
+	return opts.client().stream(ctx, filters.values(), out.record)
+}
+
+// trace shows the timeline of a request or trace id. The flags may follow
+// the id.
+func trace(ctx context.Context, arguments []string, stdout, stderr io.Writer) error {
+	var (
+		fs     = flag.NewFlagSet("trace", flag.ContinueOnError)
+		opts   options
+		period timeRange
+	)
+	opts.register(fs)
+	period.register(fs)
+	if err := parse(fs, arguments, stderr); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		return usageError{errors.New("a request or trace id is required")}
+	}
+	id := fs.Arg(0)
+	if err := parse(fs, fs.Args()[1:], stderr); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return usageError{fmt.Errorf("unexpected arguments %v", fs.Args())}
+	}
+	out, err := opts.printer(stdout)
+	if err != nil {
+		return err
+	}
+	query := url.Values{}
+	if err := period.set(query); err != nil {
+		return err
+	}
+	timeline, err := opts.client().timeline(ctx, id, query)
+	if err != nil {
+		return err
+	}
+	return out.timeline(timeline)
+}
+
+// envOr returns the environment variable or fallback when it is empty
+func envOr(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+// isTerminal reports whether w is a terminal
+func isTerminal(w io.Writer) bool {
+	file, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
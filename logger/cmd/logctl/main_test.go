@@ -0,0 +1,217 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"gitlab.com/tuneverse/toolkit/core/logger"
+	"gitlab.com/tuneverse/toolkit/internal/consts"
+	"gitlab.com/tuneverse/toolkit/internal/controllers"
+	"gitlab.com/tuneverse/toolkit/internal/entities"
+	"gitlab.com/tuneverse/toolkit/internal/middlewares"
+	"gitlab.com/tuneverse/toolkit/internal/repo"
+	"gitlab.com/tuneverse/toolkit/internal/usecases"
+)
+
+const secret = "collector-secret"
+
+// newCollector serves the collector API on a file storage
+func newCollector(t *testing.T) *httptest.Server {
+	gin.SetMode(gin.TestMode)
+	logger.InitLogger(&logger.ClientOptions{Service: consts.AppName, LogLevel: "panic"})
+
+	cfg := &entities.EnvConfig{Secret: secret}
+	logRepo, err := repo.NewFileLogRepo(t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = logRepo.Close(context.Background()) })
+
+	router := gin.New()
+	api := router.Group("/")
+	api.Use(middlewares.NewMiddlewares(cfg).Authenticate())
+	controllers.NewLogController(api, usecases.NewLogUseCases(logRepo, usecases.NewHub(consts.StreamBufferSize, consts.MaxStreams), nil), cfg).InitRoutes()
+
+	srv := httptest.NewServer(router)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// ingest posts the records to the collector
+func ingest(t *testing.T, srv *httptest.Server, records ...map[string]interface{}) {
+	client := &client{url: srv.URL, secret: secret}
+	token, err := client.authorization()
+	require.NoError(t, err)
+	body, err := json.Marshal(map[string]interface{}{"logs": records})
+	require.NoError(t, err)
+	req, err := http.NewRequest(http.MethodPost, srv.URL+"/logs", bytes.NewReader(body))
+	require.NoError(t, err)
+	req.Header.Set("Authorization", token)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Less(t, resp.StatusCode, 300)
+}
+
+func record(message, service, level, reqID string, offset time.Duration) map[string]interface{} {
+	return map[string]interface{}{
+		"message":   message,
+		"service":   service,
+		"log_level": level,
+		"req_id":    reqID,
+		"timestamp": time.Now().Add(-time.Minute + offset).UTC().Format(time.RFC3339Nano),
+	}
+}
+
+// logctl runs the command against srv with the secret
+func logctl(srv *httptest.Server, command string, args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	args = append([]string{command, "--url", srv.URL, "--secret", secret, "--no-color"}, args...)
+	code := run(context.Background(), args, &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func decode(t *testing.T, output string) []entities.Log {
+	var logs []entities.Log
+	decoder := json.NewDecoder(strings.NewReader(output))
+	for decoder.More() {
+		var log entities.Log
+		require.NoError(t, decoder.Decode(&log))
+		logs = append(logs, log)
+	}
+	return logs
+}
+
+func TestLogctl(t *testing.T) {
+	srv := newCollector(t)
+	ingest(t, srv,
+		record("partner created", "partner", "info", "req-1", 0),
+		record("partner lookup failed", "partner", "error", "req-1", time.Second),
+		record("utility call failed", "utility", "error", "req-2", 2*time.Second),
+	)
+
+	t.Run("search", func(t *testing.T) {
+		code, stdout, stderr := logctl(srv, "search", "--service", "partner", "--level", "error", "--since", "1h")
+		require.Equal(t, 0, code, stderr)
+		require.Contains(t, stdout, "ERROR   partner req-1 partner lookup failed")
+		require.NotContains(t, stdout, "utility")
+		require.Contains(t, stderr, "1 of 1 records")
+
+		code, stdout, _ = logctl(srv, "search", "-o", "json", "--limit", "2")
+		require.Equal(t, 0, code)
+		logs := decode(t, stdout)
+		require.Len(t, logs, 2)
+		require.True(t, logs[0].Timestamp.Before(logs[1].Timestamp), "oldest first")
+
+		code, stdout, _ = logctl(srv, "search", "-o", "json", "--all")
+		require.Equal(t, 0, code)
+		require.Len(t, decode(t, stdout), 3)
+	})
+
+	t.Run("no records", func(t *testing.T) {
+		code, _, stderr := logctl(srv, "search", "--service", "unknown")
+		require.Equal(t, 1, code)
+		require.Contains(t, stderr, errNoRecords.Error())
+	})
+
+	t.Run("trace", func(t *testing.T) {
+		code, stdout, stderr := logctl(srv, "trace", "req-1", "--since", "1h")
+		require.Equal(t, 0, code, stderr)
+		require.Contains(t, stdout, "timeline req-1")
+		require.Contains(t, stdout, "2 records")
+		require.Contains(t, stdout, "! ")
+
+		code, stdout, _ = logctl(srv, "trace", "-o", "json", "req-1")
+		require.Equal(t, 0, code)
+		var timeline entities.Timeline
+		require.NoError(t, json.Unmarshal([]byte(stdout), &timeline))
+		require.Len(t, timeline.Entries, 2)
+		require.Equal(t, 1, timeline.Errors)
+	})
+
+	t.Run("tail", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		out := &firstLine{cancel: cancel}
+		done := make(chan int)
+		go func() {
+			var stderr bytes.Buffer
+			done <- run(ctx, []string{"tail", "--url", srv.URL, "--secret", secret, "--req-id", "req-3"}, out, &stderr)
+		}()
+
+		// the stream is subscribed asynchronously, records are sent until
+		// one is received
+		ticker := time.NewTicker(20 * time.Millisecond)
+		defer ticker.Stop()
+		timeout := time.After(2 * time.Second)
+	loop:
+		for {
+			select {
+			case code := <-done:
+				require.Equal(t, 0, code)
+				break loop
+			case <-ticker.C:
+				ingest(t, srv, record("partner updated", "partner", "info", "req-4", 0), record("tailed", "partner", "info", "req-3", 0))
+			case <-timeout:
+				t.Fatal("no record was tailed")
+			}
+		}
+		require.Contains(t, out.String(), "req-3")
+		require.NotContains(t, out.String(), "req-4")
+	})
+
+	t.Run("authentication", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		code := run(context.Background(), []string{"search", "--url", srv.URL, "--secret", "other-secret"}, &stdout, &stderr)
+		require.Equal(t, 1, code)
+		require.Contains(t, stderr.String(), "401")
+
+		stderr.Reset()
+		code = run(context.Background(), []string{"search", "--url", srv.URL, "--secret", ""}, &stdout, &stderr)
+		require.Equal(t, 1, code)
+		require.Contains(t, stderr.String(), "--secret")
+	})
+
+	t.Run("usage", func(t *testing.T) {
+		for _, args := range [][]string{
+			{},
+			{"unknown"},
+			{"search", "--bogus"},
+			{"search", "-o", "yaml"},
+			{"search", "--since", "1h", "--from", "2024-01-31T10:00:00Z"},
+			{"search", "--to", "yesterday"},
+			{"trace"},
+			{"tail", "extra"},
+		} {
+			var stdout, stderr bytes.Buffer
+			require.Equal(t, 2, run(context.Background(), args, &stdout, &stderr), args)
+		}
+	})
+}
+
+// firstLine collects the output and cancels once a line is written
+type firstLine struct {
+	mu     sync.Mutex
+	buf    bytes.Buffer
+	cancel context.CancelFunc
+}
+
+func (f *firstLine) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	defer f.cancel()
+	return f.buf.Write(p)
+}
+
+func (f *firstLine) String() string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.buf.String()
+}
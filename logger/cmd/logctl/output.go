@@ -0,0 +1,136 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"gitlab.com/tuneverse/toolkit/internal/entities"
+)
+
+// ANSI colours of the text output
+const (
+	colorReset  = "\033[0m"
+	colorRed    = "\033[31m"
+	colorYellow = "\033[33m"
+	colorGreen  = "\033[32m"
+	colorCyan   = "\033[36m"
+	colorGray   = "\033[90m"
+	colorBold   = "\033[1m"
+)
+
+// timeFormat is the time of the records in the text output
+const timeFormat = "2006-01-02T15:04:05.000Z07:00"
+
+// levelColors colours the levels of the text output
+var levelColors = map[string]string{
+	"panic":   colorRed,
+	"fatal":   colorRed,
+	"error":   colorRed,
+	"warning": colorYellow,
+	"info":    colorGreen,
+	"debug":   colorGray,
+	"trace":   colorGray,
+}
+
+// printer writes the records as text lines or as JSON documents, one per
+// line
+type printer struct {
+	w     io.Writer
+	json  bool
+	color bool
+}
+
+// paint wraps s in the colour when the colours are on
+func (p *printer) paint(color, s string) string {
+	if !p.color || color == "" {
+		return s
+	}
+	return color + s + colorReset
+}
+
+// record writes a record
+func (p *printer) record(log entities.Log) error {
+	if p.json {
+		return json.NewEncoder(p.w).Encode(log)
+	}
+	_, err := fmt.Fprintln(p.w, p.line(log))
+	return err
+}
+
+// line formats a record: time, level, service, request id, endpoint and
+// status when present, message
+func (p *printer) line(log entities.Log) string {
+	parts := []string{
+		p.paint(colorGray, log.Timestamp.UTC().Format(timeFormat)),
+		p.paint(levelColors[log.Level], fmt.Sprintf("%-7s", strings.ToUpper(log.Level))),
+		p.paint(colorCyan, log.Service),
+	}
+	if log.RequestID != "" {
+		parts = append(parts, p.paint(colorGray, log.RequestID))
+	}
+	if log.Endpoint != "" {
+		parts = append(parts, strings.TrimSpace(log.Method+" "+log.Endpoint))
+	}
+	if log.Status != 0 {
+		status := fmt.Sprint(log.Status)
+		if log.Status >= 500 {
+			status = p.paint(colorRed, status)
+		}
+		parts = append(parts, status)
+	}
+	message := log.Message
+	if levelColors[log.Level] == colorRed {
+		message = p.paint(colorBold, message)
+	}
+	return strings.Join(append(parts, message), " ")
+}
+
+// timeline writes a timeline: a summary, the services and the records with
+// their offset, the errors highlighted
+func (p *printer) timeline(timeline *entities.Timeline) error {
+	if p.json {
+		return json.NewEncoder(p.w).Encode(timeline)
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s\n", p.paint(colorBold, "timeline"), timeline.ID)
+	fmt.Fprintf(&b, "%d records, %.1f ms, %s", len(timeline.Entries), timeline.Duration,
+		p.errors(timeline.Errors))
+	if timeline.Truncated {
+		b.WriteString(", truncated")
+	}
+	b.WriteString("\n")
+	if len(timeline.RequestIDs) > 0 {
+		fmt.Fprintf(&b, "requests: %s\n", strings.Join(timeline.RequestIDs, " "))
+	}
+	if len(timeline.TraceIDs) > 0 {
+		fmt.Fprintf(&b, "traces:   %s\n", strings.Join(timeline.TraceIDs, " "))
+	}
+
+	b.WriteString("\n")
+	for _, span := range timeline.Services {
+		fmt.Fprintf(&b, "  %s %4d requests %10.1f ms %4d records  %s\n",
+			p.paint(colorCyan, fmt.Sprintf("%-12s", span.Service)), span.Requests, span.Duration, span.Records, p.errors(span.Errors))
+	}
+
+	b.WriteString("\n")
+	for _, entry := range timeline.Entries {
+		marker := "  "
+		if entry.Error {
+			marker = p.paint(colorRed, "! ")
+		}
+		fmt.Fprintf(&b, "%s%+10.1fms %s\n", marker, entry.Offset, p.line(entry.Log))
+	}
+	_, err := io.WriteString(p.w, b.String())
+	return err
+}
+
+// errors formats an error count, red when there are some
+func (p *printer) errors(count int) string {
+	s := fmt.Sprintf("%d errors", count)
+	if count > 0 {
+		return p.paint(colorRed, s)
+	}
+	return s
+}
@@ -0,0 +1,33 @@
+package config
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/joho/godotenv"
+	"github.com/kelseyhightower/envconfig"
+	"gitlab.com/tuneverse/toolkit/internal/entities"
+)
+
+// LoadConfig loads the configuration for the application based on the given appName.
+// It uses environment variables and the "envconfig" package to populate the configuration struct.
+func LoadConfig(appName string) (*entities.EnvConfig, error) {
+
+	var cfg entities.EnvConfig
+
+	if _, err := os.Stat(".env"); err == nil {
+		println("[ENV] Load env variables from .env")
+		err := godotenv.Load()
+		if err != nil {
+			return nil, fmt.Errorf("error loading .env file: %w", err)
+		}
+
+	}
+
+	err := envconfig.Process(appName, &cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return &cfg, nil
+}
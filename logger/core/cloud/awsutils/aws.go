@@ -2,6 +2,8 @@ package awsutils
 
 import (
 	"context"
+	"errors"
+	"io"
 	"mime/multipart"
 
 	"gitlab.com/tuneverse/toolkit/core/awsmanager"
@@ -24,4 +26,9 @@ type CloudServiceImply interface {
 	DeleteObject(ctx context.Context, bucket, key string) error
 	GetObject(ctx context.Context, bucket, key string, expiration int) (string, error)
 	UploadToS3(bucketName, key string, fileHeader *multipart.FileHeader, contentType string) error
+	PutObject(ctx context.Context, bucket, key string, body io.Reader, contentType string) error
+	ReadObject(ctx context.Context, bucket, key string) (io.ReadCloser, error)
 }
+
+// ErrObjectNotFound is returned by ReadObject when the key does not exist
+var ErrObjectNotFound = errors.New("object not found")
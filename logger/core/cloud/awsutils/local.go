@@ -0,0 +1,109 @@
+package awsutils
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// LocalCloudService stores the objects in a directory, one sub directory per
+// bucket. It stands in for S3 in development and in tests.
+type LocalCloudService struct {
+	dir string
+}
+
+// NewLocalCloudService creates a LocalCloudService storing the objects in dir
+func NewLocalCloudService(dir string) CloudServiceImply {
+	return &LocalCloudService{
+		dir: dir,
+	}
+}
+
+// path returns the file of an object, keys escaping the bucket are refused
+func (cloud *LocalCloudService) path(bucket, key string) (string, error) {
+	root := filepath.Join(cloud.dir, bucket)
+	path := filepath.Join(root, filepath.FromSlash(key))
+	if bucket == "" || key == "" || !strings.HasPrefix(path, root+string(filepath.Separator)) {
+		return "", fmt.Errorf("invalid object %s/%s", bucket, key)
+	}
+	return path, nil
+}
+
+// DeleteObject removes an object, a missing object is not an error
+func (cloud *LocalCloudService) DeleteObject(ctx context.Context, bucket, key string) error {
+	path, err := cloud.path(bucket, key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// GetObject returns the file URL of an object, expiration is ignored
+func (cloud *LocalCloudService) GetObject(ctx context.Context, bucket, key string, expiration int) (string, error) {
+	path, err := cloud.path(bucket, key)
+	if err != nil {
+		return "", err
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(abs)}).String(), nil
+}
+
+// UploadToS3 copies an uploaded file to an object
+func (cloud *LocalCloudService) UploadToS3(bucketName, key string, fileHeader *multipart.FileHeader, contentType string) error {
+	file, err := fileHeader.Open()
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return cloud.PutObject(context.TODO(), bucketName, key, file, contentType)
+}
+
+// PutObject writes the content of body to an object. The object is replaced
+// once body is fully written, a failed upload leaves the previous one.
+func (cloud *LocalCloudService) PutObject(ctx context.Context, bucket, key string, body io.Reader, contentType string) error {
+	path, err := cloud.path(bucket, key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+"-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := io.Copy(tmp, body); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// ReadObject opens an object, the caller closes it. ErrObjectNotFound is
+// returned when the key does not exist.
+func (cloud *LocalCloudService) ReadObject(ctx context.Context, bucket, key string) (io.ReadCloser, error) {
+	path, err := cloud.path(bucket, key)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, ErrObjectNotFound
+	}
+	return file, err
+}
@@ -2,12 +2,14 @@ package awsutils
 
 import (
 	"context"
-
+	"errors"
+	"io"
 	"mime/multipart"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
 	"gitlab.com/tuneverse/toolkit/core/logger"
 )
 
@@ -62,3 +64,38 @@ func (cl *CloudService) UploadToS3(bucketName, key string, fileHeader *multipart
 	}
 	return nil
 }
+
+// PutObject uploads the content of body to an AWS S3 bucket. The body is
+// streamed, large objects are sent in parts.
+func (cloud *CloudService) PutObject(ctx context.Context, bucket, key string, body io.Reader, contentType string) error {
+	_, err := cloud.awsConf.Uploader().Upload(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(bucket),
+		Key:         aws.String(key),
+		Body:        body,
+		ContentType: aws.String(contentType),
+	})
+	if err != nil {
+		logger.Log().WithContext(ctx).Errorf("PutObject failed, err=%s", err.Error())
+		return err
+	}
+	return nil
+}
+
+// ReadObject returns the content of an object in an AWS S3 bucket, the
+// caller closes it. ErrObjectNotFound is returned when the key does not
+// exist.
+func (cloud *CloudService) ReadObject(ctx context.Context, bucket, key string) (io.ReadCloser, error) {
+	out, err := cloud.awsConf.S3().GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		var noSuchKey *types.NoSuchKey
+		if errors.As(err, &noSuchKey) {
+			return nil, ErrObjectNotFound
+		}
+		logger.Log().WithContext(ctx).Errorf("ReadObject failed, err=%s", err.Error())
+		return nil, err
+	}
+	return out.Body, nil
+}
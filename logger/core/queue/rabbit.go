@@ -73,6 +73,9 @@ type RabbitMQConfig struct {
 	// Additional arguments and configuration options for message consumption,
 	// such as message headers and other properties.
 	Args amqp.Table
+	// PrefetchCount is the number of unacknowledged messages the server
+	// delivers to the consumer at once, 0 leaves it unlimited.
+	PrefetchCount int
 }
 
 // RabbitMQQueue contains a reference to the AMQP connection used for communication with the RabbitMQ server,
@@ -197,6 +200,13 @@ func (rabbitMQQueue *RabbitMQQueue) Send(ctx context.Context, input interface{})
 
 // Receive receives a message from the queue.
 func (rabbitMQQueue *RabbitMQQueue) Receive(ctx context.Context) (interface{}, error) {
+	if rabbitMQQueue.config.PrefetchCount > 0 {
+		// Limit the deliveries awaiting an acknowledgment
+		if err := rabbitMQQueue.channel.Qos(rabbitMQQueue.config.PrefetchCount, 0, false); err != nil {
+			return nil, err
+		}
+	}
+
 	msg, err := rabbitMQQueue.channel.ConsumeWithContext(
 		ctx,
 		rabbitMQQueue.config.Name,
@@ -0,0 +1,161 @@
+package consts
+
+import "time"
+
+// Constants defining fundamental properties and settings of the application.
+const (
+	AppName = "logger"
+)
+
+// Storage backends selected by LOGGER_STORAGE_TYPE
+const (
+	StorageFile  = "file"
+	StorageMongo = "mongo"
+)
+
+// Queue brokers selected by LOGGER_QUEUE_TYPE
+const (
+	QueueRabbitMQ = "rabbitmq"
+	QueueSQS      = "sqs"
+)
+
+// Archive backends selected by LOGGER_RETENTION_ARCHIVE_TYPE
+const (
+	ArchiveFile = "file"
+	ArchiveS3   = "s3"
+)
+
+// Response status keys
+const (
+	SuccessKey = "success"
+	Failure    = "failure"
+)
+
+// Response messages
+const (
+	HealthMsg          = "log service is running"
+	LogsListedMsg      = "logs listed"
+	LogsStoredMsg      = "logs stored"
+	LogsRejectedMsg    = "logs rejected"
+	BindingError       = "invalid request body"
+	EmptyBatchError    = "no logs in request"
+	BatchTooLargeError = "too many logs in request"
+	UnauthorizedError  = "invalid or missing token"
+	ValidationError    = "validation error"
+	InternalServerErr  = "internal server error"
+	LogsRestoredMsg    = "logs restored"
+	ArchiveNotFound    = "archive not found"
+	TooManyStreamsErr  = "too many live streams"
+	AlertsListedMsg    = "alerts listed"
+	TimelineMsg        = "timeline listed"
+	TimelineNotFound   = "no records for this id"
+)
+
+// Field validation errors
+const (
+	Required = "required"
+	Invalid  = "invalid"
+)
+
+// Ingestion limits
+const (
+	// MaxBatchSize is the number of records accepted by one request
+	MaxBatchSize = 1000
+	// MaxBodySize is the size in bytes of the largest request body accepted
+	MaxBodySize = 16 << 20
+)
+
+// Search settings
+const (
+	// FormatNDJSON is the format query value exporting the search results
+	FormatNDJSON = "ndjson"
+	// NDJSONContentType is the content type of an export
+	NDJSONContentType = "application/x-ndjson"
+	// ExportFileName is the file name suggested for an export
+	ExportFileName = "logs.ndjson"
+)
+
+// Timeline settings
+const (
+	// FormatHTML is the format query value rendering the timeline page
+	FormatHTML = "html"
+	// MaxTimelineRecords bounds the records of a timeline
+	MaxTimelineRecords = 10000
+	// MaxTimelineIDs bounds the request and trace ids followed by a timeline
+	MaxTimelineIDs = 100
+	// MaxIDLength is the length of the longest request or trace id
+	MaxIDLength = 128
+)
+
+// Storage settings
+const (
+	// LogFilePrefix and LogFileExt name the daily files of the file storage,
+	// e.g. logs-2024-01-31.ndjson
+	LogFilePrefix     = "logs-"
+	LogFileExt        = ".ndjson"
+	LogFileDateFormat = "2006-01-02"
+
+	// MongoTimeout bounds the connection and the ping to MongoDB
+	MongoTimeout = 10 * time.Second
+	// MongoDuplicateKey is the code of the write errors on a duplicate key
+	MongoDuplicateKey = 11000
+
+	// LogIDNamespace is the namespace of the name based UUIDs identifying
+	// the records by their content
+	LogIDNamespace = "c44e496b-c2f3-40dd-a0df-817c3cab672d"
+)
+
+// Archive settings
+const (
+	// ArchiveExt and ArchiveContentType describe the archive of a service
+	// day, e.g. collector/partner/2024-01-31.ndjson.gz
+	ArchiveExt         = ".ndjson.gz"
+	ArchiveContentType = "application/gzip"
+)
+
+// Live stream settings
+const (
+	// StreamBufferSize is the number of records buffered for a stream, a
+	// client falling further behind is disconnected
+	StreamBufferSize = 256
+	// MaxStreams is the number of streams served at once
+	MaxStreams = 100
+	// StreamHeartbeat is the period of the comments keeping an idle stream
+	// open through the proxies
+	StreamHeartbeat = 15 * time.Second
+	// StreamLogEvent and StreamEndEvent name the events of a stream
+	StreamLogEvent = "log"
+	StreamEndEvent = "end"
+)
+
+// Alert settings
+const (
+	// WebhookTimeout bounds a call to the alert webhook
+	WebhookTimeout = 10 * time.Second
+	// MaxAlertWindow is the longest window of a rule
+	MaxAlertWindow = 24 * time.Hour
+)
+
+// Queue settings
+const (
+	// QueueRetryDelay is the wait before a batch the storage failed to
+	// store, or a failed receive, is retried
+	QueueRetryDelay = 5 * time.Second
+	// SQSMaxMessages is the number of messages received at once from SQS
+	SQSMaxMessages = 10
+	// SQSWaitTime is the long polling wait of a receive, in seconds
+	SQSWaitTime = 20
+	// SQSVisibilityTimeout is the time in seconds a received message is
+	// hidden from the other consumers, it is redelivered once elapsed
+	// when it was not deleted
+	SQSVisibilityTimeout = 60
+)
+
+// Shutdown settings
+const (
+	// ShutdownTimeout is the time given to the running requests on shutdown
+	ShutdownTimeout = 5 * time.Second
+	// StorageCloseTimeout is the time given to the storage to close once the
+	// requests and the jobs are done
+	StorageCloseTimeout = 5 * time.Second
+)
@@ -0,0 +1,208 @@
+package consumers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	amqp "github.com/rabbitmq/amqp091-go"
+	"gitlab.com/tuneverse/toolkit/core/logger"
+	"gitlab.com/tuneverse/toolkit/core/queue"
+	"gitlab.com/tuneverse/toolkit/internal/consts"
+	"gitlab.com/tuneverse/toolkit/internal/usecases"
+)
+
+// ErrDeliveriesClosed is returned by Run when the broker ends the deliveries,
+// the connection to RabbitMQ was lost
+var ErrDeliveriesClosed = errors.New("queue deliveries closed")
+
+// LogConsumer ingests the batches the services publish to the log queue with
+// the CloudMode queue transport. A message is acknowledged only once its
+// records are stored; a batch the storage failed to store is delivered again.
+type LogConsumer struct {
+	queue    queue.Queue
+	useCases usecases.LogUseCaseImply
+	workers  int
+	// retryDelay is the wait before a failed batch or receive is retried
+	retryDelay time.Duration
+}
+
+// message is a batch received from the queue
+type message struct {
+	body []byte
+	// ack settles a stored batch
+	ack func() error
+	// drop removes a batch which can never be stored
+	drop func() error
+	// retry gives a batch back to the queue after a storage failure
+	retry func() error
+}
+
+// NewLogConsumer creates a new LogConsumer instance storing up to workers
+// batches at once.
+func NewLogConsumer(q queue.Queue, logUseCase usecases.LogUseCaseImply, workers int) *LogConsumer {
+	if workers < 1 {
+		workers = 1
+	}
+	return &LogConsumer{
+		queue:      q,
+		useCases:   logUseCase,
+		workers:    workers,
+		retryDelay: consts.QueueRetryDelay,
+	}
+}
+
+// Run consumes the queue until ctx is done, then waits for the batches being
+// stored. The messages received but not processed yet are delivered again
+// by the broker.
+func (c *LogConsumer) Run(ctx context.Context) error {
+	var (
+		messages = make(chan message)
+		wg       sync.WaitGroup
+	)
+	for i := 0; i < c.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for msg := range messages {
+				c.handle(msg)
+			}
+		}()
+	}
+	err := c.receive(ctx, messages)
+	close(messages)
+	wg.Wait()
+	return err
+}
+
+// receive hands the messages of the queue to the workers. RabbitMQ pushes
+// its deliveries on a channel, SQS is polled.
+func (c *LogConsumer) receive(ctx context.Context, messages chan<- message) error {
+	received, err := c.queue.Receive(ctx)
+	if err != nil {
+		return fmt.Errorf("receiving from the queue failed: %w", err)
+	}
+	switch deliveries := received.(type) {
+	case <-chan amqp.Delivery:
+		for {
+			select {
+			case <-ctx.Done():
+				return nil
+			case delivery, ok := <-deliveries:
+				if !ok {
+					if ctx.Err() != nil {
+						return nil
+					}
+					return ErrDeliveriesClosed
+				}
+				messages <- c.rabbitMessage(ctx, delivery)
+			}
+		}
+	case *sqs.ReceiveMessageOutput:
+		for {
+			for _, msg := range deliveries.Messages {
+				messages <- c.sqsMessage(msg.Body, msg.ReceiptHandle)
+			}
+			if ctx.Err() != nil {
+				return nil
+			}
+			received, err := c.queue.Receive(ctx)
+			if err != nil {
+				if ctx.Err() != nil {
+					return nil
+				}
+				logger.Log().Errorf("[LogConsumer][receive] receiving from the queue failed, Error : %s", err.Error())
+				c.wait(ctx)
+				deliveries = &sqs.ReceiveMessageOutput{}
+				continue
+			}
+			deliveries = received.(*sqs.ReceiveMessageOutput)
+		}
+	default:
+		return fmt.Errorf("unsupported queue messages %T", received)
+	}
+}
+
+// handle stores the records of a message and settles it
+func (c *LogConsumer) handle(msg message) {
+	// the batch is stored even when the consumer stops meanwhile, it
+	// would be delivered again otherwise
+	ctx := context.Background()
+	log := logger.Log().WithContext(ctx)
+
+	records, err := usecases.DecodeBatch(msg.body)
+	if err != nil {
+		log.Warnf("[LogConsumer][handle] dropping an invalid batch, Error : %s", err.Error())
+		if err := msg.drop(); err != nil {
+			log.Errorf("[LogConsumer][handle] dropping the batch failed, Error : %s", err.Error())
+		}
+		return
+	}
+	if _, err := c.useCases.IngestLogs(ctx, records); err != nil {
+		// IngestLogs logged the failure
+		if err := msg.retry(); err != nil {
+			log.Errorf("[LogConsumer][handle] giving the batch back failed, Error : %s", err.Error())
+		}
+		return
+	}
+	// the rejected records are logged by IngestLogs, they would be rejected
+	// again
+	if err := msg.ack(); err != nil {
+		log.Errorf("[LogConsumer][handle] acknowledging the batch failed, Error : %s", err.Error())
+	}
+}
+
+// rabbitMessage wraps a RabbitMQ delivery. A failed batch is requeued after
+// the retry delay, so a storage outage does not spin on the same messages,
+// or as soon as ctx is done so the consumer stops without waiting for it.
+func (c *LogConsumer) rabbitMessage(ctx context.Context, delivery amqp.Delivery) message {
+	return message{
+		body: delivery.Body,
+		ack: func() error {
+			return delivery.Ack(false)
+		},
+		drop: func() error {
+			return c.queue.Delete(context.Background(), strconv.FormatUint(delivery.DeliveryTag, 10))
+		},
+		retry: func() error {
+			c.wait(ctx)
+			return delivery.Nack(false, true)
+		},
+	}
+}
+
+// sqsMessage wraps an SQS message. A failed batch is left in the queue, SQS
+// delivers it again once its visibility timeout elapsed.
+func (c *LogConsumer) sqsMessage(body, receiptHandle *string) message {
+	remove := func() error {
+		if receiptHandle == nil {
+			return errors.New("missing receipt handle")
+		}
+		return c.queue.Delete(context.Background(), *receiptHandle)
+	}
+	msg := message{
+		ack:  remove,
+		drop: remove,
+		retry: func() error {
+			return nil
+		},
+	}
+	if body != nil {
+		msg.body = []byte(*body)
+	}
+	return msg
+}
+
+// wait sleeps for the retry delay or until ctx is done
+func (c *LogConsumer) wait(ctx context.Context) {
+	timer := time.NewTimer(c.retryDelay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+	case <-timer.C:
+	}
+}
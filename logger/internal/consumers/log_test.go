@@ -0,0 +1,203 @@
+package consumers
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/stretchr/testify/require"
+	"gitlab.com/tuneverse/toolkit/core/logger"
+	"gitlab.com/tuneverse/toolkit/internal/consts"
+	"gitlab.com/tuneverse/toolkit/internal/entities"
+	"gitlab.com/tuneverse/toolkit/internal/repo"
+	"gitlab.com/tuneverse/toolkit/internal/usecases"
+)
+
+// fakeQueue serves received once, then blocks until the receive is
+// cancelled. It records the settlement of every message by its tag.
+type fakeQueue struct {
+	received interface{}
+	once     sync.Once
+
+	mu      sync.Mutex
+	settled map[string]string
+}
+
+func (q *fakeQueue) ComposeMessage(ctx context.Context, message []byte) (interface{}, error) {
+	return message, nil
+}
+
+func (q *fakeQueue) Send(ctx context.Context, message interface{}) error { return nil }
+
+func (q *fakeQueue) Receive(ctx context.Context) (interface{}, error) {
+	var received interface{}
+	q.once.Do(func() { received = q.received })
+	if received != nil {
+		return received, nil
+	}
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func (q *fakeQueue) Delete(ctx context.Context, receiptHandle string) error {
+	q.settle(receiptHandle, "deleted")
+	return nil
+}
+
+func (q *fakeQueue) Close() error { return nil }
+
+func (q *fakeQueue) settle(tag, how string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.settled[tag] = how
+}
+
+func (q *fakeQueue) settlements() map[string]string {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	settled := make(map[string]string, len(q.settled))
+	for tag, how := range q.settled {
+		settled[tag] = how
+	}
+	return settled
+}
+
+// Ack, Nack and Reject settle the RabbitMQ deliveries
+func (q *fakeQueue) Ack(tag uint64, multiple bool) error {
+	q.settle(strconv.FormatUint(tag, 10), "acked")
+	return nil
+}
+
+func (q *fakeQueue) Nack(tag uint64, multiple, requeue bool) error {
+	q.settle(strconv.FormatUint(tag, 10), "requeued")
+	return nil
+}
+
+func (q *fakeQueue) Reject(tag uint64, requeue bool) error {
+	q.settle(strconv.FormatUint(tag, 10), "rejected")
+	return nil
+}
+
+// failingUseCases fails the storage of the batches holding a failing record
+type failingUseCases struct {
+	usecases.LogUseCaseImply
+}
+
+func (f failingUseCases) IngestLogs(ctx context.Context, records []map[string]interface{}) (entities.IngestResult, error) {
+	for _, record := range records {
+		if record["message"] == "storage down" {
+			return entities.IngestResult{}, errors.New("storage down")
+		}
+	}
+	return f.LogUseCaseImply.IngestLogs(ctx, records)
+}
+
+func batch(message string) string {
+	return `{"logs": [{"message": "` + message + `", "service": "partner", "log_level": "info", "timestamp": "2024-01-31T10:00:00Z"}]}`
+}
+
+func newConsumer(t *testing.T, q *fakeQueue) (*LogConsumer, repo.LogRepoImply) {
+	logger.InitLogger(&logger.ClientOptions{Service: consts.AppName, LogLevel: "panic"})
+	logRepo, err := repo.NewFileLogRepo(t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = logRepo.Close(context.Background()) })
+	useCases := failingUseCases{usecases.NewLogUseCases(logRepo, usecases.NewHub(1, 1), nil)}
+	consumer := NewLogConsumer(q, useCases, 2)
+	consumer.retryDelay = time.Millisecond
+	return consumer, logRepo
+}
+
+// run consumes until every message is settled
+func run(t *testing.T, consumer *LogConsumer, q *fakeQueue, messages int) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- consumer.Run(ctx) }()
+	require.Eventually(t, func() bool { return len(q.settlements()) == messages }, 2*time.Second, 5*time.Millisecond)
+	cancel()
+	require.NoError(t, <-done)
+}
+
+func stored(t *testing.T, logRepo repo.LogRepoImply) []string {
+	logs, _, err := logRepo.SearchLogs(context.Background(), entities.LogFilter{}, entities.Pagination{Page: 1, Limit: 10})
+	require.NoError(t, err)
+	var messages []string
+	for _, log := range logs {
+		messages = append(messages, log.Message)
+	}
+	return messages
+}
+
+func TestLogConsumer(t *testing.T) {
+	bodies := []string{batch("stored"), "not json", batch("storage down"), `{"logs": []}`}
+
+	t.Run("rabbitmq", func(t *testing.T) {
+		q := &fakeQueue{settled: map[string]string{}}
+		deliveries := make(chan amqp.Delivery, len(bodies))
+		for i, body := range bodies {
+			deliveries <- amqp.Delivery{Acknowledger: q, DeliveryTag: uint64(i + 1), Body: []byte(body)}
+		}
+		q.received = (<-chan amqp.Delivery)(deliveries)
+		consumer, logRepo := newConsumer(t, q)
+
+		run(t, consumer, q, len(bodies))
+		require.Equal(t, map[string]string{"1": "acked", "2": "deleted", "3": "requeued", "4": "deleted"}, q.settlements())
+		require.Equal(t, []string{"stored"}, stored(t, logRepo))
+	})
+
+	t.Run("sqs", func(t *testing.T) {
+		q := &fakeQueue{settled: map[string]string{}}
+		output := &sqs.ReceiveMessageOutput{}
+		for i, body := range bodies {
+			output.Messages = append(output.Messages, types.Message{
+				Body:          aws.String(body),
+				ReceiptHandle: aws.String(strconv.Itoa(i + 1)),
+			})
+		}
+		q.received = output
+		consumer, logRepo := newConsumer(t, q)
+
+		// the failed batch is left to the visibility timeout
+		run(t, consumer, q, len(bodies)-1)
+		require.Equal(t, map[string]string{"1": "deleted", "2": "deleted", "4": "deleted"}, q.settlements())
+		require.Equal(t, []string{"stored"}, stored(t, logRepo))
+	})
+
+	t.Run("stop interrupts the retry delay", func(t *testing.T) {
+		q := &fakeQueue{settled: map[string]string{}}
+		deliveries := make(chan amqp.Delivery, 1)
+		deliveries <- amqp.Delivery{Acknowledger: q, DeliveryTag: 1, Body: []byte(batch("storage down"))}
+		q.received = (<-chan amqp.Delivery)(deliveries)
+		consumer, _ := newConsumer(t, q)
+		consumer.retryDelay = time.Hour
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error)
+		go func() { done <- consumer.Run(ctx) }()
+		// a received delivery is always handed to a worker
+		require.Eventually(t, func() bool { return len(deliveries) == 0 }, 2*time.Second, 5*time.Millisecond)
+		cancel()
+		select {
+		case err := <-done:
+			require.NoError(t, err)
+		case <-time.After(2 * time.Second):
+			t.Fatal("the retry delay blocked the stop")
+		}
+		require.Equal(t, map[string]string{"1": "requeued"}, q.settlements())
+	})
+
+	t.Run("closed deliveries", func(t *testing.T) {
+		q := &fakeQueue{settled: map[string]string{}}
+		deliveries := make(chan amqp.Delivery)
+		close(deliveries)
+		q.received = (<-chan amqp.Delivery)(deliveries)
+		consumer, _ := newConsumer(t, q)
+		require.ErrorIs(t, consumer.Run(context.Background()), ErrDeliveriesClosed)
+	})
+}
@@ -0,0 +1,36 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gitlab.com/tuneverse/toolkit/internal/consts"
+	"gitlab.com/tuneverse/toolkit/internal/usecases"
+	"gitlab.com/tuneverse/toolkit/internal/utilities"
+)
+
+// AlertController represents a controller responsible for the alert rules.
+type AlertController struct {
+	router   *gin.RouterGroup
+	useCases usecases.AlertUseCaseImply
+}
+
+// NewAlertController creates a new AlertController instance.
+func NewAlertController(router *gin.RouterGroup, alertUseCase usecases.AlertUseCaseImply) *AlertController {
+	return &AlertController{
+		router:   router,
+		useCases: alertUseCase,
+	}
+}
+
+// InitRoutes initializes and configures the alert routes
+func (a *AlertController) InitRoutes() {
+	a.router.GET("/alerts", a.ListAlerts)
+}
+
+// ListAlerts returns the rules with their current count and whether they
+// fire
+func (a *AlertController) ListAlerts(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK,
+		utilities.SuccessResponseGenerator(consts.AlertsListedMsg, http.StatusOK, a.useCases.ListAlerts(ctx.Request.Context())))
+}
@@ -0,0 +1,25 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gitlab.com/tuneverse/toolkit/core/logger"
+	"gitlab.com/tuneverse/toolkit/internal/consts"
+	"gitlab.com/tuneverse/toolkit/internal/utilities"
+)
+
+// HealthHandler is a handler responsible for handling health check requests.
+// The CloudMode of the services pings it at start and while logs are spooled.
+func (l *LogController) HealthHandler(ctx *gin.Context) {
+	if err := l.useCases.Health(ctx.Request.Context()); err != nil {
+		logger.Log().WithContext(ctx.Request.Context()).Errorf("[LogController][HealthHandler] storage unavailable, Error : %s", err.Error())
+		ctx.JSON(http.StatusServiceUnavailable,
+			utilities.ErrorResponseGenerator(consts.InternalServerErr, http.StatusServiceUnavailable, nil))
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{
+		"status":  consts.SuccessKey,
+		"message": consts.HealthMsg,
+	})
+}
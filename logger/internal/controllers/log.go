@@ -0,0 +1,247 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gitlab.com/tuneverse/toolkit/core/logger"
+	"gitlab.com/tuneverse/toolkit/internal/consts"
+	"gitlab.com/tuneverse/toolkit/internal/entities"
+	"gitlab.com/tuneverse/toolkit/internal/usecases"
+	"gitlab.com/tuneverse/toolkit/internal/utilities"
+)
+
+// LogController represents a controller responsible for handling the log
+// records sent by the services.
+type LogController struct {
+	router   *gin.RouterGroup
+	useCases usecases.LogUseCaseImply
+	cfg      *entities.EnvConfig
+}
+
+// NewLogController creates a new LogController instance.
+func NewLogController(router *gin.RouterGroup, logUseCase usecases.LogUseCaseImply, cfg *entities.EnvConfig) *LogController {
+	return &LogController{
+		router:   router,
+		useCases: logUseCase,
+		cfg:      cfg,
+	}
+}
+
+// InitRoutes initializes and configures the log-related routes for the
+// LogController. The routes are not versioned, CloudMode calls <URL>/health
+// and <URL>/logs.
+func (l *LogController) InitRoutes() {
+	l.router.GET("/health", l.HealthHandler)
+	l.router.POST("/logs", l.IngestLogs)
+	l.router.GET("/logs", l.SearchLogs)
+	l.router.GET("/logs/stream", l.StreamLogs)
+	l.router.GET("/logs/timeline/:id", l.Timeline)
+}
+
+// IngestLogs handles a batch of records, {"logs": [...]}, or a single record.
+// It answers 201 when at least one record was stored, the rejected records
+// are listed in the response data. A batch the storage failed to persist is
+// answered with 500 so the service retries it.
+func (l *LogController) IngestLogs(ctx *gin.Context) {
+	var (
+		ctxt = ctx.Request.Context()
+		log  = logger.Log().WithContext(ctxt)
+	)
+
+	body, err := io.ReadAll(http.MaxBytesReader(ctx.Writer, ctx.Request.Body, consts.MaxBodySize))
+	if err != nil {
+		log.Errorf("[LogController][IngestLogs] reading body failed, Error : %s", err.Error())
+		status := http.StatusBadRequest
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			status = http.StatusRequestEntityTooLarge
+		}
+		ctx.JSON(status, utilities.ErrorResponseGenerator(consts.BindingError, status, err.Error()))
+		return
+	}
+	records, err := usecases.DecodeBatch(body)
+	switch {
+	case errors.Is(err, usecases.ErrEmptyBatch):
+		ctx.JSON(http.StatusBadRequest,
+			utilities.ErrorResponseGenerator(consts.EmptyBatchError, http.StatusBadRequest, nil))
+		return
+	case errors.Is(err, usecases.ErrBatchTooLarge):
+		ctx.JSON(http.StatusRequestEntityTooLarge,
+			utilities.ErrorResponseGenerator(consts.BatchTooLargeError, http.StatusRequestEntityTooLarge, nil))
+		return
+	case err != nil:
+		log.Errorf("[LogController][IngestLogs] invalid body, Error : %s", err.Error())
+		ctx.JSON(http.StatusBadRequest,
+			utilities.ErrorResponseGenerator(consts.BindingError, http.StatusBadRequest, err.Error()))
+		return
+	}
+
+	result, err := l.useCases.IngestLogs(ctxt, records)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError,
+			utilities.ErrorResponseGenerator(consts.InternalServerErr, http.StatusInternalServerError, nil))
+		return
+	}
+	if result.Accepted == 0 {
+		ctx.JSON(http.StatusBadRequest,
+			utilities.ErrorResponseGenerator(consts.LogsRejectedMsg, http.StatusBadRequest, result.Errors))
+		return
+	}
+	ctx.JSON(http.StatusCreated,
+		utilities.SuccessResponseGenerator(consts.LogsStoredMsg, http.StatusCreated, result))
+}
+
+// SearchLogs handles the search of the records. The records are listed a page
+// at a time, newest first; with format=ndjson every matching record is
+// streamed instead, oldest first, one JSON document per line.
+func (l *LogController) SearchLogs(ctx *gin.Context) {
+	var (
+		ctxt       = ctx.Request.Context()
+		log        = logger.Log().WithContext(ctxt)
+		filter     entities.LogFilter
+		pagination entities.Pagination
+	)
+
+	if err := ctx.ShouldBindQuery(&filter); err != nil {
+		log.Errorf("[LogController][SearchLogs] invalid query params, Error : %s", err.Error())
+		ctx.JSON(http.StatusBadRequest,
+			utilities.ErrorResponseGenerator(consts.BindingError, http.StatusBadRequest, err.Error()))
+		return
+	}
+	if err := ctx.ShouldBindQuery(&pagination); err != nil {
+		log.Errorf("[LogController][SearchLogs] invalid pagination, Error : %s", err.Error())
+		ctx.JSON(http.StatusBadRequest,
+			utilities.ErrorResponseGenerator(consts.BindingError, http.StatusBadRequest, err.Error()))
+		return
+	}
+
+	if filter.Format == consts.FormatNDJSON {
+		l.exportLogs(ctx, filter)
+		return
+	}
+
+	resp, errs, err := l.useCases.SearchLogs(ctxt, filter, pagination)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError,
+			utilities.ErrorResponseGenerator(consts.InternalServerErr, http.StatusInternalServerError, nil))
+		return
+	}
+	if len(errs) > 0 {
+		ctx.JSON(http.StatusBadRequest,
+			utilities.ErrorResponseGenerator(consts.ValidationError, http.StatusBadRequest, errs))
+		return
+	}
+	if resp.MetaData == nil {
+		ctx.JSON(http.StatusNoContent,
+			utilities.SuccessResponseGenerator(consts.LogsListedMsg, http.StatusNoContent, nil))
+		return
+	}
+	ctx.JSON(http.StatusOK,
+		utilities.SuccessResponseGenerator(consts.LogsListedMsg, http.StatusOK, entities.Result{
+			Metadata: resp.MetaData,
+			Data:     resp.Data,
+		}))
+}
+
+// exportLogs streams the matching records as NDJSON. Once the first record
+// is written the status can not change anymore, a later failure ends the
+// stream early and is only logged.
+func (l *LogController) exportLogs(ctx *gin.Context, filter entities.LogFilter) {
+	var (
+		ctxt    = ctx.Request.Context()
+		log     = logger.Log().WithContext(ctxt)
+		encoder = json.NewEncoder(ctx.Writer)
+		started bool
+	)
+	start := func() {
+		if started {
+			return
+		}
+		started = true
+		ctx.Header("Content-Type", consts.NDJSONContentType)
+		ctx.Header("Content-Disposition", `attachment; filename="`+consts.ExportFileName+`"`)
+		ctx.Status(http.StatusOK)
+	}
+
+	errs, err := l.useCases.ExportLogs(ctxt, filter, func(record entities.Log) error {
+		start()
+		return encoder.Encode(record)
+	})
+	switch {
+	case len(errs) > 0:
+		ctx.JSON(http.StatusBadRequest,
+			utilities.ErrorResponseGenerator(consts.ValidationError, http.StatusBadRequest, errs))
+	case err != nil && !started:
+		ctx.JSON(http.StatusInternalServerError,
+			utilities.ErrorResponseGenerator(consts.InternalServerErr, http.StatusInternalServerError, nil))
+	case err != nil:
+		log.Errorf("[LogController][SearchLogs] export interrupted, Error : %s", err.Error())
+	default:
+		start()
+	}
+}
+
+// StreamLogs streams the records stored from now on as Server-Sent Events,
+// one "log" event per record. It takes the filters of the search. A client
+// falling behind gets an "end" event and is disconnected, so it does not
+// slow the ingestion down; it can reconnect. Comments are sent while no
+// record matches so the proxies keep the connection open.
+func (l *LogController) StreamLogs(ctx *gin.Context) {
+	var (
+		ctxt   = ctx.Request.Context()
+		log    = logger.Log().WithContext(ctxt)
+		filter entities.LogFilter
+	)
+
+	if err := ctx.ShouldBindQuery(&filter); err != nil {
+		log.Errorf("[LogController][StreamLogs] invalid query params, Error : %s", err.Error())
+		ctx.JSON(http.StatusBadRequest,
+			utilities.ErrorResponseGenerator(consts.BindingError, http.StatusBadRequest, err.Error()))
+		return
+	}
+	sub, errs, err := l.useCases.SubscribeLogs(ctxt, filter)
+	switch {
+	case len(errs) > 0:
+		ctx.JSON(http.StatusBadRequest,
+			utilities.ErrorResponseGenerator(consts.ValidationError, http.StatusBadRequest, errs))
+		return
+	case err != nil:
+		ctx.JSON(http.StatusServiceUnavailable,
+			utilities.ErrorResponseGenerator(consts.TooManyStreamsErr, http.StatusServiceUnavailable, nil))
+		return
+	}
+	defer sub.Close()
+
+	ctx.Header("Content-Type", "text/event-stream")
+	ctx.Header("Cache-Control", "no-cache")
+	ctx.Header("X-Accel-Buffering", "no")
+	ctx.Status(http.StatusOK)
+	ctx.Writer.Flush()
+
+	heartbeat := time.NewTicker(consts.StreamHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-ctxt.Done():
+			return
+		case <-sub.Done():
+			if err := sub.Err(); err != nil {
+				log.Warnf("[LogController][StreamLogs] stream ended, Error : %s", err.Error())
+				ctx.SSEvent(consts.StreamEndEvent, gin.H{"reason": err.Error()})
+				ctx.Writer.Flush()
+			}
+			return
+		case record := <-sub.Logs():
+			ctx.SSEvent(consts.StreamLogEvent, record)
+			ctx.Writer.Flush()
+		case <-heartbeat.C:
+			_, _ = io.WriteString(ctx.Writer, ": ping\n\n")
+			ctx.Writer.Flush()
+		}
+	}
+}
@@ -0,0 +1,206 @@
+package controllers_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"gitlab.com/tuneverse/toolkit/core/logger"
+	"gitlab.com/tuneverse/toolkit/internal/consts"
+	"gitlab.com/tuneverse/toolkit/internal/controllers"
+	"gitlab.com/tuneverse/toolkit/internal/entities"
+	"gitlab.com/tuneverse/toolkit/internal/middlewares"
+	"gitlab.com/tuneverse/toolkit/internal/repo"
+	"gitlab.com/tuneverse/toolkit/internal/usecases"
+	"gitlab.com/tuneverse/toolkit/models/api"
+	"gitlab.com/tuneverse/toolkit/utils"
+)
+
+const (
+	secret = "collector-secret"
+	bucket = "logs"
+	prefix = "collector"
+)
+
+// newCollector wires the collector the way app.Run does, on a file storage
+func newCollector(t *testing.T) (*gin.Engine, string) {
+	gin.SetMode(gin.TestMode)
+	logger.InitLogger(&logger.ClientOptions{Service: consts.AppName, LogLevel: "panic"})
+
+	dir := t.TempDir()
+	cfg := &entities.EnvConfig{
+		Secret:  secret,
+		Storage: entities.Storage{Type: consts.StorageFile, Path: dir},
+		Retention: entities.Retention{
+			Days:        30,
+			RestoreHold: time.Hour,
+			Archive:     entities.Archive{Type: consts.ArchiveFile, Path: filepath.Join(dir, "archive"), Bucket: bucket, Prefix: prefix},
+		},
+	}
+	logRepo, err := repo.NewFileLogRepo(dir)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = logRepo.Close(context.Background()) })
+	archiveRepo, err := repo.NewArchiveRepo(cfg.Retention.Archive)
+	require.NoError(t, err)
+
+	router := gin.New()
+	api := router.Group("/")
+	api.Use(middlewares.NewMiddlewares(cfg).Authenticate())
+	controllers.NewLogController(api, usecases.NewLogUseCases(logRepo, usecases.NewHub(consts.StreamBufferSize, consts.MaxStreams), nil), cfg).InitRoutes()
+	controllers.NewRetentionController(api, usecases.NewRetentionUseCases(logRepo, archiveRepo, cfg.Retention)).InitRoutes()
+	return router, dir
+}
+
+// call sends a request with the token and decodes the response
+func call(t *testing.T, router *gin.Engine, method, path, token string, body interface{}) (int, api.Response) {
+	var payload []byte
+	switch b := body.(type) {
+	case nil:
+	case string:
+		payload = []byte(b)
+	default:
+		var err error
+		payload, err = json.Marshal(b)
+		require.NoError(t, err)
+	}
+	req := httptest.NewRequest(method, path, bytes.NewReader(payload))
+	if token != "" {
+		req.Header.Set("Authorization", token)
+	}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	var resp api.Response
+	_ = json.Unmarshal(rec.Body.Bytes(), &resp)
+	return rec.Code, resp
+}
+
+func record(message string) map[string]interface{} {
+	return map[string]interface{}{
+		"message":   message,
+		"service":   "partner",
+		"log_level": "error",
+		"timestamp": "2024-01-31T10:00:00Z",
+	}
+}
+
+func TestLogController(t *testing.T) {
+	router, dir := newCollector(t)
+	token, err := utils.GenerateJWTAuthToken(secret, map[string]interface{}{})
+	require.NoError(t, err)
+
+	t.Run("health", func(t *testing.T) {
+		code, _ := call(t, router, http.MethodGet, "/health", token, nil)
+		require.Equal(t, http.StatusOK, code)
+		code, _ = call(t, router, http.MethodGet, "/health", "Bearer "+token, nil)
+		require.Equal(t, http.StatusOK, code)
+	})
+
+	t.Run("token is required", func(t *testing.T) {
+		forged, err := utils.GenerateJWTAuthToken("other-secret", map[string]interface{}{})
+		require.NoError(t, err)
+		expired, err := utils.GenerateJWTAuthToken(secret, map[string]interface{}{"exp": time.Now().Add(-time.Minute).Unix()})
+		require.NoError(t, err)
+
+		for _, token := range []string{"", "not-a-token", forged, expired} {
+			code, resp := call(t, router, http.MethodPost, "/logs", token, map[string]interface{}{
+				"logs": []interface{}{record("denied")},
+			})
+			require.Equal(t, http.StatusUnauthorized, code)
+			require.Equal(t, consts.Failure, resp.Status)
+		}
+	})
+
+	t.Run("batch", func(t *testing.T) {
+		invalid := record("invalid")
+		delete(invalid, "service")
+		code, resp := call(t, router, http.MethodPost, "/logs", token, map[string]interface{}{
+			"logs": []interface{}{record("first"), invalid, record("second")},
+		})
+		require.Equal(t, http.StatusCreated, code)
+		data := resp.Data.(map[string]interface{})
+		require.Equal(t, float64(2), data["accepted"])
+		require.Equal(t, float64(1), data["rejected"])
+		require.Equal(t, map[string]interface{}{"logs[1].service": consts.Required}, data["errors"])
+	})
+
+	t.Run("single record", func(t *testing.T) {
+		code, _ := call(t, router, http.MethodPost, "/logs", token, record("single"))
+		require.Equal(t, http.StatusCreated, code)
+
+		files, err := filepath.Glob(filepath.Join(dir, "logs-*.ndjson"))
+		require.NoError(t, err)
+		require.Len(t, files, 1)
+		data, err := os.ReadFile(files[0])
+		require.NoError(t, err)
+		require.Equal(t, 3, bytes.Count(data, []byte("\n")))
+	})
+
+	t.Run("rejected", func(t *testing.T) {
+		invalid := record("invalid")
+		invalid["log_level"] = "loud"
+		code, resp := call(t, router, http.MethodPost, "/logs", token, map[string]interface{}{
+			"logs": []interface{}{invalid},
+		})
+		require.Equal(t, http.StatusBadRequest, code)
+		require.Equal(t, map[string]interface{}{"logs[0].log_level": consts.Invalid}, resp.Errors)
+
+		for _, body := range []interface{}{"not json", map[string]interface{}{"logs": []interface{}{}}, "[]"} {
+			code, _ := call(t, router, http.MethodPost, "/logs", token, body)
+			require.Equal(t, http.StatusBadRequest, code)
+		}
+	})
+
+	t.Run("search", func(t *testing.T) {
+		code, resp := call(t, router, http.MethodGet, "/logs?service=partner&log_level=error&q=SINGLE", token, nil)
+		require.Equal(t, http.StatusOK, code)
+		data := resp.Data.(map[string]interface{})
+		require.Equal(t, float64(1), data["metadata"].(map[string]interface{})["total"])
+		records := data["records"].([]interface{})
+		require.Len(t, records, 1)
+		require.Equal(t, "single", records[0].(map[string]interface{})["message"])
+
+		code, _ = call(t, router, http.MethodGet, "/logs?service=catalog", token, nil)
+		require.Equal(t, http.StatusNoContent, code)
+
+		code, resp = call(t, router, http.MethodGet, "/logs?log_level=loud", token, nil)
+		require.Equal(t, http.StatusBadRequest, code)
+		require.Equal(t, map[string]interface{}{"log_level": consts.Invalid}, resp.Errors)
+
+		code, _ = call(t, router, http.MethodGet, "/logs?from=yesterday", token, nil)
+		require.Equal(t, http.StatusBadRequest, code)
+
+		code, _ = call(t, router, http.MethodGet, "/logs", "", nil)
+		require.Equal(t, http.StatusUnauthorized, code)
+	})
+
+	t.Run("export", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/logs?format=ndjson&to=2024-02-01T00:00:00Z", nil)
+		req.Header.Set("Authorization", token)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Equal(t, consts.NDJSONContentType, rec.Header().Get("Content-Type"))
+		lines := bytes.Split(bytes.TrimSpace(rec.Body.Bytes()), []byte("\n"))
+		require.Len(t, lines, 3)
+		var first entities.Log
+		require.NoError(t, json.Unmarshal(lines[0], &first))
+		require.Equal(t, "first", first.Message)
+
+		req = httptest.NewRequest(http.MethodGet, "/logs?format=ndjson&service=catalog", nil)
+		req.Header.Set("Authorization", token)
+		rec = httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Empty(t, rec.Body.Bytes())
+	})
+}
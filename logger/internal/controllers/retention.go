@@ -0,0 +1,53 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gitlab.com/tuneverse/toolkit/internal/consts"
+	"gitlab.com/tuneverse/toolkit/internal/repo"
+	"gitlab.com/tuneverse/toolkit/internal/usecases"
+	"gitlab.com/tuneverse/toolkit/internal/utilities"
+)
+
+// RetentionController represents a controller responsible for the archived
+// records.
+type RetentionController struct {
+	router   *gin.RouterGroup
+	useCases usecases.RetentionUseCaseImply
+}
+
+// NewRetentionController creates a new RetentionController instance.
+func NewRetentionController(router *gin.RouterGroup, retentionUseCase usecases.RetentionUseCaseImply) *RetentionController {
+	return &RetentionController{
+		router:   router,
+		useCases: retentionUseCase,
+	}
+}
+
+// InitRoutes initializes and configures the archive routes
+func (r *RetentionController) InitRoutes() {
+	r.router.POST("/logs/archives/:service/:day/restore", r.RestoreLogs)
+}
+
+// RestoreLogs restores the archived records of a service day, e.g.
+// POST /logs/archives/partner/2024-01-31/restore. The records can then be
+// searched as usual until the restore hold elapses.
+func (r *RetentionController) RestoreLogs(ctx *gin.Context) {
+	result, errs, err := r.useCases.RestoreLogs(ctx.Request.Context(), ctx.Param("service"), ctx.Param("day"))
+	switch {
+	case errors.Is(err, repo.ErrArchiveNotFound):
+		ctx.JSON(http.StatusNotFound,
+			utilities.ErrorResponseGenerator(consts.ArchiveNotFound, http.StatusNotFound, nil))
+	case err != nil:
+		ctx.JSON(http.StatusInternalServerError,
+			utilities.ErrorResponseGenerator(consts.InternalServerErr, http.StatusInternalServerError, nil))
+	case len(errs) > 0:
+		ctx.JSON(http.StatusBadRequest,
+			utilities.ErrorResponseGenerator(consts.ValidationError, http.StatusBadRequest, errs))
+	default:
+		ctx.JSON(http.StatusOK,
+			utilities.SuccessResponseGenerator(consts.LogsRestoredMsg, http.StatusOK, result))
+	}
+}
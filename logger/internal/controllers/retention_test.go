@@ -0,0 +1,59 @@
+package controllers_test
+
+import (
+	"context"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"gitlab.com/tuneverse/toolkit/core/cloud/awsutils"
+	"gitlab.com/tuneverse/toolkit/internal/consts"
+	"gitlab.com/tuneverse/toolkit/internal/entities"
+	"gitlab.com/tuneverse/toolkit/internal/repo"
+	"gitlab.com/tuneverse/toolkit/utils"
+)
+
+func TestRetentionController(t *testing.T) {
+	router, dir := newCollector(t)
+	token, err := utils.GenerateJWTAuthToken(secret, map[string]interface{}{})
+	require.NoError(t, err)
+
+	day := time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)
+	archive := repo.NewObjectArchiveRepo(awsutils.NewLocalCloudService(filepath.Join(dir, "archive")), bucket, prefix)
+	require.NoError(t, archive.WriteArchive(context.Background(), entities.LogDay{Service: "partner", Day: day}, []entities.Log{
+		{ID: "1", Timestamp: day.Add(time.Hour), Service: "partner", Level: "error", Message: "first"},
+		{ID: "2", Timestamp: day.Add(2 * time.Hour), Service: "partner", Level: "info", Message: "second"},
+	}))
+
+	t.Run("restore", func(t *testing.T) {
+		code, resp := call(t, router, http.MethodPost, "/logs/archives/partner/2024-01-31/restore", token, nil)
+		require.Equal(t, http.StatusOK, code)
+		require.Equal(t, float64(2), resp.Data.(map[string]interface{})["restored"])
+
+		code, resp = call(t, router, http.MethodGet, "/logs?service=partner", token, nil)
+		require.Equal(t, http.StatusOK, code)
+		require.Equal(t, float64(2), resp.Data.(map[string]interface{})["metadata"].(map[string]interface{})["total"])
+
+		// the records already restored are not stored twice
+		code, resp = call(t, router, http.MethodPost, "/logs/archives/partner/2024-01-31/restore", token, nil)
+		require.Equal(t, http.StatusOK, code)
+		require.Equal(t, float64(0), resp.Data.(map[string]interface{})["restored"])
+	})
+
+	t.Run("not archived", func(t *testing.T) {
+		code, resp := call(t, router, http.MethodPost, "/logs/archives/partner/2024-01-30/restore", token, nil)
+		require.Equal(t, http.StatusNotFound, code)
+		require.Equal(t, consts.ArchiveNotFound, resp.Message)
+	})
+
+	t.Run("invalid day", func(t *testing.T) {
+		code, resp := call(t, router, http.MethodPost, "/logs/archives/partner/yesterday/restore", token, nil)
+		require.Equal(t, http.StatusBadRequest, code)
+		require.Equal(t, map[string]interface{}{"day": consts.Invalid}, resp.Errors)
+
+		code, _ = call(t, router, http.MethodPost, "/logs/archives/partner/2024-01-31/restore", "", nil)
+		require.Equal(t, http.StatusUnauthorized, code)
+	})
+}
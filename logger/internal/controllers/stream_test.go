@@ -0,0 +1,60 @@
+package controllers_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gitlab.com/tuneverse/toolkit/internal/entities"
+	"gitlab.com/tuneverse/toolkit/utils"
+)
+
+func TestStreamLogs(t *testing.T) {
+	router, _ := newCollector(t)
+	srv := httptest.NewServer(router)
+	defer srv.Close()
+	token, err := utils.GenerateJWTAuthToken(secret, map[string]interface{}{})
+	require.NoError(t, err)
+
+	t.Run("records are streamed as they are stored", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, srv.URL+"/logs/stream?service=partner&log_level=error", nil)
+		require.NoError(t, err)
+		req.Header.Set("Authorization", token)
+		resp, err := srv.Client().Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+		other := record("other service")
+		other["service"] = "utility"
+		body, err := json.Marshal(map[string]interface{}{"logs": []interface{}{other, record("streamed")}})
+		require.NoError(t, err)
+		code, _ := call(t, router, http.MethodPost, "/logs", token, string(body))
+		require.Equal(t, http.StatusCreated, code)
+
+		reader := bufio.NewReader(resp.Body)
+		event, err := reader.ReadString('\n')
+		require.NoError(t, err)
+		require.Equal(t, "event:log\n", event)
+		data, err := reader.ReadString('\n')
+		require.NoError(t, err)
+		var log entities.Log
+		require.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(data, "data:")), &log))
+		require.Equal(t, "streamed", log.Message)
+	})
+
+	t.Run("same validation and auth as the search", func(t *testing.T) {
+		code, _ := call(t, router, http.MethodGet, "/logs/stream?log_level=loud", token, nil)
+		require.Equal(t, http.StatusBadRequest, code)
+
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/logs/stream", bytes.NewReader(nil)))
+		require.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+}
@@ -0,0 +1,88 @@
+package controllers
+
+import (
+	"html/template"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/render"
+	"gitlab.com/tuneverse/toolkit/core/logger"
+	"gitlab.com/tuneverse/toolkit/internal/consts"
+	"gitlab.com/tuneverse/toolkit/internal/entities"
+	"gitlab.com/tuneverse/toolkit/internal/utilities"
+)
+
+// timelinePage renders a timeline, the error records are highlighted
+var timelinePage = template.Must(template.New("timeline").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Timeline {{.ID}}</title>
+<style>
+body { font-family: sans-serif; font-size: 14px; margin: 2em; }
+table { border-collapse: collapse; margin-bottom: 2em; }
+th, td { border-bottom: 1px solid #ddd; padding: 4px 8px; text-align: left; vertical-align: top; }
+td.num { text-align: right; white-space: nowrap; }
+tr.error td { background: #fde2e2; color: #a00; }
+code { font-size: 12px; }
+</style>
+</head>
+<body>
+<h1>Timeline {{.ID}}</h1>
+<p>{{len .Entries}} records, {{printf "%.1f" .Duration}} ms, {{.Errors}} errors{{if .Truncated}}, truncated{{end}}</p>
+<p>Requests: {{range .RequestIDs}}<code>{{.}}</code> {{end}}<br>Traces: {{range .TraceIDs}}<code>{{.}}</code> {{end}}</p>
+<h2>Services</h2>
+<table>
+<tr><th>Service</th><th>Start</th><th>Requests</th><th>Duration (ms)</th><th>Records</th><th>Errors</th></tr>
+{{range .Services}}<tr{{if .Errors}} class="error"{{end}}><td>{{.Service}}</td><td>{{.Start.Format "15:04:05.000"}}</td><td class="num">{{.Requests}}</td><td class="num">{{printf "%.1f" .Duration}}</td><td class="num">{{.Records}}</td><td class="num">{{.Errors}}</td></tr>
+{{end}}</table>
+<h2>Records</h2>
+<table>
+<tr><th>+ms</th><th>Service</th><th>Level</th><th>Request</th><th>Endpoint</th><th>Status</th><th>Message</th></tr>
+{{range .Entries}}<tr{{if .Error}} class="error"{{end}}><td class="num">{{printf "%.1f" .Offset}}</td><td>{{.Log.Service}}</td><td>{{.Log.Level}}</td><td><code>{{.Log.RequestID}}</code></td><td>{{.Log.Method}} {{.Log.Endpoint}}</td><td>{{if .Log.Status}}{{.Log.Status}}{{end}}</td><td>{{.Log.Message}}</td></tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+// Timeline returns every record sharing the request or trace id, oldest
+// first, with a summary per service, e.g. GET /logs/timeline/<req_id>. With
+// format=html the timeline is rendered as a page.
+func (l *LogController) Timeline(ctx *gin.Context) {
+	var (
+		ctxt  = ctx.Request.Context()
+		log   = logger.Log().WithContext(ctxt)
+		query entities.TimelineQuery
+	)
+
+	if err := ctx.ShouldBindUri(&query); err != nil {
+		log.Errorf("[LogController][Timeline] invalid id, Error : %s", err.Error())
+		ctx.JSON(http.StatusBadRequest,
+			utilities.ErrorResponseGenerator(consts.BindingError, http.StatusBadRequest, err.Error()))
+		return
+	}
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		log.Errorf("[LogController][Timeline] invalid query params, Error : %s", err.Error())
+		ctx.JSON(http.StatusBadRequest,
+			utilities.ErrorResponseGenerator(consts.BindingError, http.StatusBadRequest, err.Error()))
+		return
+	}
+
+	timeline, errs, err := l.useCases.Timeline(ctxt, query)
+	switch {
+	case err != nil:
+		ctx.JSON(http.StatusInternalServerError,
+			utilities.ErrorResponseGenerator(consts.InternalServerErr, http.StatusInternalServerError, nil))
+	case len(errs) > 0:
+		ctx.JSON(http.StatusBadRequest,
+			utilities.ErrorResponseGenerator(consts.ValidationError, http.StatusBadRequest, errs))
+	case timeline == nil:
+		ctx.JSON(http.StatusNotFound,
+			utilities.ErrorResponseGenerator(consts.TimelineNotFound, http.StatusNotFound, nil))
+	case query.Format == consts.FormatHTML:
+		ctx.Render(http.StatusOK, render.HTML{Template: timelinePage, Data: timeline})
+	default:
+		ctx.JSON(http.StatusOK,
+			utilities.SuccessResponseGenerator(consts.TimelineMsg, http.StatusOK, timeline))
+	}
+}
@@ -0,0 +1,108 @@
+package controllers_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gitlab.com/tuneverse/toolkit/internal/consts"
+	"gitlab.com/tuneverse/toolkit/utils"
+)
+
+func TestTimeline(t *testing.T) {
+	router, _ := newCollector(t)
+	token, err := utils.GenerateJWTAuthToken(secret, map[string]interface{}{})
+	require.NoError(t, err)
+
+	const trace = "4bf92f3577b34da6a3ce929d0e0e4736"
+	entry := func(service, reqID, traceID, level, message, timestamp string, fields map[string]interface{}) map[string]interface{} {
+		r := map[string]interface{}{
+			"message":   message,
+			"service":   service,
+			"log_level": level,
+			"timestamp": timestamp,
+			"req_id":    reqID,
+		}
+		if traceID != "" {
+			r["trace_id"] = traceID
+		}
+		for key, value := range fields {
+			r[key] = value
+		}
+		return r
+	}
+	code, _ := call(t, router, http.MethodPost, "/logs", token, map[string]interface{}{"logs": []interface{}{
+		entry("partner", "r1", trace, "info", "started handling request", "2024-01-31T10:00:00Z", nil),
+		entry("utility", "r2", trace, "info", "started handling request", "2024-01-31T10:00:00.010Z", nil),
+		entry("utility", "r2", trace, "info", "completed handling request", "2024-01-31T10:00:00.050Z",
+			map[string]interface{}{"response_code": 200, "duration_ms": "40ms"}),
+		entry("partner", "r1", trace, "error", "onboarding failed", "2024-01-31T10:00:00.100Z", nil),
+		entry("partner", "r1", trace, "info", "completed handling request", "2024-01-31T10:00:00.120Z",
+			map[string]interface{}{"response_code": 500, "duration_ms": "120ms"}),
+		entry("partner", "r3", "", "error", "other request", "2024-01-31T10:00:00.060Z", nil),
+	}})
+	require.Equal(t, http.StatusCreated, code)
+
+	t.Run("by request id", func(t *testing.T) {
+		code, resp := call(t, router, http.MethodGet, "/logs/timeline/r1", token, nil)
+		require.Equal(t, http.StatusOK, code)
+		timeline := resp.Data.(map[string]interface{})
+		require.Equal(t, []interface{}{"r1", "r2"}, timeline["req_ids"])
+		require.Equal(t, []interface{}{trace}, timeline["trace_ids"])
+		require.Equal(t, float64(120), timeline["duration_ms"])
+		require.Equal(t, float64(2), timeline["errors"])
+
+		var messages []string
+		for _, e := range timeline["entries"].([]interface{}) {
+			e := e.(map[string]interface{})
+			record := e["record"].(map[string]interface{})
+			messages = append(messages, record["service"].(string)+" "+record["message"].(string))
+		}
+		require.Equal(t, []string{
+			"partner started handling request",
+			"utility started handling request",
+			"utility completed handling request",
+			"partner onboarding failed",
+			"partner completed handling request",
+		}, messages)
+
+		services := timeline["services"].([]interface{})
+		require.Len(t, services, 2)
+		partner, utility := services[0].(map[string]interface{}), services[1].(map[string]interface{})
+		require.Equal(t, "partner", partner["service"])
+		require.Equal(t, float64(120), partner["duration_ms"])
+		require.Equal(t, float64(2), partner["errors"])
+		require.Equal(t, "utility", utility["service"])
+		require.Equal(t, float64(40), utility["duration_ms"])
+		require.Equal(t, float64(1), utility["requests"])
+	})
+
+	t.Run("by trace id", func(t *testing.T) {
+		code, resp := call(t, router, http.MethodGet, "/logs/timeline/"+trace, token, nil)
+		require.Equal(t, http.StatusOK, code)
+		require.Len(t, resp.Data.(map[string]interface{})["entries"], 5)
+	})
+
+	t.Run("html", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/logs/timeline/r1?format=html", nil)
+		req.Header.Set("Authorization", token)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Contains(t, rec.Header().Get("Content-Type"), "text/html")
+		require.Contains(t, rec.Body.String(), `<tr class="error"><td class="num">100.0</td><td>partner</td>`)
+		require.Equal(t, 3, strings.Count(rec.Body.String(), `class="error"`))
+	})
+
+	t.Run("unknown id", func(t *testing.T) {
+		code, resp := call(t, router, http.MethodGet, "/logs/timeline/r9", token, nil)
+		require.Equal(t, http.StatusNotFound, code)
+		require.Equal(t, consts.TimelineNotFound, resp.Message)
+
+		code, resp = call(t, router, http.MethodGet, "/logs/timeline/r1?format=pdf", token, nil)
+		require.Equal(t, http.StatusBadRequest, code)
+		require.Equal(t, map[string]interface{}{"format": consts.Invalid}, resp.Errors)
+	})
+}
@@ -0,0 +1,74 @@
+package entities
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// AlertRule fires when more than Threshold records matching its filters are
+// received within Window. The filters are those of the search, an empty
+// one matches every record.
+type AlertRule struct {
+	Name      string   `json:"name"`
+	Service   string   `json:"service,omitempty"`
+	Level     string   `json:"log_level,omitempty"`
+	Endpoint  string   `json:"endpoint,omitempty"`
+	Status    int      `json:"response_code,omitempty"`
+	Message   string   `json:"q,omitempty"`
+	Threshold int      `json:"threshold"`
+	Window    Duration `json:"window"`
+}
+
+// AlertRules is the content of the rules file
+type AlertRules struct {
+	Rules []AlertRule `json:"rules"`
+}
+
+// Duration is a time.Duration written as a string such as "1m" or "90s"
+type Duration time.Duration
+
+// UnmarshalJSON parses a duration string
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return fmt.Errorf("duration must be a string such as \"1m\": %w", err)
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return err
+	}
+	*d = Duration(duration)
+	return nil
+}
+
+// MarshalJSON writes the duration as a string
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// Alert statuses sent to the webhook
+const (
+	AlertFiring   = "firing"
+	AlertResolved = "resolved"
+)
+
+// Alert is the notification sent to the webhook when a rule fires and when
+// it resolves. Fingerprint is the same for both, receivers deduplicate on
+// it.
+type Alert struct {
+	Status      string     `json:"status"`
+	Fingerprint string     `json:"fingerprint"`
+	Rule        AlertRule  `json:"rule"`
+	Count       int        `json:"count"`
+	StartsAt    time.Time  `json:"starts_at"`
+	EndsAt      *time.Time `json:"ends_at,omitempty"`
+}
+
+// AlertState is the state of a rule listed by GET /alerts
+type AlertState struct {
+	Rule     AlertRule  `json:"rule"`
+	Count    int        `json:"count"`
+	Firing   bool       `json:"firing"`
+	StartsAt *time.Time `json:"starts_at,omitempty"`
+}
@@ -0,0 +1,90 @@
+package entities
+
+import "time"
+
+// EnvConfig represents the configuration structure for the application.
+type EnvConfig struct {
+	Debug     bool      `default:"true" split_words:"true"`  // Indicates whether the application is in debug mode (default: true)
+	Port      int       `default:"8080" split_words:"true"`  // The port on which the server listens (default: 8080)
+	Secret    string    `required:"true" split_words:"true"` // HS256 secret shared with the CloudMode of the services (required)
+	Storage   Storage   `split_words:"true"`                 // Storage of the log records
+	Retention Retention `split_words:"true"`                 // Lifecycle of the stored records
+	Alerts    Alerts    `split_words:"true"`                 // Alerting on the received records
+	Queue     Queue     `split_words:"true"`                 // Queue the batches are consumed from
+}
+
+// Storage represents the storage configuration of the log records.
+type Storage struct {
+	Type  string `default:"file"`           // Backend of the records, file or mongo (default: file)
+	Path  string `default:"logs/collector"` // Directory of the file backend
+	Mongo Mongo  // MongoDB backend configuration
+}
+
+// Mongo represents the MongoDB configuration of the storage.
+type Mongo struct {
+	URI        string `default:"mongodb://localhost:27017"`
+	Database   string `default:"logs"`
+	Collection string `default:"logs"`
+}
+
+// Retention represents the lifecycle of the stored records. The records older
+// than the retention of their service are archived, one archive per service
+// and day, then purged.
+type Retention struct {
+	// Days the records are kept, 0 keeps them forever (default: 30)
+	Days int `default:"30"`
+	// Days per service overriding Days, e.g. partner:90,utility:14
+	Services map[string]int
+	// Period of the retention job (default: 24h)
+	Interval time.Duration `default:"24h"`
+	// Time the records restored from an archive are kept (default: 7 days)
+	RestoreHold time.Duration `default:"168h" split_words:"true"`
+	// Object store of the archives
+	Archive Archive
+}
+
+// Archive represents the object store of the archived records.
+type Archive struct {
+	// Backend of the archives, file or s3 (default: file)
+	Type string `default:"file"`
+	// Directory of the file backend
+	Path   string `default:"logs/archive"`
+	Bucket string `default:"tuneverse-logs"`
+	// Key prefix of the archives
+	Prefix string `default:"collector"`
+	// AWS settings of the s3 backend, the default credential chain is used
+	// when the keys are empty
+	Region       string
+	AccessKey    string `split_words:"true"`
+	AccessSecret string `split_words:"true"`
+}
+
+// Alerts represents the alerting on the received records.
+type Alerts struct {
+	// Path of the YAML or JSON rules file, alerting is off when empty. The
+	// file is reloaded when it changes.
+	Rules string
+	// URL the alerts are posted to
+	WebhookURL string `split_words:"true"`
+	// Period of the evaluation of the rules and of the reload check
+	// (default: 10s)
+	Interval time.Duration `default:"10s"`
+}
+
+// Queue represents the queue the services publish their batches to, consumed
+// next to POST /logs.
+type Queue struct {
+	// Broker, rabbitmq or sqs, the queue is not consumed when empty
+	Type string
+	// Name of the queue
+	Name string `default:"logs"`
+	// Connection string of RabbitMQ
+	URL string
+	// AWS settings of SQS, the default credential chain is used when the
+	// keys are empty
+	Region       string
+	AccessKey    string `split_words:"true"`
+	AccessSecret string `split_words:"true"`
+	// Number of batches stored at once (default: 4)
+	Consumers int `default:"4"`
+}
@@ -0,0 +1,78 @@
+package entities
+
+import "time"
+
+// Log is a log record received from a service. The fields used to search
+// the records are promoted, the others are kept in Fields.
+type Log struct {
+	ID         string                 `json:"id" bson:"_id"`
+	Timestamp  time.Time              `json:"timestamp" bson:"timestamp"`
+	Service    string                 `json:"service" bson:"service"`
+	Level      string                 `json:"log_level" bson:"log_level"`
+	Message    string                 `json:"message" bson:"message"`
+	RequestID  string                 `json:"req_id,omitempty" bson:"req_id,omitempty"`
+	TraceID    string                 `json:"trace_id,omitempty" bson:"trace_id,omitempty"`
+	SpanID     string                 `json:"span_id,omitempty" bson:"span_id,omitempty"`
+	Endpoint   string                 `json:"endpoint,omitempty" bson:"endpoint,omitempty"`
+	Method     string                 `json:"method,omitempty" bson:"method,omitempty"`
+	Status     int                    `json:"response_code,omitempty" bson:"response_code,omitempty"`
+	Fields     map[string]interface{} `json:"fields,omitempty" bson:"fields,omitempty"`
+	ReceivedAt time.Time              `json:"received_at" bson:"received_at"`
+	RestoredAt *time.Time             `json:"restored_at,omitempty" bson:"restored_at,omitempty"` // set on the records restored from an archive
+}
+
+// LogDay is the records of a service on a UTC day, the unit of the archives
+type LogDay struct {
+	Service string
+	Day     time.Time
+}
+
+// RetentionResult reports a run of the retention job
+type RetentionResult struct {
+	Days     int   `json:"days"`     // service days processed
+	Archived int   `json:"archived"` // records written to the archives
+	Purged   int64 `json:"purged"`   // records deleted from the storage
+	Failed   int   `json:"failed"`   // service days left for the next run
+}
+
+// RestoreResult reports the records restored from the archive of a day
+type RestoreResult struct {
+	Service  string `json:"service"`
+	Day      string `json:"day"`
+	Restored int    `json:"restored"`
+}
+
+// LogBatch is the body sent by the CloudMode of the services
+type LogBatch struct {
+	Logs []map[string]interface{} `json:"logs"`
+}
+
+// IngestResult reports the records stored and the ones rejected by the
+// schema validation. Errors are keyed by the record index and the field,
+// e.g. "logs[3].log_level".
+type IngestResult struct {
+	Accepted int               `json:"accepted"`
+	Rejected int               `json:"rejected"`
+	Errors   map[string]string `json:"errors,omitempty"`
+}
+
+// LogFilter holds the search parameters of GET /logs. The zero value of a
+// field does not filter. From is inclusive and To exclusive.
+type LogFilter struct {
+	Service   string    `form:"service"`
+	Level     string    `form:"log_level"`
+	RequestID string    `form:"req_id"`
+	TraceID   string    `form:"trace_id"`
+	Endpoint  string    `form:"endpoint"`
+	Status    int       `form:"response_code"`
+	Message   string    `form:"q"` // case insensitive part of the message
+	From      time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To        time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+	Format    string    `form:"format"` // ndjson exports every matching record
+}
+
+// Pagination holds the page requested by GET /logs
+type Pagination struct {
+	Page  int32 `form:"page,omitempty"`
+	Limit int32 `form:"limit,omitempty"`
+}
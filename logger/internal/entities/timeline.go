@@ -0,0 +1,49 @@
+package entities
+
+import "time"
+
+// TimelineQuery holds the parameters of GET /logs/timeline/:id. ID is a
+// request or a trace id, From and To bound the records like the search.
+type TimelineQuery struct {
+	ID     string    `uri:"id"`
+	From   time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To     time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+	Format string    `form:"format"` // html renders the page
+}
+
+// Timeline is every record sharing a request or a trace id with the queried
+// id, oldest first. The ids found on the records are followed, so the
+// requests a service made to the others are part of the timeline.
+type Timeline struct {
+	ID         string          `json:"id"`
+	RequestIDs []string        `json:"req_ids,omitempty"`
+	TraceIDs   []string        `json:"trace_ids,omitempty"`
+	Start      time.Time       `json:"start"`
+	End        time.Time       `json:"end"`
+	Duration   float64         `json:"duration_ms"`
+	Errors     int             `json:"errors"`
+	Truncated  bool            `json:"truncated,omitempty"` // more records than a timeline holds
+	Services   []ServiceSpan   `json:"services"`
+	Entries    []TimelineEntry `json:"entries"`
+}
+
+// ServiceSpan sums up the records of a service in a timeline. Duration adds
+// the duration_ms of the requests it completed, it is the time between its
+// first and last record when none was logged.
+type ServiceSpan struct {
+	Service  string    `json:"service"`
+	Start    time.Time `json:"start"`
+	End      time.Time `json:"end"`
+	Duration float64   `json:"duration_ms"`
+	Requests int       `json:"requests"`
+	Records  int       `json:"records"`
+	Errors   int       `json:"errors"`
+}
+
+// TimelineEntry is a record of a timeline, Offset is its time since the
+// start of the timeline. Error marks the error records and the 5xx answers.
+type TimelineEntry struct {
+	Offset float64 `json:"offset_ms"`
+	Error  bool    `json:"error"`
+	Log    Log     `json:"record"`
+}
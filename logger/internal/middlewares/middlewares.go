@@ -0,0 +1,59 @@
+package middlewares
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gin-gonic/gin"
+	"gitlab.com/tuneverse/toolkit/core/logger"
+	"gitlab.com/tuneverse/toolkit/internal/consts"
+	"gitlab.com/tuneverse/toolkit/internal/entities"
+	"gitlab.com/tuneverse/toolkit/internal/utilities"
+)
+
+type middlewares struct {
+	Cfg *entities.EnvConfig
+}
+
+func NewMiddlewares(cfg *entities.EnvConfig) *middlewares {
+	return &middlewares{
+		Cfg: cfg,
+	}
+}
+
+// Authenticate verifies the token sent in the Authorization header. The
+// services sign it with utils.GenerateJWTAuthToken, HS256 and the secret
+// shared with the collector. The raw token and the "Bearer" form are both
+// accepted.
+func (m *middlewares) Authenticate() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		tokenString := strings.TrimSpace(ctx.GetHeader("Authorization"))
+		if len(tokenString) > 7 && strings.EqualFold(tokenString[:7], "Bearer ") {
+			tokenString = strings.TrimSpace(tokenString[7:])
+		}
+
+		if err := m.verifyToken(tokenString); err != nil {
+			logger.Log().WithContext(ctx.Request.Context()).Warnf("[Middlewares][Authenticate] rejected request from %s, Error : %s", ctx.ClientIP(), err.Error())
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized,
+				utilities.ErrorResponseGenerator(consts.UnauthorizedError, http.StatusUnauthorized, nil))
+			return
+		}
+		ctx.Next()
+	}
+}
+
+// verifyToken checks the signature and the time claims of a token
+func (m *middlewares) verifyToken(tokenString string) error {
+	if tokenString == "" {
+		return fmt.Errorf("missing token")
+	}
+	_, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		if token.Method != jwt.SigningMethodHS256 {
+			return nil, fmt.Errorf("unexpected signing method %v", token.Header["alg"])
+		}
+		return []byte(m.Cfg.Secret), nil
+	})
+	return err
+}
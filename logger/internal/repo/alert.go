@@ -0,0 +1,128 @@
+package repo
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"gitlab.com/tuneverse/toolkit/internal/consts"
+	"gitlab.com/tuneverse/toolkit/internal/entities"
+	"gopkg.in/yaml.v3"
+)
+
+// RulesRepoImply is the source of the alert rules
+type RulesRepoImply interface {
+	// ModTime returns the time the rules last changed
+	ModTime(ctx context.Context) (time.Time, error)
+	// LoadRules reads the rules
+	LoadRules(ctx context.Context) ([]entities.AlertRule, error)
+}
+
+// FileRulesRepo reads the rules from a YAML or JSON file
+//
+//	rules:
+//	  - name: partner-errors
+//	    service: partner
+//	    log_level: error
+//	    endpoint: /:version/partners/:partner_id
+//	    threshold: 20
+//	    window: 1m
+type FileRulesRepo struct {
+	path string
+}
+
+// NewFileRulesRepo creates a FileRulesRepo reading path
+func NewFileRulesRepo(path string) RulesRepoImply {
+	return &FileRulesRepo{
+		path: path,
+	}
+}
+
+// ModTime returns the modification time of the file
+func (repo *FileRulesRepo) ModTime(ctx context.Context) (time.Time, error) {
+	info, err := os.Stat(repo.path)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return info.ModTime(), nil
+}
+
+// LoadRules parses the file. JSON being YAML, both are read by the YAML
+// decoder, the result is then decoded as JSON so the rules are described by
+// their JSON tags only. Unknown fields are refused.
+func (repo *FileRulesRepo) LoadRules(ctx context.Context) ([]entities.AlertRule, error) {
+	data, err := os.ReadFile(repo.path)
+	if err != nil {
+		return nil, fmt.Errorf("reading the rules failed: %w", err)
+	}
+	var document interface{}
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return nil, fmt.Errorf("parsing the rules failed: %w", err)
+	}
+	data, err = json.Marshal(document)
+	if err != nil {
+		return nil, fmt.Errorf("parsing the rules failed: %w", err)
+	}
+	var rules entities.AlertRules
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&rules); err != nil {
+		return nil, fmt.Errorf("parsing the rules failed: %w", err)
+	}
+	return rules.Rules, nil
+}
+
+// WebhookRepoImply delivers the alerts
+type WebhookRepoImply interface {
+	// Notify sends an alert, it is delivered once it returns nil
+	Notify(ctx context.Context, alert entities.Alert) error
+}
+
+// WebhookRepo posts the alerts as JSON to a URL
+type WebhookRepo struct {
+	url    string
+	client *http.Client
+}
+
+// NewWebhookRepo creates a WebhookRepo posting to rawURL, an absolute http
+// or https URL
+func NewWebhookRepo(rawURL string) (WebhookRepoImply, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid webhook url %q: %w", rawURL, err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid webhook url %q, an absolute http(s) url is required", rawURL)
+	}
+	return &WebhookRepo{
+		url:    rawURL,
+		client: &http.Client{Timeout: consts.WebhookTimeout},
+	}, nil
+}
+
+// Notify posts the alert, any status but 2xx is a failure
+func (repo *WebhookRepo) Notify(ctx context.Context, alert entities.Alert) error {
+	body, err := json.Marshal(alert)
+	if err != nil {
+		return fmt.Errorf("encoding the alert failed: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, repo.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("creating the webhook request failed: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := repo.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("calling the webhook failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("the webhook answered %d", resp.StatusCode)
+	}
+	return nil
+}
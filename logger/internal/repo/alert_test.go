@@ -0,0 +1,18 @@
+package repo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewWebhookRepo(t *testing.T) {
+	for _, rawURL := range []string{"http://alerts.local/hook", "https://alerts.example.com:8443/hook?team=core"} {
+		_, err := NewWebhookRepo(rawURL)
+		require.NoError(t, err, rawURL)
+	}
+	for _, rawURL := range []string{"", "alerts.local/hook", "/hook", "ftp://alerts.local/hook", "http:///hook", "http://%zz"} {
+		_, err := NewWebhookRepo(rawURL)
+		require.Error(t, err, rawURL)
+	}
+}
@@ -0,0 +1,133 @@
+package repo
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+
+	"github.com/aws/aws-sdk-go-v2/config"
+	"gitlab.com/tuneverse/toolkit/core/awsmanager"
+	"gitlab.com/tuneverse/toolkit/core/cloud/awsutils"
+	"gitlab.com/tuneverse/toolkit/internal/consts"
+	"gitlab.com/tuneverse/toolkit/internal/entities"
+)
+
+// ErrArchiveNotFound is returned by ReadArchive when a day was not archived
+var ErrArchiveNotFound = errors.New("archive not found")
+
+// ArchiveRepoImply is the object store of the archived records
+type ArchiveRepoImply interface {
+	// WriteArchive replaces the archive of a service day
+	WriteArchive(ctx context.Context, day entities.LogDay, logs []entities.Log) error
+	// ReadArchive returns the records of the archive of a service day
+	ReadArchive(ctx context.Context, day entities.LogDay) ([]entities.Log, error)
+}
+
+// ArchiveRepo stores the archives as gzipped NDJSON objects through
+// awsutils.CloudServiceImply, one per service and day, e.g.
+// collector/partner/2024-01-31.ndjson.gz
+type ArchiveRepo struct {
+	cloud  awsutils.CloudServiceImply
+	bucket string
+	prefix string
+}
+
+// NewArchiveRepo creates the object store selected by the configuration
+func NewArchiveRepo(cfg entities.Archive) (ArchiveRepoImply, error) {
+	var cloud awsutils.CloudServiceImply
+	switch cfg.Type {
+	case consts.ArchiveFile, "":
+		cloud = awsutils.NewLocalCloudService(cfg.Path)
+	case consts.ArchiveS3:
+		opts := []func(*config.LoadOptions) error{}
+		if cfg.Region != "" {
+			opts = append(opts, awsmanager.WithRegion(cfg.Region))
+		}
+		if cfg.AccessKey != "" {
+			opts = append(opts, awsmanager.WithCredentialsProvider(cfg.AccessKey, cfg.AccessSecret))
+		}
+		awsConf, err := awsmanager.CreateAwsSession(opts...)
+		if err != nil {
+			return nil, fmt.Errorf("unable to create the aws session: %w", err)
+		}
+		cloud = awsutils.NewCloudService(awsConf)
+	default:
+		return nil, fmt.Errorf("unknown archive storage %q", cfg.Type)
+	}
+	return NewObjectArchiveRepo(cloud, cfg.Bucket, cfg.Prefix), nil
+}
+
+// NewObjectArchiveRepo creates an ArchiveRepo storing the archives in bucket
+// under prefix
+func NewObjectArchiveRepo(cloud awsutils.CloudServiceImply, bucket, prefix string) ArchiveRepoImply {
+	return &ArchiveRepo{
+		cloud:  cloud,
+		bucket: bucket,
+		prefix: prefix,
+	}
+}
+
+// key returns the object key of a service day
+func (repo *ArchiveRepo) key(day entities.LogDay) string {
+	return path.Join(repo.prefix, url.PathEscape(day.Service), day.Day.UTC().Format(consts.LogFileDateFormat)+consts.ArchiveExt)
+}
+
+// WriteArchive compresses the records while they are uploaded
+func (repo *ArchiveRepo) WriteArchive(ctx context.Context, day entities.LogDay, logs []entities.Log) error {
+	reader, writer := io.Pipe()
+	go func() {
+		zw := gzip.NewWriter(writer)
+		encoder := json.NewEncoder(zw)
+		for _, log := range logs {
+			if err := encoder.Encode(log); err != nil {
+				writer.CloseWithError(err)
+				return
+			}
+		}
+		writer.CloseWithError(zw.Close())
+	}()
+	err := repo.cloud.PutObject(ctx, repo.bucket, repo.key(day), reader, consts.ArchiveContentType)
+	// unblocks the encoder when the upload stopped early
+	reader.Close()
+	if err != nil {
+		return fmt.Errorf("writing archive %s failed: %w", repo.key(day), err)
+	}
+	return nil
+}
+
+// ReadArchive downloads and decodes an archive
+func (repo *ArchiveRepo) ReadArchive(ctx context.Context, day entities.LogDay) ([]entities.Log, error) {
+	object, err := repo.cloud.ReadObject(ctx, repo.bucket, repo.key(day))
+	if err != nil {
+		if errors.Is(err, awsutils.ErrObjectNotFound) {
+			return nil, ErrArchiveNotFound
+		}
+		return nil, fmt.Errorf("reading archive %s failed: %w", repo.key(day), err)
+	}
+	defer object.Close()
+
+	zr, err := gzip.NewReader(object)
+	if err != nil {
+		return nil, fmt.Errorf("reading archive %s failed: %w", repo.key(day), err)
+	}
+	var logs []entities.Log
+	scanner := bufio.NewScanner(zr)
+	scanner.Buffer(make([]byte, 64*1024), consts.MaxBodySize)
+	for scanner.Scan() {
+		var log entities.Log
+		if err := json.Unmarshal(scanner.Bytes(), &log); err != nil {
+			return nil, fmt.Errorf("decoding archive %s failed: %w", repo.key(day), err)
+		}
+		logs = append(logs, log)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading archive %s failed: %w", repo.key(day), err)
+	}
+	return logs, nil
+}
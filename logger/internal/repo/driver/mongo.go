@@ -0,0 +1,27 @@
+package driver
+
+import (
+	"context"
+	"fmt"
+
+	"gitlab.com/tuneverse/toolkit/internal/consts"
+	"gitlab.com/tuneverse/toolkit/internal/entities"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ConnectMongo initializes the MongoDB client
+func ConnectMongo(ctx context.Context, cfg entities.Mongo) (*mongo.Client, error) {
+	ctx, cancel := context.WithTimeout(ctx, consts.MongoTimeout)
+	defer cancel()
+
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(cfg.URI))
+	if err != nil {
+		return nil, fmt.Errorf("unable to connect to mongo: %w", err)
+	}
+	if err := client.Ping(ctx, nil); err != nil {
+		_ = client.Disconnect(context.Background())
+		return nil, fmt.Errorf("unable to connect to mongo(ping): %w", err)
+	}
+	return client, nil
+}
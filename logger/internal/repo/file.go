@@ -0,0 +1,382 @@
+package repo
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gitlab.com/tuneverse/toolkit/internal/consts"
+	"gitlab.com/tuneverse/toolkit/internal/entities"
+)
+
+// FileLogRepo stores the records in one NDJSON file per day, named after the
+// UTC date of the record timestamp. It needs no external service and is the
+// default storage.
+type FileLogRepo struct {
+	dir string
+
+	mu    sync.Mutex
+	files map[string]*os.File
+}
+
+// NewFileLogRepo creates a FileLogRepo writing in dir
+func NewFileLogRepo(dir string) (LogRepoImply, error) {
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return nil, fmt.Errorf("unable to create the log directory: %w", err)
+	}
+	return &FileLogRepo{
+		dir:   dir,
+		files: map[string]*os.File{},
+	}, nil
+}
+
+// StoreLogs appends the records to the file of their day. The files are
+// synced before it returns, so an acknowledged batch survives a crash.
+func (repo *FileLogRepo) StoreLogs(ctx context.Context, logs []entities.Log) error {
+	lines := map[string][]byte{}
+	for _, log := range logs {
+		line, err := json.Marshal(log)
+		if err != nil {
+			return fmt.Errorf("encoding log record failed: %w", err)
+		}
+		day := log.Timestamp.UTC().Format(consts.LogFileDateFormat)
+		lines[day] = append(append(lines[day], line...), '\n')
+	}
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	for day, data := range lines {
+		file, err := repo.file(day)
+		if err != nil {
+			return err
+		}
+		if _, err := file.Write(data); err != nil {
+			return fmt.Errorf("writing log records failed: %w", err)
+		}
+		if err := file.Sync(); err != nil {
+			return fmt.Errorf("syncing log records failed: %w", err)
+		}
+	}
+	return nil
+}
+
+// file returns the file of a day, repo.mu must be held. The file of today
+// stays open, the older ones are closed as soon as a new day starts.
+func (repo *FileLogRepo) file(day string) (*os.File, error) {
+	if file, ok := repo.files[day]; ok {
+		return file, nil
+	}
+	today := time.Now().UTC().Format(consts.LogFileDateFormat)
+	for name, file := range repo.files {
+		if name != today {
+			_ = file.Close()
+			delete(repo.files, name)
+		}
+	}
+	file, err := os.OpenFile(repo.path(day), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("opening log file failed: %w", err)
+	}
+	repo.files[day] = file
+	return file, nil
+}
+
+// path returns the file name of a day
+func (repo *FileLogRepo) path(day string) string {
+	return filepath.Join(repo.dir, consts.LogFilePrefix+day+consts.LogFileExt)
+}
+
+// SearchLogs reads the files from the newest day back. Only the records of
+// one day and the requested page are held in memory.
+func (repo *FileLogRepo) SearchLogs(ctx context.Context, filter entities.LogFilter, pagination entities.Pagination) ([]entities.Log, int64, error) {
+	days, err := repo.days(filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var (
+		offset = int64(pagination.Page-1) * int64(pagination.Limit)
+		total  int64
+		page   = make([]entities.Log, 0, pagination.Limit)
+	)
+	for i := len(days) - 1; i >= 0; i-- {
+		logs, err := repo.readDay(ctx, days[i], filter)
+		if err != nil {
+			return nil, 0, err
+		}
+		for j := len(logs) - 1; j >= 0; j-- {
+			if total >= offset && len(page) < int(pagination.Limit) {
+				page = append(page, logs[j])
+			}
+			total++
+		}
+	}
+	return page, total, nil
+}
+
+// ExportLogs reads the files from the oldest day on
+func (repo *FileLogRepo) ExportLogs(ctx context.Context, filter entities.LogFilter, fn func(entities.Log) error) error {
+	days, err := repo.days(filter)
+	if err != nil {
+		return err
+	}
+	for _, day := range days {
+		logs, err := repo.readDay(ctx, day, filter)
+		if err != nil {
+			return err
+		}
+		for _, log := range logs {
+			if err := fn(log); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// days returns the stored days overlapping the time range of the filter,
+// oldest first
+func (repo *FileLogRepo) days(filter entities.LogFilter) ([]string, error) {
+	paths, err := filepath.Glob(filepath.Join(repo.dir, consts.LogFilePrefix+"*"+consts.LogFileExt))
+	if err != nil {
+		return nil, err
+	}
+	days := make([]string, 0, len(paths))
+	for _, path := range paths {
+		day := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(path), consts.LogFilePrefix), consts.LogFileExt)
+		start, err := time.Parse(consts.LogFileDateFormat, day)
+		if err != nil {
+			continue
+		}
+		if !filter.From.IsZero() && !start.AddDate(0, 0, 1).After(filter.From) {
+			continue
+		}
+		if !filter.To.IsZero() && !start.Before(filter.To) {
+			continue
+		}
+		days = append(days, day)
+	}
+	sort.Strings(days)
+	return days, nil
+}
+
+// readDay returns the matching records of a day, oldest first
+func (repo *FileLogRepo) readDay(ctx context.Context, day string, filter entities.LogFilter) ([]entities.Log, error) {
+	file, err := os.Open(repo.path(day))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("opening log file failed: %w", err)
+	}
+	defer file.Close()
+
+	var (
+		logs    []entities.Log
+		message = strings.ToLower(filter.Message)
+	)
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), consts.MaxBodySize)
+	for scanner.Scan() {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		var log entities.Log
+		// a line being appended is not complete yet and is skipped
+		if err := json.Unmarshal(scanner.Bytes(), &log); err != nil {
+			continue
+		}
+		if matchLog(filter, message, log) {
+			logs = append(logs, log)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading log file failed: %w", err)
+	}
+	sort.SliceStable(logs, func(i, j int) bool {
+		return logs[i].Timestamp.Before(logs[j].Timestamp)
+	})
+	return logs, nil
+}
+
+// LogDays reads the files of the days before before and lists the services
+// found in each
+func (repo *FileLogRepo) LogDays(ctx context.Context, before time.Time) ([]entities.LogDay, error) {
+	filter := entities.LogFilter{To: before}
+	days, err := repo.days(filter)
+	if err != nil {
+		return nil, err
+	}
+	var logDays []entities.LogDay
+	for _, day := range days {
+		logs, err := repo.readDay(ctx, day, filter)
+		if err != nil {
+			return nil, err
+		}
+		start, _ := time.Parse(consts.LogFileDateFormat, day)
+		services := map[string]bool{}
+		for _, log := range logs {
+			services[log.Service] = true
+		}
+		names := make([]string, 0, len(services))
+		for service := range services {
+			names = append(names, service)
+		}
+		sort.Strings(names)
+		for _, service := range names {
+			logDays = append(logDays, entities.LogDay{Service: service, Day: start})
+		}
+	}
+	return logDays, nil
+}
+
+// PurgeLogs rewrites the files of the days in [from, to) without the purged
+// records. The records are not appended to meanwhile.
+func (repo *FileLogRepo) PurgeLogs(ctx context.Context, service string, from, to, restoredBefore time.Time) (int64, error) {
+	days, err := repo.days(entities.LogFilter{From: from, To: to})
+	if err != nil {
+		return 0, err
+	}
+	purge := func(log entities.Log) bool {
+		return log.Service == service &&
+			!log.Timestamp.Before(from) && log.Timestamp.Before(to) &&
+			(log.RestoredAt == nil || log.RestoredAt.Before(restoredBefore))
+	}
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	var purged int64
+	for _, day := range days {
+		if err := ctx.Err(); err != nil {
+			return purged, err
+		}
+		n, err := repo.purgeDay(day, purge)
+		purged += n
+		if err != nil {
+			return purged, err
+		}
+	}
+	return purged, nil
+}
+
+// purgeDay rewrites the file of a day without the records matched by purge,
+// repo.mu must be held. The file is replaced once the copy is synced, it is
+// removed when no record is left.
+func (repo *FileLogRepo) purgeDay(day string, purge func(entities.Log) bool) (int64, error) {
+	path := repo.path(day)
+	src, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("opening log file failed: %w", err)
+	}
+	defer src.Close()
+	tmp, err := os.CreateTemp(repo.dir, "."+filepath.Base(path)+"-*")
+	if err != nil {
+		return 0, fmt.Errorf("creating log file failed: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	var (
+		purged, kept int64
+		writer       = bufio.NewWriter(tmp)
+		scanner      = bufio.NewScanner(src)
+	)
+	scanner.Buffer(make([]byte, 64*1024), consts.MaxBodySize)
+	for scanner.Scan() {
+		var log entities.Log
+		if err := json.Unmarshal(scanner.Bytes(), &log); err == nil && purge(log) {
+			purged++
+			continue
+		}
+		kept++
+		writer.Write(scanner.Bytes())
+		writer.WriteByte('\n')
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, fmt.Errorf("reading log file failed: %w", err)
+	}
+	if purged == 0 {
+		return 0, nil
+	}
+	if err := writer.Flush(); err != nil {
+		return 0, fmt.Errorf("writing log file failed: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		return 0, fmt.Errorf("syncing log file failed: %w", err)
+	}
+
+	// the open file of the day would keep appending to the replaced one
+	if file, ok := repo.files[day]; ok {
+		_ = file.Close()
+		delete(repo.files, day)
+	}
+	if kept == 0 {
+		if err := os.Remove(path); err != nil {
+			return 0, fmt.Errorf("removing log file failed: %w", err)
+		}
+		return purged, nil
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return 0, fmt.Errorf("replacing log file failed: %w", err)
+	}
+	return purged, nil
+}
+
+// MatchLog reports whether a record matches the filter of a search
+func MatchLog(filter entities.LogFilter, log entities.Log) bool {
+	return matchLog(filter, strings.ToLower(filter.Message), log)
+}
+
+// matchLog reports whether a record matches the filter, message is the
+// lower case message of the filter
+func matchLog(filter entities.LogFilter, message string, log entities.Log) bool {
+	switch {
+	case filter.Service != "" && log.Service != filter.Service,
+		filter.Level != "" && log.Level != filter.Level,
+		filter.RequestID != "" && log.RequestID != filter.RequestID,
+		filter.TraceID != "" && log.TraceID != filter.TraceID,
+		filter.Endpoint != "" && log.Endpoint != filter.Endpoint,
+		filter.Status != 0 && log.Status != filter.Status,
+		message != "" && !strings.Contains(strings.ToLower(log.Message), message),
+		!filter.From.IsZero() && log.Timestamp.Before(filter.From),
+		!filter.To.IsZero() && !log.Timestamp.Before(filter.To):
+		return false
+	}
+	return true
+}
+
+// Ping checks the log directory is still there
+func (repo *FileLogRepo) Ping(ctx context.Context) error {
+	info, err := os.Stat(repo.dir)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", repo.dir)
+	}
+	return nil
+}
+
+// Close closes the open files
+func (repo *FileLogRepo) Close(ctx context.Context) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	var err error
+	for day, file := range repo.files {
+		if closeErr := file.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+		delete(repo.files, day)
+	}
+	return err
+}
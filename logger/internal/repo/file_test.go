@@ -0,0 +1,180 @@
+package repo
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"gitlab.com/tuneverse/toolkit/internal/entities"
+)
+
+// readFile returns the records of an NDJSON file
+func readFile(t *testing.T, path string) []entities.Log {
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+
+	var logs []entities.Log
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var log entities.Log
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &log))
+		logs = append(logs, log)
+	}
+	require.NoError(t, scanner.Err())
+	return logs
+}
+
+func TestFileLogRepo(t *testing.T) {
+	var (
+		ctx       = context.Background()
+		dir       = filepath.Join(t.TempDir(), "collector")
+		yesterday = time.Date(2024, 1, 30, 23, 59, 0, 0, time.UTC)
+		today     = time.Date(2024, 1, 31, 0, 1, 0, 0, time.UTC)
+	)
+
+	repo, err := NewFileLogRepo(dir)
+	require.NoError(t, err)
+	require.NoError(t, repo.Ping(ctx))
+
+	require.NoError(t, repo.StoreLogs(ctx, []entities.Log{
+		{ID: "1", Timestamp: yesterday, Service: "partner", Level: "info", Message: "first"},
+		{ID: "2", Timestamp: today, Service: "partner", Level: "error", Message: "second", Fields: map[string]interface{}{"func": "main"}},
+	}))
+	require.NoError(t, repo.Close(ctx))
+
+	// records are appended after a restart
+	repo, err = NewFileLogRepo(dir)
+	require.NoError(t, err)
+	require.NoError(t, repo.StoreLogs(ctx, []entities.Log{
+		{ID: "3", Timestamp: today, Service: "utility", Level: "info", Message: "third"},
+	}))
+	require.NoError(t, repo.Close(ctx))
+
+	first := readFile(t, filepath.Join(dir, "logs-2024-01-30.ndjson"))
+	require.Len(t, first, 1)
+	require.Equal(t, "first", first[0].Message)
+
+	second := readFile(t, filepath.Join(dir, "logs-2024-01-31.ndjson"))
+	require.Len(t, second, 2)
+	require.Equal(t, "2", second[0].ID)
+	require.Equal(t, "main", second[0].Fields["func"])
+	require.Equal(t, "utility", second[1].Service)
+}
+
+func TestFileLogRepoSearch(t *testing.T) {
+	ctx := context.Background()
+	repo, err := NewFileLogRepo(t.TempDir())
+	require.NoError(t, err)
+	defer repo.Close(ctx)
+
+	at := func(day, hour int) time.Time {
+		return time.Date(2024, 1, day, hour, 0, 0, 0, time.UTC)
+	}
+	// stored out of order, the search sorts by timestamp
+	require.NoError(t, repo.StoreLogs(ctx, []entities.Log{
+		{ID: "2", Timestamp: at(30, 12), Service: "partner", Level: "error", Message: "Payment gateway failed", RequestID: "r1", Status: 500},
+		{ID: "1", Timestamp: at(30, 10), Service: "partner", Level: "info", Message: "request received", RequestID: "r1"},
+		{ID: "3", Timestamp: at(31, 9), Service: "utility", Level: "info", Message: "genres listed", RequestID: "r2", Status: 200},
+		{ID: "4", Timestamp: at(31, 11), Service: "partner", Level: "error", Message: "payment gateway timeout", RequestID: "r3", Endpoint: "/api/:version/partners"},
+	}))
+
+	ids := func(logs []entities.Log) []string {
+		var ids []string
+		for _, log := range logs {
+			ids = append(ids, log.ID)
+		}
+		return ids
+	}
+
+	tests := []struct {
+		name   string
+		filter entities.LogFilter
+		ids    []string
+	}{
+		{name: "everything newest first", ids: []string{"4", "3", "2", "1"}},
+		{name: "service", filter: entities.LogFilter{Service: "partner"}, ids: []string{"4", "2", "1"}},
+		{name: "level", filter: entities.LogFilter{Level: "error"}, ids: []string{"4", "2"}},
+		{name: "request id", filter: entities.LogFilter{RequestID: "r1"}, ids: []string{"2", "1"}},
+		{name: "endpoint", filter: entities.LogFilter{Endpoint: "/api/:version/partners"}, ids: []string{"4"}},
+		{name: "status", filter: entities.LogFilter{Status: 500}, ids: []string{"2"}},
+		{name: "message", filter: entities.LogFilter{Message: "PAYMENT gateway"}, ids: []string{"4", "2"}},
+		{name: "time range", filter: entities.LogFilter{From: at(30, 12), To: at(31, 11)}, ids: []string{"3", "2"}},
+		{name: "no match", filter: entities.LogFilter{Service: "catalog"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs, total, err := repo.SearchLogs(ctx, tt.filter, entities.Pagination{Page: 1, Limit: 10})
+			require.NoError(t, err)
+			require.Equal(t, int64(len(tt.ids)), total)
+			require.Equal(t, tt.ids, ids(logs))
+		})
+	}
+
+	t.Run("pages", func(t *testing.T) {
+		logs, total, err := repo.SearchLogs(ctx, entities.LogFilter{}, entities.Pagination{Page: 2, Limit: 3})
+		require.NoError(t, err)
+		require.Equal(t, int64(4), total)
+		require.Equal(t, []string{"1"}, ids(logs))
+	})
+
+	t.Run("export oldest first", func(t *testing.T) {
+		var exported []entities.Log
+		err := repo.ExportLogs(ctx, entities.LogFilter{Service: "partner"}, func(log entities.Log) error {
+			exported = append(exported, log)
+			return nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, []string{"1", "2", "4"}, ids(exported))
+	})
+}
+
+func TestFileLogRepoPurge(t *testing.T) {
+	var (
+		ctx      = context.Background()
+		dir      = t.TempDir()
+		day      = time.Date(2024, 1, 30, 0, 0, 0, 0, time.UTC)
+		restored = day.AddDate(0, 0, 5)
+	)
+	repo, err := NewFileLogRepo(dir)
+	require.NoError(t, err)
+	defer repo.Close(ctx)
+
+	require.NoError(t, repo.StoreLogs(ctx, []entities.Log{
+		{ID: "1", Timestamp: day.Add(time.Hour), Service: "partner", Level: "info", Message: "purged"},
+		{ID: "2", Timestamp: day.Add(2 * time.Hour), Service: "utility", Level: "info", Message: "other service"},
+		{ID: "3", Timestamp: day.Add(3 * time.Hour), Service: "partner", Level: "info", Message: "restored", RestoredAt: &restored},
+		{ID: "4", Timestamp: day.AddDate(0, 0, 1), Service: "partner", Level: "info", Message: "next day"},
+	}))
+
+	days, err := repo.LogDays(ctx, day.AddDate(0, 0, 1))
+	require.NoError(t, err)
+	require.Equal(t, []entities.LogDay{{Service: "partner", Day: day}, {Service: "utility", Day: day}}, days)
+
+	purged, err := repo.PurgeLogs(ctx, "partner", day, day.AddDate(0, 0, 1), restored)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), purged)
+	left := readFile(t, filepath.Join(dir, "logs-2024-01-30.ndjson"))
+	require.Len(t, left, 2)
+	require.Equal(t, "2", left[0].ID)
+	require.Equal(t, "3", left[1].ID)
+
+	// the records stored afterwards go to the new file
+	require.NoError(t, repo.StoreLogs(ctx, []entities.Log{
+		{ID: "5", Timestamp: day.Add(4 * time.Hour), Service: "utility", Level: "info", Message: "late"},
+	}))
+	require.Len(t, readFile(t, filepath.Join(dir, "logs-2024-01-30.ndjson")), 3)
+
+	// the file is removed with its last record
+	for _, service := range []string{"partner", "utility"} {
+		_, err = repo.PurgeLogs(ctx, service, day, day.AddDate(0, 0, 1), restored.Add(time.Second))
+		require.NoError(t, err)
+	}
+	_, err = os.Stat(filepath.Join(dir, "logs-2024-01-30.ndjson"))
+	require.True(t, os.IsNotExist(err))
+}
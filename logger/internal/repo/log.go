@@ -0,0 +1,51 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gitlab.com/tuneverse/toolkit/internal/consts"
+	"gitlab.com/tuneverse/toolkit/internal/entities"
+	"gitlab.com/tuneverse/toolkit/internal/repo/driver"
+)
+
+// LogRepoImply is the storage of the log records. A backend is added by
+// implementing it and selecting it in NewLogRepo.
+type LogRepoImply interface {
+	// StoreLogs persists the records, they are durable once it returns nil
+	StoreLogs(ctx context.Context, logs []entities.Log) error
+	// SearchLogs returns a page of the matching records, newest first, and
+	// the number of matching records
+	SearchLogs(ctx context.Context, filter entities.LogFilter, pagination entities.Pagination) ([]entities.Log, int64, error)
+	// ExportLogs calls fn for every matching record, oldest first. It stops
+	// at the first error of fn.
+	ExportLogs(ctx context.Context, filter entities.LogFilter, fn func(entities.Log) error) error
+	// LogDays returns the days of every service holding records older than
+	// before, oldest first
+	LogDays(ctx context.Context, before time.Time) ([]entities.LogDay, error)
+	// PurgeLogs deletes the records of a service in [from, to) and returns
+	// their number. The restored records are only deleted when they were
+	// restored before restoredBefore.
+	PurgeLogs(ctx context.Context, service string, from, to, restoredBefore time.Time) (int64, error)
+	// Ping reports whether the storage can accept records
+	Ping(ctx context.Context) error
+	// Close releases the storage
+	Close(ctx context.Context) error
+}
+
+// NewLogRepo creates the storage selected by the configuration
+func NewLogRepo(ctx context.Context, cfg entities.Storage) (LogRepoImply, error) {
+	switch cfg.Type {
+	case consts.StorageFile, "":
+		return NewFileLogRepo(cfg.Path)
+	case consts.StorageMongo:
+		client, err := driver.ConnectMongo(ctx, cfg.Mongo)
+		if err != nil {
+			return nil, err
+		}
+		return NewMongoLogRepo(ctx, client, cfg.Mongo)
+	default:
+		return nil, fmt.Errorf("unknown log storage %q", cfg.Type)
+	}
+}
@@ -0,0 +1,210 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+
+	"gitlab.com/tuneverse/toolkit/internal/consts"
+	"gitlab.com/tuneverse/toolkit/internal/entities"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoLogRepo stores the records in a MongoDB collection
+type MongoLogRepo struct {
+	client     *mongo.Client
+	collection *mongo.Collection
+}
+
+// NewMongoLogRepo creates a MongoLogRepo and the indexes used to search the
+// records
+func NewMongoLogRepo(ctx context.Context, client *mongo.Client, cfg entities.Mongo) (LogRepoImply, error) {
+	collection := client.Database(cfg.Database).Collection(cfg.Collection)
+	_, err := collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "timestamp", Value: -1}}},
+		{Keys: bson.D{{Key: "service", Value: 1}, {Key: "timestamp", Value: -1}}},
+		{Keys: bson.D{{Key: "req_id", Value: 1}}, Options: options.Index().SetSparse(true)},
+		{Keys: bson.D{{Key: "trace_id", Value: 1}}, Options: options.Index().SetSparse(true)},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("creating log indexes failed: %w", err)
+	}
+	return &MongoLogRepo{
+		client:     client,
+		collection: collection,
+	}, nil
+}
+
+// StoreLogs inserts the records. The insert is unordered, a failing record
+// does not stop the others. The records already stored, those of a batch
+// sent again after a partial failure, are skipped.
+func (repo *MongoLogRepo) StoreLogs(ctx context.Context, logs []entities.Log) error {
+	if len(logs) == 0 {
+		return nil
+	}
+	documents := make([]interface{}, len(logs))
+	for i := range logs {
+		documents[i] = logs[i]
+	}
+	_, err := repo.collection.InsertMany(ctx, documents, options.InsertMany().SetOrdered(false))
+	if err != nil && !duplicatesOnly(err) {
+		return fmt.Errorf("inserting log records failed: %w", err)
+	}
+	return nil
+}
+
+// duplicatesOnly reports whether every write of a bulk insert failed on a
+// duplicate key, the records were stored already
+func duplicatesOnly(err error) bool {
+	var bulkErr mongo.BulkWriteException
+	if !errors.As(err, &bulkErr) || bulkErr.WriteConcernError != nil || len(bulkErr.WriteErrors) == 0 {
+		return false
+	}
+	for _, writeErr := range bulkErr.WriteErrors {
+		if writeErr.Code != consts.MongoDuplicateKey {
+			return false
+		}
+	}
+	return true
+}
+
+// SearchLogs finds a page of the matching records, newest first
+func (repo *MongoLogRepo) SearchLogs(ctx context.Context, filter entities.LogFilter, pagination entities.Pagination) ([]entities.Log, int64, error) {
+	query := mongoQuery(filter)
+	total, err := repo.collection.CountDocuments(ctx, query)
+	if err != nil {
+		return nil, 0, fmt.Errorf("counting log records failed: %w", err)
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "timestamp", Value: -1}}).
+		SetSkip(int64(pagination.Page-1) * int64(pagination.Limit)).
+		SetLimit(int64(pagination.Limit))
+	cursor, err := repo.collection.Find(ctx, query, opts)
+	if err != nil {
+		return nil, 0, fmt.Errorf("finding log records failed: %w", err)
+	}
+	logs := make([]entities.Log, 0, pagination.Limit)
+	if err := cursor.All(ctx, &logs); err != nil {
+		return nil, 0, fmt.Errorf("decoding log records failed: %w", err)
+	}
+	return logs, total, nil
+}
+
+// ExportLogs iterates over the matching records, oldest first
+func (repo *MongoLogRepo) ExportLogs(ctx context.Context, filter entities.LogFilter, fn func(entities.Log) error) error {
+	opts := options.Find().SetSort(bson.D{{Key: "timestamp", Value: 1}})
+	cursor, err := repo.collection.Find(ctx, mongoQuery(filter), opts)
+	if err != nil {
+		return fmt.Errorf("finding log records failed: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var log entities.Log
+		if err := cursor.Decode(&log); err != nil {
+			return fmt.Errorf("decoding log record failed: %w", err)
+		}
+		if err := fn(log); err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
+
+// LogDays groups the records older than before by service and UTC day
+func (repo *MongoLogRepo) LogDays(ctx context.Context, before time.Time) ([]entities.LogDay, error) {
+	cursor, err := repo.collection.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"timestamp": bson.M{"$lt": before}}}},
+		{{Key: "$group", Value: bson.M{"_id": bson.M{
+			"service": "$service",
+			"day":     bson.M{"$dateToString": bson.M{"format": "%Y-%m-%d", "date": "$timestamp"}},
+		}}}},
+		{{Key: "$sort", Value: bson.D{{Key: "_id.day", Value: 1}, {Key: "_id.service", Value: 1}}}},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("grouping log records failed: %w", err)
+	}
+	var groups []struct {
+		ID struct {
+			Service string `bson:"service"`
+			Day     string `bson:"day"`
+		} `bson:"_id"`
+	}
+	if err := cursor.All(ctx, &groups); err != nil {
+		return nil, fmt.Errorf("decoding log days failed: %w", err)
+	}
+	days := make([]entities.LogDay, 0, len(groups))
+	for _, group := range groups {
+		day, err := time.Parse(consts.LogFileDateFormat, group.ID.Day)
+		if err != nil {
+			return nil, fmt.Errorf("invalid log day %q: %w", group.ID.Day, err)
+		}
+		days = append(days, entities.LogDay{Service: group.ID.Service, Day: day})
+	}
+	return days, nil
+}
+
+// PurgeLogs deletes the records of a service in [from, to)
+func (repo *MongoLogRepo) PurgeLogs(ctx context.Context, service string, from, to, restoredBefore time.Time) (int64, error) {
+	result, err := repo.collection.DeleteMany(ctx, bson.M{
+		"service":   service,
+		"timestamp": bson.M{"$gte": from, "$lt": to},
+		"$or": bson.A{
+			bson.M{"restored_at": bson.M{"$exists": false}},
+			bson.M{"restored_at": bson.M{"$lt": restoredBefore}},
+		},
+	})
+	if err != nil {
+		return 0, fmt.Errorf("deleting log records failed: %w", err)
+	}
+	return result.DeletedCount, nil
+}
+
+// mongoQuery converts the filter to a MongoDB query
+func mongoQuery(filter entities.LogFilter) bson.M {
+	query := bson.M{}
+	for key, value := range map[string]string{
+		"service":   filter.Service,
+		"log_level": filter.Level,
+		"req_id":    filter.RequestID,
+		"trace_id":  filter.TraceID,
+		"endpoint":  filter.Endpoint,
+	} {
+		if value != "" {
+			query[key] = value
+		}
+	}
+	if filter.Status != 0 {
+		query["response_code"] = filter.Status
+	}
+	if filter.Message != "" {
+		query["message"] = primitive.Regex{Pattern: regexp.QuoteMeta(filter.Message), Options: "i"}
+	}
+	timestamp := bson.M{}
+	if !filter.From.IsZero() {
+		timestamp["$gte"] = filter.From
+	}
+	if !filter.To.IsZero() {
+		timestamp["$lt"] = filter.To
+	}
+	if len(timestamp) > 0 {
+		query["timestamp"] = timestamp
+	}
+	return query
+}
+
+// Ping checks the connection to MongoDB
+func (repo *MongoLogRepo) Ping(ctx context.Context) error {
+	return repo.client.Ping(ctx, nil)
+}
+
+// Close disconnects from MongoDB
+func (repo *MongoLogRepo) Close(ctx context.Context) error {
+	return repo.client.Disconnect(ctx)
+}
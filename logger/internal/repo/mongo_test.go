@@ -0,0 +1,29 @@
+package repo
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestDuplicatesOnly(t *testing.T) {
+	writeErr := func(code int) mongo.BulkWriteError {
+		return mongo.BulkWriteError{WriteError: mongo.WriteError{Code: code}}
+	}
+	duplicates := mongo.BulkWriteException{WriteErrors: []mongo.BulkWriteError{writeErr(11000), writeErr(11000)}}
+	require.True(t, duplicatesOnly(duplicates))
+	require.True(t, duplicatesOnly(fmt.Errorf("insert: %w", duplicates)))
+
+	mixed := mongo.BulkWriteException{WriteErrors: []mongo.BulkWriteError{writeErr(11000), writeErr(121)}}
+	require.False(t, duplicatesOnly(mixed))
+	concern := mongo.BulkWriteException{
+		WriteErrors:       []mongo.BulkWriteError{writeErr(11000)},
+		WriteConcernError: &mongo.WriteConcernError{Code: 64},
+	}
+	require.False(t, duplicatesOnly(concern))
+	require.False(t, duplicatesOnly(mongo.BulkWriteException{}))
+	require.False(t, duplicatesOnly(errors.New("connection reset")))
+}
@@ -0,0 +1,54 @@
+package repo
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"gitlab.com/tuneverse/toolkit/core/awsmanager"
+	"gitlab.com/tuneverse/toolkit/core/queue"
+	"gitlab.com/tuneverse/toolkit/internal/consts"
+	"gitlab.com/tuneverse/toolkit/internal/entities"
+)
+
+// NewLogQueue connects to the queue the services publish their batches to.
+// The messages are acknowledged by the consumer once stored, so RabbitMQ
+// does not acknowledge them on delivery.
+func NewLogQueue(cfg entities.Queue) (queue.Queue, error) {
+	switch cfg.Type {
+	case consts.QueueRabbitMQ:
+		return queue.NewRabbitMQQueue(&queue.RabbitMQConfig{
+			URL:     cfg.URL,
+			Name:    cfg.Name,
+			Durable: true,
+			// the deliveries beyond the batches being stored stay in the
+			// queue, for the other collectors and across a restart
+			PrefetchCount: cfg.Consumers,
+		})
+	case consts.QueueSQS:
+		opts := []func(*config.LoadOptions) error{}
+		if cfg.Region != "" {
+			opts = append(opts, awsmanager.WithRegion(cfg.Region))
+		}
+		if cfg.AccessKey != "" {
+			opts = append(opts, awsmanager.WithCredentialsProvider(cfg.AccessKey, cfg.AccessSecret))
+		}
+		awsConf, err := awsmanager.CreateAwsSession(opts...)
+		if err != nil {
+			return nil, fmt.Errorf("unable to create the aws session: %w", err)
+		}
+		return queue.NewSQSQueue(awsConf, &queue.SQSConfig{
+			QueueInfo: &sqs.CreateQueueInput{
+				QueueName: aws.String(cfg.Name),
+			},
+			ReceiveMessageConfig: &sqs.ReceiveMessageInput{
+				MaxNumberOfMessages: consts.SQSMaxMessages,
+				WaitTimeSeconds:     consts.SQSWaitTime,
+				VisibilityTimeout:   consts.SQSVisibilityTimeout,
+			},
+		})
+	default:
+		return nil, fmt.Errorf("unknown queue broker %q", cfg.Type)
+	}
+}
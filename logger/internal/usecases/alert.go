@@ -0,0 +1,343 @@
+package usecases
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gitlab.com/tuneverse/toolkit/core/logger"
+	"gitlab.com/tuneverse/toolkit/internal/consts"
+	"gitlab.com/tuneverse/toolkit/internal/entities"
+	"gitlab.com/tuneverse/toolkit/internal/repo"
+)
+
+// alertBuckets is the number of buckets of a rule window, the records are
+// counted with a precision of a sixtieth of the window
+const alertBuckets = 60
+
+// AlertUseCases counts the received records matching the alert rules over
+// their sliding window and notifies the webhook when a rule starts and stops
+// firing.
+type AlertUseCases struct {
+	rules   repo.RulesRepoImply
+	webhook repo.WebhookRepoImply
+	now     func() time.Time
+
+	mu      sync.Mutex
+	states  []*alertState
+	modTime time.Time
+	// resolved notifications of the removed rules not delivered yet
+	pending []entities.Alert
+}
+
+// alertState is the window and the firing state of a rule
+type alertState struct {
+	rule     entities.AlertRule
+	filter   entities.LogFilter
+	window   *slidingWindow
+	firing   bool
+	startsAt time.Time
+	// sent is the last status delivered to the webhook
+	sent string
+}
+
+// AlertUseCaseImply is an interface defining the methods of the alerting.
+type AlertUseCaseImply interface {
+	Observe(logs []entities.Log)
+	Evaluate(ctx context.Context) error
+	ListAlerts(ctx context.Context) []entities.AlertState
+}
+
+// NewAlertUseCases creates a new AlertUseCases instance and loads the rules,
+// an invalid rules file fails the creation.
+func NewAlertUseCases(ctx context.Context, rulesRepo repo.RulesRepoImply, webhookRepo repo.WebhookRepoImply) (AlertUseCaseImply, error) {
+	a := &AlertUseCases{
+		rules:   rulesRepo,
+		webhook: webhookRepo,
+		now:     time.Now,
+	}
+	if err := a.reload(ctx); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// ValidateRules checks the rules and normalizes their level
+func ValidateRules(rules []entities.AlertRule) map[string]string {
+	errs := map[string]string{}
+	names := make(map[string]bool, len(rules))
+	for i := range rules {
+		rule := &rules[i]
+		field := fmt.Sprintf("rules[%d].", i)
+		if rule.Name == "" {
+			errs[field+"name"] = consts.Required
+		} else if names[rule.Name] {
+			errs[field+"name"] = consts.Invalid
+		}
+		names[rule.Name] = true
+		if rule.Level != "" {
+			level, err := logrus.ParseLevel(rule.Level)
+			if err != nil {
+				errs[field+"log_level"] = consts.Invalid
+			} else {
+				rule.Level = level.String()
+			}
+		}
+		if rule.Status != 0 && (rule.Status < 100 || rule.Status > 599) {
+			errs[field+"response_code"] = consts.Invalid
+		}
+		if rule.Threshold < 0 {
+			errs[field+"threshold"] = consts.Invalid
+		}
+		if window := time.Duration(rule.Window); window <= 0 || window > consts.MaxAlertWindow {
+			errs[field+"window"] = consts.Invalid
+		}
+	}
+	return errs
+}
+
+// Observe counts the received records in the windows of the matching rules,
+// by their arrival time
+func (a *AlertUseCases) Observe(logs []entities.Log) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	now := a.now()
+	for _, state := range a.states {
+		matched := 0
+		for _, log := range logs {
+			if repo.MatchLog(state.filter, log) {
+				matched++
+			}
+		}
+		if matched > 0 {
+			state.window.add(now, matched)
+		}
+	}
+}
+
+// Evaluate reloads the rules when they changed, then notifies the rules
+// which started firing, more than Threshold records within their window, and
+// those which resolved. A notification is sent once, a failed one is
+// retried by the next evaluation.
+func (a *AlertUseCases) Evaluate(ctx context.Context) error {
+	log := logger.Log().WithContext(ctx)
+	if err := a.reload(ctx); err != nil {
+		// the previous rules are kept
+		log.Errorf("[AlertUseCases][Evaluate] reloading the rules failed, Error : %s", err.Error())
+	}
+
+	type notification struct {
+		state *alertState
+		alert entities.Alert
+	}
+	var notifications []notification
+
+	a.mu.Lock()
+	now := a.now().UTC()
+	for _, alert := range a.pending {
+		notifications = append(notifications, notification{alert: alert})
+	}
+	for _, state := range a.states {
+		count := state.window.count(now)
+		switch {
+		case !state.firing && count > state.rule.Threshold:
+			state.firing = true
+			state.startsAt = now
+		case state.firing && count <= state.rule.Threshold:
+			state.firing = false
+		}
+		switch {
+		case state.firing && state.sent != entities.AlertFiring:
+			notifications = append(notifications, notification{state, state.alert(entities.AlertFiring, count, nil)})
+		case !state.firing && state.sent == entities.AlertFiring:
+			notifications = append(notifications, notification{state, state.alert(entities.AlertResolved, count, &now)})
+		}
+	}
+	a.pending = nil
+	a.mu.Unlock()
+
+	var errs []error
+	for _, n := range notifications {
+		if err := a.webhook.Notify(ctx, n.alert); err != nil {
+			log.Errorf("[AlertUseCases][Evaluate] notifying %s of %s failed, Error : %s",
+				n.alert.Status, n.alert.Rule.Name, err.Error())
+			errs = append(errs, err)
+			if n.state == nil {
+				a.mu.Lock()
+				a.pending = append(a.pending, n.alert)
+				a.mu.Unlock()
+			}
+			continue
+		}
+		log.Infof("[AlertUseCases][Evaluate] %s %s, %d records", n.alert.Rule.Name, n.alert.Status, n.alert.Count)
+		if n.state != nil {
+			a.mu.Lock()
+			n.state.sent = n.alert.Status
+			a.mu.Unlock()
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ListAlerts returns the state of the rules
+func (a *AlertUseCases) ListAlerts(ctx context.Context) []entities.AlertState {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	now := a.now().UTC()
+	alerts := make([]entities.AlertState, 0, len(a.states))
+	for _, state := range a.states {
+		alert := entities.AlertState{
+			Rule:   state.rule,
+			Count:  state.window.count(now),
+			Firing: state.firing,
+		}
+		if state.firing {
+			startsAt := state.startsAt
+			alert.StartsAt = &startsAt
+		}
+		alerts = append(alerts, alert)
+	}
+	return alerts
+}
+
+// reload loads the rules when their modification time changed. An unchanged
+// rule keeps its window and state, a removed rule which fired is resolved.
+func (a *AlertUseCases) reload(ctx context.Context) error {
+	modTime, err := a.rules.ModTime(ctx)
+	if err != nil {
+		return err
+	}
+	a.mu.Lock()
+	changed := !modTime.Equal(a.modTime)
+	a.mu.Unlock()
+	if !changed {
+		return nil
+	}
+	rules, err := a.rules.LoadRules(ctx)
+	if err != nil {
+		return err
+	}
+	if errs := ValidateRules(rules); len(errs) > 0 {
+		return fmt.Errorf("invalid rules: %v", errs)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	previous := make(map[string]*alertState, len(a.states))
+	for _, state := range a.states {
+		previous[state.rule.Name] = state
+	}
+	states := make([]*alertState, 0, len(rules))
+	for _, rule := range rules {
+		if state, ok := previous[rule.Name]; ok && state.rule == rule {
+			delete(previous, rule.Name)
+			states = append(states, state)
+			continue
+		}
+		states = append(states, newAlertState(rule))
+	}
+	now := a.now().UTC()
+	for _, state := range previous {
+		if state.sent == entities.AlertFiring {
+			a.pending = append(a.pending, state.alert(entities.AlertResolved, 0, &now))
+		}
+	}
+	a.states = states
+	a.modTime = modTime
+	logger.Log().WithContext(ctx).Infof("[AlertUseCases][reload] %d rules loaded", len(rules))
+	return nil
+}
+
+// newAlertState creates the state of a rule
+func newAlertState(rule entities.AlertRule) *alertState {
+	return &alertState{
+		rule: rule,
+		filter: entities.LogFilter{
+			Service:  rule.Service,
+			Level:    rule.Level,
+			Endpoint: rule.Endpoint,
+			Status:   rule.Status,
+			Message:  rule.Message,
+		},
+		window: newSlidingWindow(time.Duration(rule.Window)),
+	}
+}
+
+// alert returns the notification of the rule
+func (s *alertState) alert(status string, count int, endsAt *time.Time) entities.Alert {
+	sum := sha256.Sum256([]byte(s.rule.Name))
+	return entities.Alert{
+		Status:      status,
+		Fingerprint: hex.EncodeToString(sum[:8]),
+		Rule:        s.rule,
+		Count:       count,
+		StartsAt:    s.startsAt,
+		EndsAt:      endsAt,
+	}
+}
+
+// slidingWindow counts events over the last window, in buckets
+type slidingWindow struct {
+	width  time.Duration
+	counts []int
+	// latest is the slot of the newest bucket, slots are numbered from the
+	// epoch
+	latest int64
+}
+
+// newSlidingWindow creates a window split in alertBuckets buckets of at
+// least a second
+func newSlidingWindow(window time.Duration) *slidingWindow {
+	width := window / alertBuckets
+	if width < time.Second {
+		width = time.Second
+	}
+	buckets := int((window + width - 1) / width)
+	return &slidingWindow{
+		width:  width,
+		counts: make([]int, buckets),
+	}
+}
+
+// advance moves the window to now, emptying the buckets which left it, and
+// returns the slot of now
+func (w *slidingWindow) advance(now time.Time) int64 {
+	slot := now.UnixNano() / int64(w.width)
+	if slot <= w.latest {
+		return slot
+	}
+	size := int64(len(w.counts))
+	if slot-w.latest >= size {
+		clear(w.counts)
+	} else {
+		for s := w.latest + 1; s <= slot; s++ {
+			w.counts[s%size] = 0
+		}
+	}
+	w.latest = slot
+	return slot
+}
+
+// add counts n events at now
+func (w *slidingWindow) add(now time.Time, n int) {
+	slot := w.advance(now)
+	if slot <= w.latest-int64(len(w.counts)) {
+		return
+	}
+	w.counts[slot%int64(len(w.counts))] += n
+}
+
+// count returns the number of events within the window ending at now
+func (w *slidingWindow) count(now time.Time) int {
+	w.advance(now)
+	total := 0
+	for _, n := range w.counts {
+		total += n
+	}
+	return total
+}
@@ -0,0 +1,149 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"gitlab.com/tuneverse/toolkit/core/logger"
+	"gitlab.com/tuneverse/toolkit/internal/consts"
+	"gitlab.com/tuneverse/toolkit/internal/entities"
+	"gitlab.com/tuneverse/toolkit/internal/repo"
+)
+
+// webhookRepo records the notifications, it fails while err is set
+type webhookRepo struct {
+	alerts []entities.Alert
+	err    error
+}
+
+func (w *webhookRepo) Notify(ctx context.Context, alert entities.Alert) error {
+	if w.err != nil {
+		return w.err
+	}
+	w.alerts = append(w.alerts, alert)
+	return nil
+}
+
+func TestAlerts(t *testing.T) {
+	logger.InitLogger(&logger.ClientOptions{Service: consts.AppName, LogLevel: "panic"})
+	var (
+		ctx     = context.Background()
+		path    = filepath.Join(t.TempDir(), "rules.yaml")
+		now     = time.Date(2024, 1, 31, 10, 0, 0, 0, time.UTC)
+		webhook = &webhookRepo{}
+		version = 0
+	)
+	writeRules := func(rules string) {
+		require.NoError(t, os.WriteFile(path, []byte(rules), 0o644))
+		// the modification time may not change within the test
+		version++
+		modTime := now.Add(time.Duration(version) * time.Second)
+		require.NoError(t, os.Chtimes(path, modTime, modTime))
+	}
+	errorLogs := func(n int) []entities.Log {
+		logs := make([]entities.Log, n)
+		for i := range logs {
+			logs[i] = entities.Log{Service: "partner", Level: "error", Endpoint: "/:version/partners", Message: "failed"}
+		}
+		return logs
+	}
+	statuses := func() []string {
+		var statuses []string
+		for _, alert := range webhook.alerts {
+			statuses = append(statuses, alert.Rule.Name+" "+alert.Status)
+		}
+		return statuses
+	}
+
+	writeRules(`
+rules:
+  - name: partner-errors
+    service: partner
+    log_level: ERROR
+    endpoint: /:version/partners
+    threshold: 2
+    window: 1m
+`)
+	imply, err := NewAlertUseCases(ctx, repo.NewFileRulesRepo(path), webhook)
+	require.NoError(t, err)
+	useCase := imply.(*AlertUseCases)
+	useCase.now = func() time.Time { return now }
+
+	t.Run("below the threshold", func(t *testing.T) {
+		useCase.Observe(errorLogs(2))
+		useCase.Observe([]entities.Log{{Service: "utility", Level: "error", Endpoint: "/:version/partners"}})
+		require.NoError(t, useCase.Evaluate(ctx))
+		require.Empty(t, webhook.alerts)
+		require.Equal(t, 2, useCase.ListAlerts(ctx)[0].Count)
+	})
+
+	t.Run("fires once", func(t *testing.T) {
+		now = now.Add(30 * time.Second)
+		useCase.Observe(errorLogs(1))
+		require.NoError(t, useCase.Evaluate(ctx))
+		require.NoError(t, useCase.Evaluate(ctx))
+		require.Equal(t, []string{"partner-errors firing"}, statuses())
+		require.Equal(t, 3, webhook.alerts[0].Count)
+		require.Equal(t, now, webhook.alerts[0].StartsAt)
+		require.True(t, useCase.ListAlerts(ctx)[0].Firing)
+	})
+
+	t.Run("resolves when the records leave the window", func(t *testing.T) {
+		now = now.Add(45 * time.Second)
+		webhook.err = errors.New("unavailable")
+		require.Error(t, useCase.Evaluate(ctx))
+		webhook.err = nil
+		require.NoError(t, useCase.Evaluate(ctx))
+		require.Equal(t, []string{"partner-errors firing", "partner-errors resolved"}, statuses())
+		require.Equal(t, webhook.alerts[0].Fingerprint, webhook.alerts[1].Fingerprint)
+		require.Equal(t, 1, webhook.alerts[1].Count)
+		require.NotNil(t, webhook.alerts[1].EndsAt)
+	})
+
+	t.Run("reload", func(t *testing.T) {
+		webhook.alerts = nil
+		useCase.Observe(errorLogs(5))
+		require.NoError(t, useCase.Evaluate(ctx))
+		require.Equal(t, []string{"partner-errors firing"}, statuses())
+
+		writeRules(`{"rules": [{"name": "all", "threshold": 0, "window": "10s"}]}`)
+		require.NoError(t, useCase.Evaluate(ctx))
+		require.Equal(t, []string{"partner-errors firing", "partner-errors resolved"}, statuses())
+		alerts := useCase.ListAlerts(ctx)
+		require.Len(t, alerts, 1)
+		require.Equal(t, "all", alerts[0].Rule.Name)
+		require.Zero(t, alerts[0].Count)
+	})
+
+	t.Run("invalid rules are not loaded", func(t *testing.T) {
+		writeRules(`
+rules:
+  - name: all
+    threshold: -1
+    window: 48h
+  - name: all
+    log_level: loud
+    window: 1m
+`)
+		require.NoError(t, useCase.Evaluate(ctx))
+		require.Equal(t, "all", useCase.ListAlerts(ctx)[0].Rule.Name)
+
+		rules, err := repo.NewFileRulesRepo(path).LoadRules(ctx)
+		require.NoError(t, err)
+		require.Equal(t, map[string]string{
+			"rules[0].threshold": "invalid",
+			"rules[0].window":    "invalid",
+			"rules[1].name":      "invalid",
+			"rules[1].log_level": "invalid",
+		}, ValidateRules(rules))
+
+		writeRules("rules:\n  - name: all\n    window: 1m\n    severity: high\n")
+		_, err = repo.NewFileRulesRepo(path).LoadRules(ctx)
+		require.Error(t, err)
+	})
+}
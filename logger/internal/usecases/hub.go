@@ -0,0 +1,148 @@
+package usecases
+
+import (
+	"errors"
+	"sync"
+
+	"gitlab.com/tuneverse/toolkit/internal/entities"
+	"gitlab.com/tuneverse/toolkit/internal/repo"
+)
+
+var (
+	// ErrTooManySubscribers is returned by Subscribe when the hub is full
+	ErrTooManySubscribers = errors.New("too many subscribers")
+	// ErrSlowSubscriber ends a subscription whose buffer overflowed
+	ErrSlowSubscriber = errors.New("subscriber too slow")
+	// ErrHubClosed ends the subscriptions when the collector stops
+	ErrHubClosed = errors.New("hub closed")
+)
+
+// Hub fans the stored records out to the live subscribers. Publishing never
+// blocks: every subscription has a bounded buffer and a subscriber which
+// does not keep up is dropped, so one slow client can not hold the ingestion
+// back.
+type Hub struct {
+	buffer int
+	max    int
+
+	mu          sync.RWMutex
+	subscribers map[*Subscription]struct{}
+	closed      bool
+}
+
+// NewHub creates a hub accepting max subscribers, each buffering up to
+// buffer records
+func NewHub(buffer, max int) *Hub {
+	return &Hub{
+		buffer:      buffer,
+		max:         max,
+		subscribers: map[*Subscription]struct{}{},
+	}
+}
+
+// Subscription receives the published records matching its filter until it
+// is closed, dropped or the hub closes.
+type Subscription struct {
+	hub    *Hub
+	filter entities.LogFilter
+	logs   chan entities.Log
+
+	once sync.Once
+	done chan struct{}
+	err  error
+}
+
+// Subscribe registers a subscriber to the records matching filter
+func (h *Hub) Subscribe(filter entities.LogFilter) (*Subscription, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return nil, ErrHubClosed
+	}
+	if len(h.subscribers) >= h.max {
+		return nil, ErrTooManySubscribers
+	}
+	sub := &Subscription{
+		hub:    h,
+		filter: filter,
+		logs:   make(chan entities.Log, h.buffer),
+		done:   make(chan struct{}),
+	}
+	h.subscribers[sub] = struct{}{}
+	return sub, nil
+}
+
+// Publish hands the records to the matching subscribers, oldest first
+func (h *Hub) Publish(logs []entities.Log) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for sub := range h.subscribers {
+		sub.publish(logs)
+	}
+}
+
+// Close ends every subscription, the later ones are refused
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.closed = true
+	for sub := range h.subscribers {
+		sub.end(ErrHubClosed)
+		delete(h.subscribers, sub)
+	}
+}
+
+// publish buffers the matching records, the subscription is dropped when the
+// buffer is full
+func (s *Subscription) publish(logs []entities.Log) {
+	for _, log := range logs {
+		if !repo.MatchLog(s.filter, log) {
+			continue
+		}
+		select {
+		case <-s.done:
+			return
+		case s.logs <- log:
+		default:
+			s.end(ErrSlowSubscriber)
+			return
+		}
+	}
+}
+
+// end closes Done with the reason of the end
+func (s *Subscription) end(err error) {
+	s.once.Do(func() {
+		s.err = err
+		close(s.done)
+	})
+}
+
+// Logs returns the records of the subscription
+func (s *Subscription) Logs() <-chan entities.Log {
+	return s.logs
+}
+
+// Done is closed when the subscription ends, Err then tells why
+func (s *Subscription) Done() <-chan struct{} {
+	return s.done
+}
+
+// Err returns ErrSlowSubscriber or ErrHubClosed once Done is closed, nil
+// before or when the subscriber closed it
+func (s *Subscription) Err() error {
+	select {
+	case <-s.done:
+		return s.err
+	default:
+		return nil
+	}
+}
+
+// Close unregisters the subscriber
+func (s *Subscription) Close() {
+	s.end(nil)
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	delete(s.hub.subscribers, s)
+}
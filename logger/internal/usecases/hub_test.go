@@ -0,0 +1,80 @@
+package usecases
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gitlab.com/tuneverse/toolkit/internal/entities"
+)
+
+func TestHub(t *testing.T) {
+	logs := []entities.Log{
+		{ID: "1", Service: "partner", Level: "error"},
+		{ID: "2", Service: "utility", Level: "error"},
+		{ID: "3", Service: "partner", Level: "info"},
+	}
+	received := func(sub *Subscription) []string {
+		var ids []string
+		for {
+			select {
+			case log := <-sub.Logs():
+				ids = append(ids, log.ID)
+			default:
+				return ids
+			}
+		}
+	}
+
+	t.Run("fan out the matching records", func(t *testing.T) {
+		hub := NewHub(10, 2)
+		partner, err := hub.Subscribe(entities.LogFilter{Service: "partner"})
+		require.NoError(t, err)
+		errors, err := hub.Subscribe(entities.LogFilter{Level: "error"})
+		require.NoError(t, err)
+
+		hub.Publish(logs)
+		require.Equal(t, []string{"1", "3"}, received(partner))
+		require.Equal(t, []string{"1", "2"}, received(errors))
+	})
+
+	t.Run("bounded number of subscribers", func(t *testing.T) {
+		hub := NewHub(10, 1)
+		sub, err := hub.Subscribe(entities.LogFilter{})
+		require.NoError(t, err)
+		_, err = hub.Subscribe(entities.LogFilter{})
+		require.ErrorIs(t, err, ErrTooManySubscribers)
+
+		sub.Close()
+		require.NoError(t, sub.Err())
+		_, err = hub.Subscribe(entities.LogFilter{})
+		require.NoError(t, err)
+	})
+
+	t.Run("slow subscribers are dropped", func(t *testing.T) {
+		hub := NewHub(2, 2)
+		slow, err := hub.Subscribe(entities.LogFilter{})
+		require.NoError(t, err)
+		fast, err := hub.Subscribe(entities.LogFilter{Service: "utility"})
+		require.NoError(t, err)
+
+		hub.Publish(logs)
+		<-slow.Done()
+		require.ErrorIs(t, slow.Err(), ErrSlowSubscriber)
+		require.Equal(t, []string{"1", "2"}, received(slow))
+
+		// the other subscribers are served
+		require.NoError(t, fast.Err())
+		require.Equal(t, []string{"2"}, received(fast))
+	})
+
+	t.Run("close ends the subscriptions", func(t *testing.T) {
+		hub := NewHub(2, 2)
+		sub, err := hub.Subscribe(entities.LogFilter{})
+		require.NoError(t, err)
+		hub.Close()
+		<-sub.Done()
+		require.ErrorIs(t, sub.Err(), ErrHubClosed)
+		_, err = hub.Subscribe(entities.LogFilter{})
+		require.ErrorIs(t, err, ErrHubClosed)
+	})
+}
@@ -0,0 +1,346 @@
+package usecases
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	constants "gitlab.com/tuneverse/toolkit/consts"
+	"gitlab.com/tuneverse/toolkit/core/logger"
+	"gitlab.com/tuneverse/toolkit/internal/consts"
+	"gitlab.com/tuneverse/toolkit/internal/entities"
+	"gitlab.com/tuneverse/toolkit/internal/repo"
+	"gitlab.com/tuneverse/toolkit/models"
+	"gitlab.com/tuneverse/toolkit/utils"
+)
+
+var (
+	// ErrEmptyBatch is returned by DecodeBatch for a batch without record
+	ErrEmptyBatch = errors.New("no logs in batch")
+	// ErrBatchTooLarge is returned by DecodeBatch for a batch of more than
+	// MaxBatchSize records
+	ErrBatchTooLarge = errors.New("too many logs in batch")
+
+	// logIDNamespace is the namespace of the record ids
+	logIDNamespace = uuid.MustParse(consts.LogIDNamespace)
+)
+
+var (
+	traceIDPattern = regexp.MustCompile(`^[0-9a-f]{32}$`)
+	spanIDPattern  = regexp.MustCompile(`^[0-9a-f]{16}$`)
+
+	// requiredFields are set by the toolkit logger on every record
+	requiredFields = []string{
+		constants.ContextMessage,
+		constants.ContextService,
+		constants.ContextLogLevel,
+		constants.ContextTimeStamp,
+	}
+
+	// stringFields are optional, they are strings when present
+	stringFields = []string{
+		constants.ContextRequestID,
+		constants.ContextRequestURI,
+		constants.ContextRequestMethod,
+		constants.ContextRequestIP,
+		constants.ContextRequestURITemplate,
+		constants.ContextTraceID,
+		constants.ContextSpanID,
+		constants.ContextParentSpanID,
+	}
+
+	// objectFields are optional, they are JSON objects when present
+	objectFields = []string{
+		constants.ContextRequestDump,
+		constants.ContextResponseDump,
+	}
+
+	// promotedFields are stored in the typed fields of entities.Log
+	promotedFields = map[string]bool{
+		constants.ContextMessage:            true,
+		constants.ContextService:            true,
+		constants.ContextLogLevel:           true,
+		constants.ContextTimeStamp:          true,
+		constants.ContextRequestID:          true,
+		constants.ContextTraceID:            true,
+		constants.ContextSpanID:             true,
+		constants.ContextRequestURITemplate: true,
+		constants.ContextRequestMethod:      true,
+		constants.ContextRequestStatus:      true,
+	}
+)
+
+// LogUseCases represents use cases for handling the log records.
+type LogUseCases struct {
+	repo   repo.LogRepoImply
+	hub    *Hub
+	alerts AlertUseCaseImply
+}
+
+// LogUseCaseImply is an interface defining the methods for working with log use cases.
+type LogUseCaseImply interface {
+	IngestLogs(ctx context.Context, records []map[string]interface{}) (entities.IngestResult, error)
+	SearchLogs(ctx context.Context, filter entities.LogFilter, pagination entities.Pagination) (*entities.Response, map[string]string, error)
+	ExportLogs(ctx context.Context, filter entities.LogFilter, fn func(entities.Log) error) (map[string]string, error)
+	SubscribeLogs(ctx context.Context, filter entities.LogFilter) (*Subscription, map[string]string, error)
+	Timeline(ctx context.Context, query entities.TimelineQuery) (*entities.Timeline, map[string]string, error)
+	Health(ctx context.Context) error
+}
+
+// NewLogUseCases creates a new LogUseCases instance. The stored records are
+// published to hub for the live streams and counted by the alert rules,
+// alerts is nil when the alerting is off.
+func NewLogUseCases(logRepo repo.LogRepoImply, hub *Hub, alerts AlertUseCaseImply) LogUseCaseImply {
+	return &LogUseCases{
+		repo:   logRepo,
+		hub:    hub,
+		alerts: alerts,
+	}
+}
+
+// DecodeBatch reads the records of a batch, {"logs": [...]}, or of a single
+// record. The batches of POST /logs and of the queue share this format.
+func DecodeBatch(body []byte) ([]map[string]interface{}, error) {
+	var batch entities.LogBatch
+	if err := json.Unmarshal(body, &batch); err != nil {
+		return nil, err
+	}
+	if batch.Logs == nil {
+		// a single record
+		var record map[string]interface{}
+		if err := json.Unmarshal(body, &record); err == nil && len(record) > 0 {
+			batch.Logs = []map[string]interface{}{record}
+		}
+	}
+	switch {
+	case len(batch.Logs) == 0:
+		return nil, ErrEmptyBatch
+	case len(batch.Logs) > consts.MaxBatchSize:
+		return nil, ErrBatchTooLarge
+	}
+	return batch.Logs, nil
+}
+
+// IngestLogs validates the records and stores the valid ones. The invalid
+// records are reported in the result, they do not fail the batch. An error
+// is returned only when the storage fails, the batch can then be retried:
+// the id of a record is derived from its content, so a record sent again
+// keeps its id and the storage skips it.
+func (l *LogUseCases) IngestLogs(ctx context.Context, records []map[string]interface{}) (entities.IngestResult, error) {
+	var (
+		result     = entities.IngestResult{Errors: map[string]string{}}
+		logs       = make([]entities.Log, 0, len(records))
+		receivedAt = time.Now().UTC()
+	)
+	for i, record := range records {
+		id := recordID(record)
+		log, errs := ValidateRecord(record)
+		if len(errs) > 0 {
+			for field, msg := range errs {
+				result.Errors[fmt.Sprintf("logs[%d].%s", i, field)] = msg
+			}
+			result.Rejected++
+			continue
+		}
+		log.ID = id
+		log.ReceivedAt = receivedAt
+		logs = append(logs, log)
+	}
+
+	if len(logs) > 0 {
+		if err := l.repo.StoreLogs(ctx, logs); err != nil {
+			logger.Log().WithContext(ctx).Errorf("[LogUseCases][IngestLogs] storing %d records failed, Error : %s", len(logs), err.Error())
+			return entities.IngestResult{}, err
+		}
+		l.hub.Publish(logs)
+		if l.alerts != nil {
+			l.alerts.Observe(logs)
+		}
+	}
+	result.Accepted = len(logs)
+	if result.Rejected > 0 {
+		logger.Log().WithContext(ctx).Warnf("[LogUseCases][IngestLogs] %d of %d records rejected", result.Rejected, len(records))
+	}
+	return result, nil
+}
+
+// recordID returns the name based UUID of the JSON encoding of the record,
+// the keys of which are sorted. A random UUID is returned when the record
+// can not be encoded.
+func recordID(record map[string]interface{}) string {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return uuid.NewString()
+	}
+	return uuid.NewSHA1(logIDNamespace, data).String()
+}
+
+// SearchLogs validates the filter and returns a page of the matching
+// records, newest first
+func (l *LogUseCases) SearchLogs(ctx context.Context, filter entities.LogFilter, pagination entities.Pagination) (*entities.Response, map[string]string, error) {
+	if errs := validateFilter(&filter); len(errs) > 0 {
+		return nil, errs, nil
+	}
+	pagination.Page, pagination.Limit = utils.Paginate(pagination.Page, pagination.Limit, constants.DefaultLimit)
+
+	logs, total, err := l.repo.SearchLogs(ctx, filter, pagination)
+	if err != nil {
+		logger.Log().WithContext(ctx).Errorf("[LogUseCases][SearchLogs] Error : %s", err.Error())
+		return nil, nil, err
+	}
+
+	metaData := utils.MetaDataInfo(&models.MetaData{
+		Total:       total,
+		PerPage:     pagination.Limit,
+		CurrentPage: pagination.Page,
+	})
+	return &entities.Response{
+		MetaData: metaData,
+		Data:     logs,
+	}, nil, nil
+}
+
+// ExportLogs validates the filter and calls fn for every matching record,
+// oldest first. fn is not called when the filter is invalid.
+func (l *LogUseCases) ExportLogs(ctx context.Context, filter entities.LogFilter, fn func(entities.Log) error) (map[string]string, error) {
+	if errs := validateFilter(&filter); len(errs) > 0 {
+		return errs, nil
+	}
+	if err := l.repo.ExportLogs(ctx, filter, fn); err != nil {
+		logger.Log().WithContext(ctx).Errorf("[LogUseCases][ExportLogs] Error : %s", err.Error())
+		return nil, err
+	}
+	return nil, nil
+}
+
+// SubscribeLogs validates the filter and subscribes to the records stored
+// from now on. The caller closes the subscription.
+func (l *LogUseCases) SubscribeLogs(ctx context.Context, filter entities.LogFilter) (*Subscription, map[string]string, error) {
+	if errs := validateFilter(&filter); len(errs) > 0 {
+		return nil, errs, nil
+	}
+	sub, err := l.hub.Subscribe(filter)
+	if err != nil {
+		logger.Log().WithContext(ctx).Warnf("[LogUseCases][SubscribeLogs] Error : %s", err.Error())
+		return nil, nil, err
+	}
+	return sub, nil, nil
+}
+
+// validateFilter checks the search parameters and normalizes the level
+func validateFilter(filter *entities.LogFilter) map[string]string {
+	errs := map[string]string{}
+	if filter.Level != "" {
+		level, err := logrus.ParseLevel(filter.Level)
+		if err != nil {
+			errs[constants.ContextLogLevel] = consts.Invalid
+		}
+		filter.Level = level.String()
+	}
+	if filter.Status != 0 && (filter.Status < 100 || filter.Status > 599) {
+		errs[constants.ContextRequestStatus] = consts.Invalid
+	}
+	if !filter.From.IsZero() && !filter.To.IsZero() && !filter.From.Before(filter.To) {
+		errs["to"] = consts.Invalid
+	}
+	if filter.Format != "" && filter.Format != consts.FormatNDJSON {
+		errs["format"] = consts.Invalid
+	}
+	return errs
+}
+
+// Health checks the storage can accept records
+func (l *LogUseCases) Health(ctx context.Context) error {
+	return l.repo.Ping(ctx)
+}
+
+// ValidateRecord checks a record against the field set of the toolkit logger
+// and converts it. The errors are keyed by field.
+func ValidateRecord(record map[string]interface{}) (entities.Log, map[string]string) {
+	var (
+		log  = entities.Log{Fields: map[string]interface{}{}}
+		errs = map[string]string{}
+	)
+
+	for _, field := range requiredFields {
+		value, ok := record[field].(string)
+		switch {
+		case record[field] == nil:
+			errs[field] = consts.Required
+		case !ok || (value == "" && field != constants.ContextMessage):
+			errs[field] = consts.Invalid
+		}
+	}
+	for _, field := range stringFields {
+		if _, ok := record[field].(string); record[field] != nil && !ok {
+			errs[field] = consts.Invalid
+		}
+	}
+	for _, field := range objectFields {
+		if _, ok := record[field].(map[string]interface{}); record[field] != nil && !ok {
+			errs[field] = consts.Invalid
+		}
+	}
+
+	if _, failed := errs[constants.ContextLogLevel]; !failed {
+		level, err := logrus.ParseLevel(record[constants.ContextLogLevel].(string))
+		if err != nil {
+			errs[constants.ContextLogLevel] = consts.Invalid
+		}
+		log.Level = level.String()
+	}
+	if _, failed := errs[constants.ContextTimeStamp]; !failed {
+		timestamp, err := time.Parse(time.RFC3339Nano, record[constants.ContextTimeStamp].(string))
+		if err != nil {
+			errs[constants.ContextTimeStamp] = consts.Invalid
+		}
+		log.Timestamp = timestamp.UTC()
+	}
+	if value, ok := record[constants.ContextTraceID].(string); ok && !traceIDPattern.MatchString(value) {
+		errs[constants.ContextTraceID] = consts.Invalid
+	}
+	for _, field := range []string{constants.ContextSpanID, constants.ContextParentSpanID} {
+		if value, ok := record[field].(string); ok && !spanIDPattern.MatchString(value) {
+			errs[field] = consts.Invalid
+		}
+	}
+	if value, ok := record[constants.ContextRequestStatus]; ok && value != nil {
+		status, ok := value.(float64)
+		if !ok || status != math.Trunc(status) || status < 100 || status > 599 {
+			errs[constants.ContextRequestStatus] = consts.Invalid
+		}
+		log.Status = int(status)
+	}
+	switch record[constants.ContextRequestTimetaken].(type) {
+	case nil, string, float64:
+	default:
+		errs[constants.ContextRequestTimetaken] = consts.Invalid
+	}
+
+	if len(errs) > 0 {
+		return entities.Log{}, errs
+	}
+
+	log.Message, _ = record[constants.ContextMessage].(string)
+	log.Service, _ = record[constants.ContextService].(string)
+	log.RequestID, _ = record[constants.ContextRequestID].(string)
+	log.TraceID, _ = record[constants.ContextTraceID].(string)
+	log.SpanID, _ = record[constants.ContextSpanID].(string)
+	log.Endpoint, _ = record[constants.ContextRequestURITemplate].(string)
+	log.Method, _ = record[constants.ContextRequestMethod].(string)
+	for key, value := range record {
+		if !promotedFields[key] {
+			log.Fields[key] = value
+		}
+	}
+	if len(log.Fields) == 0 {
+		log.Fields = nil
+	}
+	return log, nil
+}
@@ -0,0 +1,258 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"gitlab.com/tuneverse/toolkit/core/logger"
+	"gitlab.com/tuneverse/toolkit/internal/consts"
+	"gitlab.com/tuneverse/toolkit/internal/entities"
+)
+
+// memoryRepo keeps the stored records in memory
+type memoryRepo struct {
+	logs []entities.Log
+	err  error
+
+	// the parameters of the last search
+	filter     entities.LogFilter
+	pagination entities.Pagination
+}
+
+func (repo *memoryRepo) StoreLogs(ctx context.Context, logs []entities.Log) error {
+	if repo.err != nil {
+		return repo.err
+	}
+	repo.logs = append(repo.logs, logs...)
+	return nil
+}
+
+func (repo *memoryRepo) SearchLogs(ctx context.Context, filter entities.LogFilter, pagination entities.Pagination) ([]entities.Log, int64, error) {
+	repo.filter, repo.pagination = filter, pagination
+	return repo.logs, int64(len(repo.logs)), repo.err
+}
+
+func (repo *memoryRepo) ExportLogs(ctx context.Context, filter entities.LogFilter, fn func(entities.Log) error) error {
+	repo.filter = filter
+	for _, log := range repo.logs {
+		if err := fn(log); err != nil {
+			return err
+		}
+	}
+	return repo.err
+}
+
+func (repo *memoryRepo) LogDays(ctx context.Context, before time.Time) ([]entities.LogDay, error) {
+	return nil, repo.err
+}
+
+func (repo *memoryRepo) PurgeLogs(ctx context.Context, service string, from, to, restoredBefore time.Time) (int64, error) {
+	return 0, repo.err
+}
+
+func (repo *memoryRepo) Ping(ctx context.Context) error  { return repo.err }
+func (repo *memoryRepo) Close(ctx context.Context) error { return nil }
+
+// validRecord returns a record as sent by the toolkit CloudMode
+func validRecord() map[string]interface{} {
+	return map[string]interface{}{
+		"message":        "request completed",
+		"service":        "partner",
+		"log_level":      "info",
+		"timestamp":      "2024-01-31T10:15:00.123456+05:30",
+		"req_id":         "6f0c6f8e-1b1a-4c4e-9d0e-2f1b8d7c6a5b",
+		"trace_id":       "4bf92f3577b34da6a3ce929d0e0e4736",
+		"span_id":        "00f067aa0ba902b7",
+		"parent_span_id": "b7ad6b7169203331",
+		"endpoint":       "/api/:version/partners/:partner_id",
+		"method":         "GET",
+		"response_code":  float64(200),
+		"duration_ms":    "1.2ms",
+		"request_dump":   map[string]interface{}{"headers": map[string]interface{}{}},
+		"func":           "partner/internal/controllers.(*PartnerController).GetPartner",
+	}
+}
+
+func TestValidateRecord(t *testing.T) {
+	t.Run("valid record", func(t *testing.T) {
+		log, errs := ValidateRecord(validRecord())
+		require.Empty(t, errs)
+		require.Equal(t, "partner", log.Service)
+		require.Equal(t, "info", log.Level)
+		require.Equal(t, "request completed", log.Message)
+		require.Equal(t, "2024-01-31T04:45:00.123456Z", log.Timestamp.Format("2006-01-02T15:04:05.999999Z07:00"))
+		require.Equal(t, "6f0c6f8e-1b1a-4c4e-9d0e-2f1b8d7c6a5b", log.RequestID)
+		require.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", log.TraceID)
+		require.Equal(t, "00f067aa0ba902b7", log.SpanID)
+		require.Equal(t, "/api/:version/partners/:partner_id", log.Endpoint)
+		require.Equal(t, "GET", log.Method)
+		require.Equal(t, 200, log.Status)
+		require.Equal(t, "1.2ms", log.Fields["duration_ms"])
+		require.Equal(t, "b7ad6b7169203331", log.Fields["parent_span_id"])
+		require.Contains(t, log.Fields, "func")
+		require.NotContains(t, log.Fields, "service")
+	})
+
+	t.Run("warning level is normalized", func(t *testing.T) {
+		record := validRecord()
+		record["log_level"] = "warn"
+		log, errs := ValidateRecord(record)
+		require.Empty(t, errs)
+		require.Equal(t, "warning", log.Level)
+	})
+
+	tests := []struct {
+		name   string
+		update func(map[string]interface{})
+		errs   map[string]string
+	}{
+		{
+			name: "missing required fields",
+			update: func(record map[string]interface{}) {
+				delete(record, "service")
+				delete(record, "timestamp")
+			},
+			errs: map[string]string{"service": consts.Required, "timestamp": consts.Required},
+		},
+		{
+			name:   "empty service",
+			update: func(record map[string]interface{}) { record["service"] = "" },
+			errs:   map[string]string{"service": consts.Invalid},
+		},
+		{
+			name:   "unknown level",
+			update: func(record map[string]interface{}) { record["log_level"] = "loud" },
+			errs:   map[string]string{"log_level": consts.Invalid},
+		},
+		{
+			name:   "timestamp not RFC 3339",
+			update: func(record map[string]interface{}) { record["timestamp"] = "31-01-2024" },
+			errs:   map[string]string{"timestamp": consts.Invalid},
+		},
+		{
+			name:   "request id not a string",
+			update: func(record map[string]interface{}) { record["req_id"] = float64(12) },
+			errs:   map[string]string{"req_id": consts.Invalid},
+		},
+		{
+			name: "malformed trace ids",
+			update: func(record map[string]interface{}) {
+				record["trace_id"] = "4BF92F3577B34DA6A3CE929D0E0E4736"
+				record["span_id"] = "00f067aa"
+			},
+			errs: map[string]string{"trace_id": consts.Invalid, "span_id": consts.Invalid},
+		},
+		{
+			name:   "response code out of range",
+			update: func(record map[string]interface{}) { record["response_code"] = float64(1000) },
+			errs:   map[string]string{"response_code": consts.Invalid},
+		},
+		{
+			name:   "response code not a number",
+			update: func(record map[string]interface{}) { record["response_code"] = "200" },
+			errs:   map[string]string{"response_code": consts.Invalid},
+		},
+		{
+			name:   "dump not an object",
+			update: func(record map[string]interface{}) { record["request_dump"] = "GET /" },
+			errs:   map[string]string{"request_dump": consts.Invalid},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			record := validRecord()
+			tt.update(record)
+			_, errs := ValidateRecord(record)
+			require.Equal(t, tt.errs, errs)
+		})
+	}
+}
+
+func TestIngestLogs(t *testing.T) {
+	logger.InitLogger(&logger.ClientOptions{Service: consts.AppName, LogLevel: "panic"})
+
+	t.Run("invalid records are reported", func(t *testing.T) {
+		repo := &memoryRepo{}
+		invalid := validRecord()
+		invalid["log_level"] = "loud"
+
+		other := validRecord()
+		other["message"] = "request started"
+
+		result, err := NewLogUseCases(repo, NewHub(1, 1), nil).IngestLogs(context.Background(), []map[string]interface{}{validRecord(), invalid, other})
+		require.NoError(t, err)
+		require.Equal(t, 2, result.Accepted)
+		require.Equal(t, 1, result.Rejected)
+		require.Equal(t, map[string]string{"logs[1].log_level": consts.Invalid}, result.Errors)
+
+		require.Len(t, repo.logs, 2)
+		require.NotEmpty(t, repo.logs[0].ID)
+		require.NotEqual(t, repo.logs[0].ID, repo.logs[1].ID)
+		require.False(t, repo.logs[0].ReceivedAt.IsZero())
+	})
+
+	t.Run("a record sent again keeps its id", func(t *testing.T) {
+		repo := &memoryRepo{}
+		useCases := NewLogUseCases(repo, NewHub(1, 1), nil)
+		for i := 0; i < 2; i++ {
+			_, err := useCases.IngestLogs(context.Background(), []map[string]interface{}{validRecord()})
+			require.NoError(t, err)
+		}
+		require.Len(t, repo.logs, 2)
+		require.Equal(t, repo.logs[0].ID, repo.logs[1].ID)
+	})
+
+	t.Run("storage failure", func(t *testing.T) {
+		repo := &memoryRepo{err: errors.New("disk full")}
+		_, err := NewLogUseCases(repo, NewHub(1, 1), nil).IngestLogs(context.Background(), []map[string]interface{}{validRecord()})
+		require.Error(t, err)
+	})
+}
+
+func TestSearchLogs(t *testing.T) {
+	logger.InitLogger(&logger.ClientOptions{Service: consts.AppName, LogLevel: "panic"})
+
+	t.Run("defaults and metadata", func(t *testing.T) {
+		repo := &memoryRepo{logs: make([]entities.Log, 12)}
+		resp, errs, err := NewLogUseCases(repo, NewHub(1, 1), nil).SearchLogs(context.Background(), entities.LogFilter{Level: "warn"}, entities.Pagination{})
+		require.NoError(t, err)
+		require.Empty(t, errs)
+		require.Equal(t, "warning", repo.filter.Level)
+		require.Equal(t, entities.Pagination{Page: 1, Limit: 10}, repo.pagination)
+		require.Equal(t, int64(12), resp.MetaData.Total)
+		require.Equal(t, int32(2), resp.MetaData.Next)
+	})
+
+	t.Run("no record", func(t *testing.T) {
+		resp, _, err := NewLogUseCases(&memoryRepo{}, NewHub(1, 1), nil).SearchLogs(context.Background(), entities.LogFilter{}, entities.Pagination{})
+		require.NoError(t, err)
+		require.Nil(t, resp.MetaData)
+	})
+
+	t.Run("invalid filter", func(t *testing.T) {
+		now := time.Now()
+		filter := entities.LogFilter{
+			Level:  "loud",
+			Status: 42,
+			From:   now,
+			To:     now.Add(-time.Hour),
+			Format: "csv",
+		}
+		called := false
+		errs, err := NewLogUseCases(&memoryRepo{logs: make([]entities.Log, 1)}, NewHub(1, 1), nil).ExportLogs(context.Background(), filter, func(entities.Log) error {
+			called = true
+			return nil
+		})
+		require.NoError(t, err)
+		require.False(t, called)
+		require.Equal(t, map[string]string{
+			"log_level":     consts.Invalid,
+			"response_code": consts.Invalid,
+			"to":            consts.Invalid,
+			"format":        consts.Invalid,
+		}, errs)
+	})
+}
@@ -0,0 +1,240 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"sort"
+	"time"
+
+	"gitlab.com/tuneverse/toolkit/core/logger"
+	"gitlab.com/tuneverse/toolkit/internal/consts"
+	"gitlab.com/tuneverse/toolkit/internal/entities"
+	"gitlab.com/tuneverse/toolkit/internal/repo"
+)
+
+// servicePattern matches the service names accepted by the restore
+var servicePattern = regexp.MustCompile(`^[A-Za-z0-9_.\-]+$`)
+
+// RetentionUseCases archives and purges the records past their retention and
+// restores the archived days.
+type RetentionUseCases struct {
+	repo    repo.LogRepoImply
+	archive repo.ArchiveRepoImply
+	cfg     entities.Retention
+	now     func() time.Time
+}
+
+// RetentionUseCaseImply is an interface defining the methods of the record
+// lifecycle.
+type RetentionUseCaseImply interface {
+	ApplyRetention(ctx context.Context) (entities.RetentionResult, error)
+	RestoreLogs(ctx context.Context, service, day string) (*entities.RestoreResult, map[string]string, error)
+}
+
+// NewRetentionUseCases creates a new RetentionUseCases instance.
+func NewRetentionUseCases(logRepo repo.LogRepoImply, archiveRepo repo.ArchiveRepoImply, cfg entities.Retention) RetentionUseCaseImply {
+	return &RetentionUseCases{
+		repo:    logRepo,
+		archive: archiveRepo,
+		cfg:     cfg,
+		now:     time.Now,
+	}
+}
+
+// retentionDays returns the number of days the records of a service are
+// kept, 0 keeps them forever
+func (r *RetentionUseCases) retentionDays(service string) int {
+	if days, ok := r.cfg.Services[service]; ok {
+		return days
+	}
+	return r.cfg.Days
+}
+
+// ApplyRetention archives the service days past their retention, then purges
+// them. A day whose archive failed is kept and retried by the next run, the
+// other days are processed anyway.
+func (r *RetentionUseCases) ApplyRetention(ctx context.Context) (entities.RetentionResult, error) {
+	var (
+		log    = logger.Log().WithContext(ctx)
+		result entities.RetentionResult
+		now    = r.now().UTC()
+		today  = now.Truncate(24 * time.Hour)
+	)
+
+	// the shortest retention bounds the days to look at
+	shortest := 0
+	for _, days := range append([]int{r.cfg.Days}, mapValues(r.cfg.Services)...) {
+		if days > 0 && (shortest == 0 || days < shortest) {
+			shortest = days
+		}
+	}
+	if shortest == 0 {
+		return result, nil
+	}
+	logDays, err := r.repo.LogDays(ctx, today.AddDate(0, 0, -shortest))
+	if err != nil {
+		log.Errorf("[RetentionUseCases][ApplyRetention] listing the days failed, Error : %s", err.Error())
+		return result, err
+	}
+
+	var errs []error
+	for _, day := range logDays {
+		retention := r.retentionDays(day.Service)
+		if retention <= 0 || day.Day.AddDate(0, 0, 1).After(today.AddDate(0, 0, -retention)) {
+			continue
+		}
+		result.Days++
+		archived, err := r.archiveDay(ctx, day)
+		if err != nil {
+			log.Errorf("[RetentionUseCases][ApplyRetention] archiving %s of %s failed, Error : %s",
+				day.Day.Format(consts.LogFileDateFormat), day.Service, err.Error())
+			result.Failed++
+			errs = append(errs, err)
+			continue
+		}
+		result.Archived += archived
+		purged, err := r.repo.PurgeLogs(ctx, day.Service, day.Day, day.Day.AddDate(0, 0, 1), now.Add(-r.cfg.RestoreHold))
+		result.Purged += purged
+		if err != nil {
+			log.Errorf("[RetentionUseCases][ApplyRetention] purging %s of %s failed, Error : %s",
+				day.Day.Format(consts.LogFileDateFormat), day.Service, err.Error())
+			result.Failed++
+			errs = append(errs, err)
+		}
+	}
+	log.Infof("[RetentionUseCases][ApplyRetention] %d days, %d records archived, %d purged, %d failed",
+		result.Days, result.Archived, result.Purged, result.Failed)
+	return result, errors.Join(errs...)
+}
+
+// archiveDay adds the stored records of a service day to its archive and
+// returns their number. The restored records are already archived. The
+// records of an existing archive are kept, so records received late are
+// added to the day instead of replacing it.
+func (r *RetentionUseCases) archiveDay(ctx context.Context, day entities.LogDay) (int, error) {
+	stored, err := r.dayLogs(ctx, day)
+	if err != nil {
+		return 0, err
+	}
+	logs := make([]entities.Log, 0, len(stored))
+	for _, log := range stored {
+		if log.RestoredAt == nil {
+			logs = append(logs, log)
+		}
+	}
+	if len(logs) == 0 {
+		return 0, nil
+	}
+
+	archived, err := r.archive.ReadArchive(ctx, day)
+	if err != nil && !errors.Is(err, repo.ErrArchiveNotFound) {
+		return 0, err
+	}
+	merged := mergeLogs(archived, logs)
+	if err := r.archive.WriteArchive(ctx, day, merged); err != nil {
+		return 0, err
+	}
+	return len(logs), nil
+}
+
+// RestoreLogs stores the records of an archived service day again. They are
+// kept for the restore hold, then purged by the retention job. The records
+// of the day still stored are not duplicated.
+func (r *RetentionUseCases) RestoreLogs(ctx context.Context, service, day string) (*entities.RestoreResult, map[string]string, error) {
+	var (
+		log  = logger.Log().WithContext(ctx)
+		errs = map[string]string{}
+	)
+	if !servicePattern.MatchString(service) {
+		errs["service"] = consts.Invalid
+	}
+	start, err := time.Parse(consts.LogFileDateFormat, day)
+	if err != nil {
+		errs["day"] = consts.Invalid
+	}
+	if len(errs) > 0 {
+		return nil, errs, nil
+	}
+	logDay := entities.LogDay{Service: service, Day: start}
+
+	archived, err := r.archive.ReadArchive(ctx, logDay)
+	if err != nil {
+		if !errors.Is(err, repo.ErrArchiveNotFound) {
+			log.Errorf("[RetentionUseCases][RestoreLogs] Error : %s", err.Error())
+		}
+		return nil, nil, err
+	}
+	stored, err := r.dayLogs(ctx, logDay)
+	if err != nil {
+		log.Errorf("[RetentionUseCases][RestoreLogs] Error : %s", err.Error())
+		return nil, nil, err
+	}
+	ids := make(map[string]bool, len(stored))
+	for _, record := range stored {
+		ids[record.ID] = true
+	}
+
+	restoredAt := r.now().UTC()
+	logs := make([]entities.Log, 0, len(archived))
+	for _, record := range archived {
+		if !ids[record.ID] {
+			record.RestoredAt = &restoredAt
+			logs = append(logs, record)
+		}
+	}
+	for i := 0; i < len(logs); i += consts.MaxBatchSize {
+		end := i + consts.MaxBatchSize
+		if end > len(logs) {
+			end = len(logs)
+		}
+		if err := r.repo.StoreLogs(ctx, logs[i:end]); err != nil {
+			log.Errorf("[RetentionUseCases][RestoreLogs] storing the records failed, Error : %s", err.Error())
+			return nil, nil, err
+		}
+	}
+	log.Infof("[RetentionUseCases][RestoreLogs] %d records of %s restored for %s", len(logs), service, day)
+	return &entities.RestoreResult{
+		Service:  service,
+		Day:      day,
+		Restored: len(logs),
+	}, nil, nil
+}
+
+// dayLogs returns the stored records of a service day
+func (r *RetentionUseCases) dayLogs(ctx context.Context, day entities.LogDay) ([]entities.Log, error) {
+	var logs []entities.Log
+	filter := entities.LogFilter{Service: day.Service, From: day.Day, To: day.Day.AddDate(0, 0, 1)}
+	err := r.repo.ExportLogs(ctx, filter, func(log entities.Log) error {
+		logs = append(logs, log)
+		return nil
+	})
+	return logs, err
+}
+
+// mergeLogs returns the records of both lists once, oldest first
+func mergeLogs(archived, logs []entities.Log) []entities.Log {
+	ids := make(map[string]bool, len(logs))
+	for _, log := range logs {
+		ids[log.ID] = true
+	}
+	merged := append([]entities.Log{}, logs...)
+	for _, log := range archived {
+		if !ids[log.ID] {
+			merged = append(merged, log)
+		}
+	}
+	sort.SliceStable(merged, func(i, j int) bool {
+		return merged[i].Timestamp.Before(merged[j].Timestamp)
+	})
+	return merged
+}
+
+// mapValues returns the values of a map
+func mapValues(m map[string]int) []int {
+	values := make([]int, 0, len(m))
+	for _, value := range m {
+		values = append(values, value)
+	}
+	return values
+}
@@ -0,0 +1,106 @@
+package usecases
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"gitlab.com/tuneverse/toolkit/core/cloud/awsutils"
+	"gitlab.com/tuneverse/toolkit/internal/entities"
+	"gitlab.com/tuneverse/toolkit/internal/repo"
+)
+
+func TestRetention(t *testing.T) {
+	var (
+		ctx = context.Background()
+		dir = t.TempDir()
+		day = func(d int) time.Time { return time.Date(2024, 1, d, 0, 0, 0, 0, time.UTC) }
+		now = day(31).Add(10 * time.Hour)
+		cfg = entities.Retention{Days: 10, Services: map[string]int{"partner": 20}, RestoreHold: 24 * time.Hour}
+		ids = func(logs []entities.Log) []string {
+			var ids []string
+			for _, log := range logs {
+				ids = append(ids, log.ID)
+			}
+			return ids
+		}
+	)
+	logRepo, err := repo.NewFileLogRepo(filepath.Join(dir, "logs"))
+	require.NoError(t, err)
+	defer logRepo.Close(ctx)
+	archiveRepo := repo.NewObjectArchiveRepo(awsutils.NewLocalCloudService(filepath.Join(dir, "archive")), "logs", "collector")
+	useCase := NewRetentionUseCases(logRepo, archiveRepo, cfg).(*RetentionUseCases)
+	useCase.now = func() time.Time { return now }
+
+	require.NoError(t, logRepo.StoreLogs(ctx, []entities.Log{
+		{ID: "1", Timestamp: day(5).Add(time.Hour), Service: "partner", Level: "info", Message: "old partner"},
+		{ID: "2", Timestamp: day(5).Add(2 * time.Hour), Service: "utility", Level: "info", Message: "old utility"},
+		{ID: "3", Timestamp: day(15), Service: "partner", Level: "info", Message: "kept partner"},
+		{ID: "4", Timestamp: day(15).Add(time.Hour), Service: "utility", Level: "info", Message: "expired utility"},
+		{ID: "5", Timestamp: day(30), Service: "utility", Level: "info", Message: "recent utility"},
+	}))
+
+	stored := func() []string {
+		logs, _, err := logRepo.SearchLogs(ctx, entities.LogFilter{}, entities.Pagination{Page: 1, Limit: 10})
+		require.NoError(t, err)
+		return ids(logs)
+	}
+
+	t.Run("archive and purge", func(t *testing.T) {
+		result, err := useCase.ApplyRetention(ctx)
+		require.NoError(t, err)
+		require.Equal(t, entities.RetentionResult{Days: 3, Archived: 3, Purged: 3}, result)
+		require.Equal(t, []string{"5", "3"}, stored())
+
+		archived, err := archiveRepo.ReadArchive(ctx, entities.LogDay{Service: "utility", Day: day(15)})
+		require.NoError(t, err)
+		require.Equal(t, []string{"4"}, ids(archived))
+		_, err = os.Stat(filepath.Join(dir, "archive", "logs", "collector", "partner", "2024-01-05.ndjson.gz"))
+		require.NoError(t, err)
+	})
+
+	t.Run("late records are added to the archive", func(t *testing.T) {
+		require.NoError(t, logRepo.StoreLogs(ctx, []entities.Log{
+			{ID: "6", Timestamp: day(5), Service: "partner", Level: "info", Message: "late partner"},
+		}))
+		result, err := useCase.ApplyRetention(ctx)
+		require.NoError(t, err)
+		require.Equal(t, 1, result.Archived)
+
+		archived, err := archiveRepo.ReadArchive(ctx, entities.LogDay{Service: "partner", Day: day(5)})
+		require.NoError(t, err)
+		require.Equal(t, []string{"6", "1"}, ids(archived))
+	})
+
+	t.Run("restore", func(t *testing.T) {
+		result, errs, err := useCase.RestoreLogs(ctx, "partner", "2024-01-05")
+		require.NoError(t, err)
+		require.Empty(t, errs)
+		require.Equal(t, 2, result.Restored)
+		require.Equal(t, []string{"5", "3", "1", "6"}, stored())
+
+		// kept during the restore hold, and not archived again
+		retention, err := useCase.ApplyRetention(ctx)
+		require.NoError(t, err)
+		require.Equal(t, entities.RetentionResult{Days: 1}, retention)
+		require.Equal(t, []string{"5", "3", "1", "6"}, stored())
+
+		now = now.Add(25 * time.Hour)
+		retention, err = useCase.ApplyRetention(ctx)
+		require.NoError(t, err)
+		require.Equal(t, int64(2), retention.Purged)
+		require.Equal(t, []string{"5", "3"}, stored())
+	})
+
+	t.Run("restore errors", func(t *testing.T) {
+		_, _, err := useCase.RestoreLogs(ctx, "partner", "2024-01-06")
+		require.ErrorIs(t, err, repo.ErrArchiveNotFound)
+
+		_, errs, err := useCase.RestoreLogs(ctx, "../partner", "06-01-2024")
+		require.NoError(t, err)
+		require.Len(t, errs, 2)
+	})
+}
@@ -0,0 +1,191 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"time"
+
+	constants "gitlab.com/tuneverse/toolkit/consts"
+	"gitlab.com/tuneverse/toolkit/core/logger"
+	"gitlab.com/tuneverse/toolkit/internal/consts"
+	"gitlab.com/tuneverse/toolkit/internal/entities"
+)
+
+// errTimelineFull ends the export once a timeline holds MaxTimelineRecords
+var errTimelineFull = errors.New("timeline full")
+
+// errorLevels are the levels highlighted in a timeline
+var errorLevels = map[string]bool{
+	"error": true,
+	"fatal": true,
+	"panic": true,
+}
+
+// Timeline gathers the records sharing the queried request or trace id. The
+// request and trace ids of the records found are followed in turn, so a
+// request id also brings the records of the services the request called
+// within its trace. It returns nil when no record matches.
+func (l *LogUseCases) Timeline(ctx context.Context, query entities.TimelineQuery) (*entities.Timeline, map[string]string, error) {
+	errs := map[string]string{}
+	if query.ID == "" {
+		errs["id"] = consts.Required
+	} else if len(query.ID) > consts.MaxIDLength {
+		errs["id"] = consts.Invalid
+	}
+	if !query.From.IsZero() && !query.To.IsZero() && !query.From.Before(query.To) {
+		errs["to"] = consts.Invalid
+	}
+	if query.Format != "" && query.Format != consts.FormatHTML {
+		errs["format"] = consts.Invalid
+	}
+	if len(errs) > 0 {
+		return nil, errs, nil
+	}
+
+	var (
+		records    = map[string]entities.Log{}
+		requestIDs = map[string]bool{query.ID: true}
+		traceIDs   = map[string]bool{query.ID: true}
+		pending    = []entities.LogFilter{{RequestID: query.ID}, {TraceID: query.ID}}
+		truncated  bool
+	)
+	// follow queues the records of an id not seen yet
+	follow := func(ids map[string]bool, id string, filter entities.LogFilter) {
+		if id == "" || ids[id] {
+			return
+		}
+		if len(requestIDs)+len(traceIDs) >= consts.MaxTimelineIDs {
+			truncated = true
+			return
+		}
+		ids[id] = true
+		pending = append(pending, filter)
+	}
+	for len(pending) > 0 {
+		filter := pending[0]
+		pending = pending[1:]
+		filter.From, filter.To = query.From, query.To
+		err := l.repo.ExportLogs(ctx, filter, func(log entities.Log) error {
+			if _, ok := records[log.ID]; ok {
+				return nil
+			}
+			if len(records) >= consts.MaxTimelineRecords {
+				return errTimelineFull
+			}
+			records[log.ID] = log
+			follow(requestIDs, log.RequestID, entities.LogFilter{RequestID: log.RequestID})
+			follow(traceIDs, log.TraceID, entities.LogFilter{TraceID: log.TraceID})
+			return nil
+		})
+		if errors.Is(err, errTimelineFull) {
+			truncated = true
+			break
+		}
+		if err != nil {
+			logger.Log().WithContext(ctx).Errorf("[LogUseCases][Timeline] Error : %s", err.Error())
+			return nil, nil, err
+		}
+	}
+	if len(records) == 0 {
+		return nil, nil, nil
+	}
+
+	logs := make([]entities.Log, 0, len(records))
+	for _, log := range records {
+		logs = append(logs, log)
+	}
+	timeline := buildTimeline(query.ID, logs)
+	timeline.Truncated = truncated
+	return timeline, nil, nil
+}
+
+// buildTimeline orders the records and sums them up per service
+func buildTimeline(id string, logs []entities.Log) *entities.Timeline {
+	sort.SliceStable(logs, func(i, j int) bool {
+		if !logs[i].Timestamp.Equal(logs[j].Timestamp) {
+			return logs[i].Timestamp.Before(logs[j].Timestamp)
+		}
+		return logs[i].ReceivedAt.Before(logs[j].ReceivedAt)
+	})
+
+	var (
+		timeline = &entities.Timeline{
+			ID:      id,
+			Start:   logs[0].Timestamp,
+			End:     logs[len(logs)-1].Timestamp,
+			Entries: make([]entities.TimelineEntry, 0, len(logs)),
+		}
+		spans      = map[string]*entities.ServiceSpan{}
+		requestIDs = map[string]bool{}
+		traceIDs   = map[string]bool{}
+	)
+	timeline.Duration = milliseconds(timeline.End.Sub(timeline.Start))
+	for _, log := range logs {
+		isError := errorLevels[log.Level] || log.Status >= 500
+		timeline.Entries = append(timeline.Entries, entities.TimelineEntry{
+			Offset: milliseconds(log.Timestamp.Sub(timeline.Start)),
+			Error:  isError,
+			Log:    log,
+		})
+		if log.RequestID != "" && !requestIDs[log.RequestID] {
+			requestIDs[log.RequestID] = true
+			timeline.RequestIDs = append(timeline.RequestIDs, log.RequestID)
+		}
+		if log.TraceID != "" && !traceIDs[log.TraceID] {
+			traceIDs[log.TraceID] = true
+			timeline.TraceIDs = append(timeline.TraceIDs, log.TraceID)
+		}
+
+		span, ok := spans[log.Service]
+		if !ok {
+			span = &entities.ServiceSpan{Service: log.Service, Start: log.Timestamp}
+			spans[log.Service] = span
+		}
+		span.End = log.Timestamp
+		span.Records++
+		if isError {
+			span.Errors++
+			timeline.Errors++
+		}
+		if duration, ok := requestDuration(log); ok {
+			span.Requests++
+			span.Duration += duration
+		}
+	}
+
+	for _, span := range spans {
+		if span.Requests == 0 {
+			span.Duration = milliseconds(span.End.Sub(span.Start))
+		}
+		timeline.Services = append(timeline.Services, *span)
+	}
+	sort.Slice(timeline.Services, func(i, j int) bool {
+		return timeline.Services[i].Start.Before(timeline.Services[j].Start)
+	})
+	return timeline
+}
+
+// requestDuration returns the duration_ms of a record in milliseconds. The
+// toolkit middleware logs it as a Go duration, "12.5ms", a number is taken
+// as milliseconds.
+func requestDuration(log entities.Log) (float64, bool) {
+	switch value := log.Fields[constants.ContextRequestTimetaken].(type) {
+	case float64:
+		return value, true
+	case string:
+		if duration, err := time.ParseDuration(value); err == nil {
+			return milliseconds(duration), true
+		}
+		if duration, err := strconv.ParseFloat(value, 64); err == nil {
+			return duration, true
+		}
+	}
+	return 0, false
+}
+
+// milliseconds converts a duration to fractional milliseconds
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
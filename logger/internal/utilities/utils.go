@@ -0,0 +1,22 @@
+package utilities
+
+import (
+	"gitlab.com/tuneverse/toolkit/internal/consts"
+	"gitlab.com/tuneverse/toolkit/models/api"
+)
+
+// For success response
+func SuccessResponseGenerator(message string, code int, data any) api.Response {
+	if data == nil {
+		data = map[string]interface{}{}
+	}
+	return api.Response{Status: consts.SuccessKey, Message: message, Code: code, Data: data, Errors: map[string]string{}}
+}
+
+// For error response
+func ErrorResponseGenerator(message string, code int, errors any) api.Response {
+	if errors == nil {
+		errors = map[string]interface{}{}
+	}
+	return api.Response{Status: consts.Failure, Message: message, Code: code, Data: map[string]string{}, Errors: errors}
+}
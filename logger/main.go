@@ -1 +1,9 @@
-package toolkit
+package main
+
+import (
+	"gitlab.com/tuneverse/toolkit/app"
+)
+
+func main() {
+	app.Run()
+}
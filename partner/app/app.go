@@ -44,11 +44,13 @@ func Run() {
 	}
 
 	file := &logger.FileMode{
-		LogfileName:  "partner.log",
-		LogPath:      "logs",
-		LogMaxAge:    consts.LogMaxAge,
-		LogMaxSize:   consts.LogMaxSize,
-		LogMaxBackup: consts.LogMaxBackup,
+		LogfileName:     "partner.log",
+		LogPath:         "logs",
+		Rotation:        logger.RotateDaily,
+		LogMaxAge:       consts.LogMaxAge,
+		LogMaxSize:      consts.LogMaxSize,
+		LogMaxBackup:    consts.LogMaxBackup,
+		LogMaxTotalSize: consts.LogMaxTotalSize,
 	}
 
 	clientOpt := &logger.ClientOptions{
@@ -58,28 +60,6 @@ func Run() {
 		IncludeResponseDump: false,
 	}
 
-	if cfg.Debug {
-		logger.InitLogger(clientOpt, file)
-	} else {
-		db := &logger.CloudMode{
-
-			URL:    consts.LoggerServiceURL,
-			Secret: consts.LoggerSecret,
-		}
-		logger.InitLogger(clientOpt, db, file)
-	}
-	// Check if the application is in debug mode.
-	if cfg.Debug {
-		logger.InitLogger(clientOpt, file)
-	} else {
-		db := &logger.CloudMode{
-			URL:    consts.LoggerServiceURL,
-			Secret: consts.LoggerSecret,
-		}
-
-		// Initialize the logger with the specified configurations for database, file, and console logging.
-		logger.InitLogger(clientOpt, db, file)
-	}
 	// Check if the application is in debug mode.
 	if cfg.Debug {
 		logger.InitLogger(clientOpt, file)
@@ -94,9 +74,19 @@ func Run() {
 			Secret: consts.LoggerSecret,
 		}
 
+		// Repeated messages, e.g. the same error during a downstream outage,
+		// are sampled instead of reaching the log service one by one.
+		clientOpt.Sampling = &logger.SamplingOptions{}
+
 		// Initialize the logger with the specified configurations for database, file, and console logging.
 		logger.InitLogger(clientOpt, db, file)
 	}
+	// SIGUSR1 raises the log level to debug, SIGUSR2 restores it
+	stopLevelSignals := logger.HandleLevelSignals(logger.LevelSignalOptions{
+		RevertAfter: consts.LogLevelRevertAfter,
+	})
+	defer stopLevelSignals()
+
 	activitylog, err := activitylog.Init(consts.ActivityLogServiceURL)
 	if err != nil {
 		log.Fatalf("unable to connect the activity log service : %v", err)
@@ -129,6 +119,7 @@ func Run() {
 
 	api := router.Group("/api")
 	api.Use(middleware.LogMiddleware(map[string]interface{}{}))
+	api.Use(middleware.Recovery())
 	api.Use(middleware.APIVersionGuard(middleware.VersionOptions{
 		AcceptedVersions: cfg.AcceptedVersions,
 	}))
@@ -151,6 +142,12 @@ func Run() {
 		},
 	))
 
+	// runtime log level control, restricted to the tokens signed with the
+	// logger secret
+	levels := logger.LevelHandler(logger.LevelHandlerOptions{Authorize: logger.AuthorizeToken(consts.LoggerSecret)})
+	api.GET("/:version/admin/log-level", levels)
+	api.PUT("/:version/admin/log-level", levels)
+
 	// complete user related initialization
 	{
 
@@ -224,6 +221,13 @@ func launch(cfg *entities.EnvConfig, router *gin.Engine) {
 	if err := srv.Shutdown(ctx); err != nil {
 		log.Fatalf("Server Shutdown:%s", err.Error())
 	}
+
+	// deliver the logs of the last requests before exiting
+	logCtx, logCancel := context.WithTimeout(context.Background(), consts.LogCloseTimeout)
+	defer logCancel()
+	if err := logger.Log().Close(logCtx); err != nil {
+		log.Printf("closing the logger failed: %s", err.Error())
+	}
 	// catching ctx.Done(). timeout of 5 seconds.
 
 	<-ctx.Done()
@@ -173,6 +173,9 @@ const (
 	LogMaxAge                    = 7
 	LogMaxSize                   = 1024 * 1024 * 10
 	LogMaxBackup                 = 5
+	LogMaxTotalSize              = 1024 * 1024 * 100
+	LogLevelRevertAfter          = 15 * time.Minute
+	LogCloseTimeout              = 5 * time.Second
 	MaxExpiryWarningCount        = 20
 	MaxFreePlanLimit             = 100
 	MaxRemittancePerMonth        = 20
@@ -236,6 +239,7 @@ const (
 	GetPartnerOauthCredentialErrMsg       = "GetPartnerByOauthCredential failed, err = %s"
 	CreatePartnerErrMsg                   = "CreatePartner failed err=%s"
 	GetPartnerPaymentGatewaysErrMsg       = "GetPartnerPaymentGateways failed ,err=%s"
+	GetPartnerPaymentGatewaysFailedMsg    = "GetPartnerPaymentGateways failed"
 	GetAllPartnersErrMsg                  = "Get all Partners failed, err = %s"
 	UpdateTermsAndConditionsErrMsg        = "Update Terms and conditions failed, err = %s"
 	DeletePartnerErrMsg                   = "Delete partner failed,error=%s"
@@ -113,10 +113,9 @@ func (repo *PartnerRepo) GetPartnerStores(ctx context.Context, PartnerID string)
 	return store, nil
 }
 
-func (repo *PartnerRepo) GetPartnerPaymentGateways(ctx *gin.Context, PartnerID string) (entities.GetPaymentGateways, error) {
+func (repo *PartnerRepo) GetPartnerPaymentGateways(ctx *gin.Context, PartnerID string) (data entities.GetPaymentGateways, err error) {
 
 	var (
-		data                 entities.GetPaymentGateways
 		gatewayData          entities.PaymentGatewayDetails
 		gateways             []entities.PaymentGateways
 		details              entities.PaymentGateways
@@ -125,12 +124,17 @@ func (repo *PartnerRepo) GetPartnerPaymentGateways(ctx *gin.Context, PartnerID s
 		currencyId           int
 		gatewayId            string
 		gatewayIds           []string
-		log                  = logger.Log().WithContext(ctx)
 	)
+	// errors are wrapped below and logged once here
+	defer func() {
+		if err != nil {
+			logger.Log().WithContext(ctx).WithError(err).Error(consts.GetPartnerPaymentGatewaysFailedMsg)
+		}
+	}()
 	query := ` SELECT payment_gateway_id,payment_details FROM partner_payment_gateway WHERE partner_id=$1`
 	rows, err := repo.db.QueryContext(ctx, query, PartnerID)
 	if err != nil {
-		return entities.GetPaymentGateways{}, err
+		return entities.GetPaymentGateways{}, logger.Wrap(err, "query partner payment gateways")
 	}
 
 	for rows.Next() {
@@ -140,8 +144,7 @@ func (repo *PartnerRepo) GetPartnerPaymentGateways(ctx *gin.Context, PartnerID s
 		)
 
 		if err != nil {
-
-			return entities.GetPaymentGateways{}, err
+			return entities.GetPaymentGateways{}, logger.Wrap(err, "scan partner payment gateway")
 		}
 		encryptedPaymentData = append(encryptedPaymentData, encryptedData)
 		gatewayIds = append(gatewayIds, gatewayId)
@@ -149,13 +152,11 @@ func (repo *PartnerRepo) GetPartnerPaymentGateways(ctx *gin.Context, PartnerID s
 	for i, encryptData := range encryptedPaymentData {
 		decryptedData, err := repo.DecryptPaymentData(ctx, encryptData)
 		if err != nil {
-			log.Errorf(consts.GetPartnerPaymentGatewaysErrMsg, err.Error())
-			return entities.GetPaymentGateways{}, err
+			return entities.GetPaymentGateways{}, logger.Wrap(err, "decrypt payment details")
 		}
 		err = json.Unmarshal([]byte(decryptedData), &details)
 		if err != nil {
-			log.Errorf(consts.GetPartnerPaymentGatewaysErrMsg, err.Error())
-			return entities.GetPaymentGateways{}, err
+			return entities.GetPaymentGateways{}, logger.Wrap(err, "decode payment details")
 		}
 		gateways = append(gateways, details)
 		gateways[i].GatewayId = gatewayIds[i]
@@ -176,8 +177,7 @@ func (repo *PartnerRepo) GetPartnerPaymentGateways(ctx *gin.Context, PartnerID s
 
 	rows, err = repo.db.QueryContext(ctx, query, PartnerID)
 	if err != nil {
-		log.Errorf(consts.GetPartnerPaymentGatewaysErrMsg, err.Error())
-		return entities.GetPaymentGateways{}, err
+		return entities.GetPaymentGateways{}, logger.Wrap(err, "query partner payment settings")
 	}
 	for rows.Next() {
 		err := rows.Scan(
@@ -187,19 +187,16 @@ func (repo *PartnerRepo) GetPartnerPaymentGateways(ctx *gin.Context, PartnerID s
 			&gatewayId,
 		)
 		if err != nil {
-			log.Errorf(consts.GetPartnerPaymentGatewaysErrMsg, err.Error())
-			return entities.GetPaymentGateways{}, err
+			return entities.GetPaymentGateways{}, logger.Wrap(err, "scan partner payment settings")
 		}
 	}
 	currency, err := utilities.GetCurrencyName(ctx, repo.cache, currencyId, consts.UtilityServiceURL)
 	if err != nil {
-		log.Errorf(consts.GetPartnerPaymentGatewaysErrMsg, err.Error())
-		return entities.GetPaymentGateways{}, err
+		return entities.GetPaymentGateways{}, logger.Wrap(err, "get default currency")
 	}
 	gatewayData.DefaultPaymentGateway, err = utilities.GetPaymentGatewayName(ctx, repo.cache, gatewayId, consts.UtilityServiceURL)
 	if err != nil {
-		log.Errorf(consts.GetPartnerPaymentGatewaysErrMsg, err.Error())
-		return entities.GetPaymentGateways{}, err
+		return entities.GetPaymentGateways{}, logger.Wrap(err, "get default payment gateway")
 	}
 	gatewayData.DefaultCurrency = currency
 	data.PaymentGatewayDetails = gatewayData
@@ -752,7 +749,7 @@ func (repo *PartnerRepo) UpdateTermsAndConditions(ctx context.Context, partnerID
 		return err
 	}
 
-	isUpdated, err := utilities.UpdateMemberTermsAndConditions(tx, id, false, partnerID, consts.MemberServiceURL)
+	isUpdated, err := utilities.UpdateMemberTermsAndConditions(ctx, id, false, partnerID, consts.MemberServiceURL)
 	if err != nil {
 		log.Errorf(consts.UpdateTermsAndConditionsErrMsg, err.Error())
 		return err
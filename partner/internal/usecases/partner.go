@@ -2102,6 +2102,8 @@ func (partner *PartnerUseCases) ValidatePartnerUpdate(ctx context.Context, partn
 // function to update partner details based on partner id
 func (partner *PartnerUseCases) UpdatePartner(ctx context.Context, partnerID string, memberID uuid.UUID, partnerData entities.PartnerProperties, endpoint string, method string, errMap map[string]models.ErrorResponse) (map[string]models.ErrorResponse, error) {
 
+	// every log line of the update carries the partner and the member
+	ctx = logger.ContextWithFields(ctx, consts.PartnerIDKey, partnerID, consts.MemberIdKey, memberID.String())
 	var (
 		log               = logger.Log().WithContext(ctx)
 		updatePartnerData entities.Partner
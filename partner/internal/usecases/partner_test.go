@@ -7,7 +7,6 @@ import (
 	"errors"
 	"net/http"
 	"net/http/httptest"
-	"partner/internal/consts"
 	"partner/internal/entities"
 	"partner/internal/repo/mock"
 	"testing"
@@ -17,15 +16,15 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/golang/mock/gomock"
 	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
-	"gitlab.com/tuneverse/toolkit/core/logger"
+	"gitlab.com/tuneverse/toolkit/core/logger/loggertest"
 	"gitlab.com/tuneverse/toolkit/models"
 	"gitlab.com/tuneverse/toolkit/utils/crypto"
 )
 
 var (
-	logLevel   = "info"
 	Currency   = "INR"
 	Language   = "EN"
 	Country    = "IN"
@@ -34,14 +33,6 @@ var (
 
 func TestGetPartnerOauthCredential(t *testing.T) {
 
-	clientOpt := &logger.ClientOptions{
-		Service:             consts.AppName,
-		LogLevel:            logLevel,
-		IncludeRequestDump:  false,
-		IncludeResponseDump: true,
-	}
-	_ = logger.InitLogger(clientOpt)
-
 	clientId, err := crypto.Encrypt("hello", []byte("tuneverse-esreve"))
 	require.Nil(t, err)
 	clientSecret, err := crypto.Encrypt("hello@123", []byte("tuneverse-esreve"))
@@ -144,13 +135,6 @@ func TestGetPartnerOauthCredential(t *testing.T) {
 }
 
 func TestGetPartnerById(t *testing.T) {
-	clientOpt := &logger.ClientOptions{
-		Service:             consts.AppName, // Service name.
-		LogLevel:            "info",         // Log level.
-		IncludeRequestDump:  false,          // Include request data in logs.
-		IncludeResponseDump: false,          // Include response data in logs.
-	}
-	_ = logger.InitLogger(clientOpt)
 	// Create a mock HTTP request
 	req, err := http.NewRequest(http.MethodGet, "", nil)
 	if err != nil {
@@ -197,13 +181,7 @@ func TestGetPartnerById(t *testing.T) {
 }
 
 func TestGetPartnerByIdError(t *testing.T) {
-	clientOpt := &logger.ClientOptions{
-		Service:             consts.AppName, // Service name.
-		LogLevel:            "info",         // Log level.
-		IncludeRequestDump:  false,          // Include request data in logs.
-		IncludeResponseDump: false,          // Include response data in logs.
-	}
-	_ = logger.InitLogger(clientOpt)
+	rec := loggertest.New(t)
 	// Create a mock HTTP request
 	req, err := http.NewRequest(http.MethodGet, "", nil)
 	if err != nil {
@@ -250,17 +228,15 @@ func TestGetPartnerByIdError(t *testing.T) {
 	assert.Error(t, err) // An error is expected
 	assert.Equal(t, expectedError, err)
 	assert.Equal(t, entities.GetPartner{}, viewedPartner) // Partner should be empty
+
+	// The failure is logged with the repository error
+	rec.AssertLogged(logrus.ErrorLevel, "Get partner by ID failed, err = database error", map[string]interface{}{
+		"file": "partner.go",
+	})
 }
 
 func TestGetTAllTermsAndConditions(t *testing.T) {
 	// Initialize a new controller
-	clientOpt := &logger.ClientOptions{
-		Service:             consts.AppName,
-		LogLevel:            logLevel,
-		IncludeRequestDump:  false,
-		IncludeResponseDump: false,
-	}
-	logger.InitLogger(clientOpt)
 
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -293,16 +269,6 @@ func TestGetTAllTermsAndConditions(t *testing.T) {
 }
 
 func TestUpdatePartnerSuccess(t *testing.T) {
-	clientOpt := &logger.ClientOptions{
-		Service:             consts.AppName,
-		LogLevel:            "info",
-		IncludeRequestDump:  false,
-		IncludeResponseDump: true,
-	}
-
-	// Check if the application is in debug mode.
-
-	_ = logger.InitLogger(clientOpt)
 	id := uuid.New()
 	testCases := []struct {
 		name          string
@@ -433,16 +399,6 @@ func TestUpdatePartnerSuccess(t *testing.T) {
 }
 
 func TestUpdatePartnerMinimalFields(t *testing.T) {
-	clientOpt := &logger.ClientOptions{
-		Service:             consts.AppName,
-		LogLevel:            "info",
-		IncludeRequestDump:  false,
-		IncludeResponseDump: true,
-	}
-
-	// Check if the application is in debug mode.
-
-	_ = logger.InitLogger(clientOpt)
 	id := uuid.New()
 	testCases := []struct {
 		name          string
@@ -536,16 +492,6 @@ func TestUpdatePartnerMinimalFields(t *testing.T) {
 
 }
 func TestUpdatePartnerEmptyFields(t *testing.T) {
-	clientOpt := &logger.ClientOptions{
-		Service:             consts.AppName,
-		LogLevel:            "info",
-		IncludeRequestDump:  false,
-		IncludeResponseDump: true,
-	}
-
-	// Check if the application is in debug mode.
-
-	_ = logger.InitLogger(clientOpt)
 	id := uuid.New()
 	testCases := []struct {
 		name          string
@@ -669,16 +615,6 @@ func TestUpdatePartnerEmptyFields(t *testing.T) {
 }
 
 func TestUpdatePartnerInvalidFields(t *testing.T) {
-	clientOpt := &logger.ClientOptions{
-		Service:             consts.AppName,
-		LogLevel:            "info",
-		IncludeRequestDump:  false,
-		IncludeResponseDump: true,
-	}
-
-	// Check if the application is in debug mode.
-
-	_ = logger.InitLogger(clientOpt)
 	id := uuid.New()
 	testCases := []struct {
 		name          string
@@ -804,16 +740,6 @@ func TestUpdatePartnerInvalidFields(t *testing.T) {
 }
 
 func TestUpdatePartnerMaxLengthErr(t *testing.T) {
-	clientOpt := &logger.ClientOptions{
-		Service:             consts.AppName,
-		LogLevel:            "info",
-		IncludeRequestDump:  false,
-		IncludeResponseDump: true,
-	}
-
-	// Check if the application is in debug mode.
-
-	_ = logger.InitLogger(clientOpt)
 	id := uuid.New()
 	testCases := []struct {
 		name          string
@@ -938,16 +864,6 @@ func TestUpdatePartnerMaxLengthErr(t *testing.T) {
 }
 func TestUpdatePartnerServerError(t *testing.T) {
 
-	clientOpt := &logger.ClientOptions{
-		Service:             consts.AppName,
-		LogLevel:            "info",
-		IncludeRequestDump:  false,
-		IncludeResponseDump: true,
-	}
-
-	// Check if the application is in debug mode.
-
-	_ = logger.InitLogger(clientOpt)
 	id := uuid.New()
 	testCases := []struct {
 		name          string
@@ -1079,17 +995,7 @@ func TestUpdatePartnerServerError(t *testing.T) {
 }
 
 func TestCreatePartnerFailure(t *testing.T) {
-	// logger.InitLogger(clientOpt)
-
-	clientOpt := &logger.ClientOptions{
-		Service:             consts.AppName, // Service name.
-		LogLevel:            logLevel,       // Log level.
-		IncludeRequestDump:  false,          // Include request data in logs.
-		IncludeResponseDump: true,           // Include response data in logs.
-	}
-	// Check if the application is in debug mode.
 
-	_ = logger.InitLogger(clientOpt)
 	testCases := []struct {
 		name          string
 		partner       entities.Partner
@@ -1200,17 +1106,7 @@ func TestCreatePartnerFailure(t *testing.T) {
 
 }
 func TestCreatePartnerDefaultField(t *testing.T) {
-	// logger.InitLogger(clientOpt)
-
-	clientOpt := &logger.ClientOptions{
-		Service:             consts.AppName, // Service name.
-		LogLevel:            logLevel,       // Log level.
-		IncludeRequestDump:  false,          // Include request data in logs.
-		IncludeResponseDump: true,           // Include response data in logs.
-	}
-	// Check if the application is in debug mode.
 
-	_ = logger.InitLogger(clientOpt)
 	testCases2 := []struct {
 		name          string
 		partner       entities.Partner
@@ -1320,17 +1216,7 @@ func TestCreatePartnerDefaultField(t *testing.T) {
 	}
 }
 func TestCreatePartnerMaxLengthErr(t *testing.T) {
-	// logger.InitLogger(clientOpt)
 
-	clientOpt := &logger.ClientOptions{
-		Service:             consts.AppName, // Service name.
-		LogLevel:            logLevel,       // Log level.
-		IncludeRequestDump:  false,          // Include request data in logs.
-		IncludeResponseDump: true,           // Include response data in logs.
-	}
-	// Check if the application is in debug mode.
-
-	_ = logger.InitLogger(clientOpt)
 	testCases2 := []struct {
 		name          string
 		partner       entities.Partner
@@ -1439,17 +1325,7 @@ func TestCreatePartnerMaxLengthErr(t *testing.T) {
 	}
 }
 func TestCreatePartnerInvalidFields(t *testing.T) {
-	// logger.InitLogger(clientOpt)
-
-	clientOpt := &logger.ClientOptions{
-		Service:             consts.AppName, // Service name.
-		LogLevel:            logLevel,       // Log level.
-		IncludeRequestDump:  false,          // Include request data in logs.
-		IncludeResponseDump: true,           // Include response data in logs.
-	}
-	// Check if the application is in debug mode.
 
-	_ = logger.InitLogger(clientOpt)
 	testCases2 := []struct {
 		name          string
 		partner       entities.Partner
@@ -1575,17 +1451,7 @@ func TestCreatePartnerInvalidFields(t *testing.T) {
 	}
 }
 func TestCreatePartnerEmptyFields(t *testing.T) {
-	// logger.InitLogger(clientOpt)
-
-	clientOpt := &logger.ClientOptions{
-		Service:             consts.AppName, // Service name.
-		LogLevel:            logLevel,       // Log level.
-		IncludeRequestDump:  false,          // Include request data in logs.
-		IncludeResponseDump: true,           // Include response data in logs.
-	}
-	// Check if the application is in debug mode.
 
-	_ = logger.InitLogger(clientOpt)
 	testCases2 := []struct {
 		name          string
 		partner       entities.Partner
@@ -1695,17 +1561,7 @@ func TestCreatePartnerEmptyFields(t *testing.T) {
 }
 
 func TestCreatePartnerSuccess(t *testing.T) {
-	// logger.InitLogger(clientOpt)
 
-	clientOpt := &logger.ClientOptions{
-		Service:             consts.AppName, // Service name.
-		LogLevel:            logLevel,       // Log level.
-		IncludeRequestDump:  false,          // Include request data in logs.
-		IncludeResponseDump: true,           // Include response data in logs.
-	}
-	// Check if the application is in debug mode.
-
-	_ = logger.InitLogger(clientOpt)
 	testCases2 := []struct {
 		name          string
 		partner       entities.Partner
@@ -1891,13 +1747,6 @@ func TestCreatePartnerSuccess(t *testing.T) {
 // }
 
 func TestGetAllPartners(t *testing.T) {
-	clientOpt := &logger.ClientOptions{
-		Service:             consts.AppName,
-		LogLevel:            "info",
-		IncludeRequestDump:  false,
-		IncludeResponseDump: false,
-	}
-	logger.InitLogger(clientOpt)
 	// Create a mock HTTP request
 	req, err := http.NewRequest("GET", "http://localhost:8031/api/v1/partners?page=1&limit=10", nil)
 	if err != nil {
@@ -2094,13 +1943,6 @@ func TestGetAllPartners(t *testing.T) {
 }
 
 func TestDeletePartner(t *testing.T) {
-	clientOpt := &logger.ClientOptions{
-		Service:             consts.AppName,
-		LogLevel:            "info",
-		IncludeRequestDump:  false,
-		IncludeResponseDump: false,
-	}
-	logger.InitLogger(clientOpt)
 
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -2151,13 +1993,6 @@ func TestDeletePartner(t *testing.T) {
 }
 
 func TestUpdateTermsAndConditions(t *testing.T) {
-	clientOpt := &logger.ClientOptions{
-		Service:             consts.AppName,
-		LogLevel:            logLevel,
-		IncludeRequestDump:  false,
-		IncludeResponseDump: true,
-	}
-	_ = logger.InitLogger(clientOpt)
 
 	testCases := []struct {
 		name               string
@@ -2265,13 +2100,6 @@ func TestUpdateTermsAndConditions(t *testing.T) {
 }
 
 func TestGetPartnerPaymentGateways(t *testing.T) {
-	clientOpt := &logger.ClientOptions{
-		Service:             consts.AppName,
-		LogLevel:            logLevel,
-		IncludeRequestDump:  false,
-		IncludeResponseDump: true,
-	}
-	_ = logger.InitLogger(clientOpt)
 
 	// Create a mock HTTP request
 	req, err := http.NewRequest("GET", "http://localhost:8031/api/v1/partners/61c1eb1c-4be4-44e2-80a9-6662cfed6dda/payment-gateways", nil)
@@ -2343,13 +2171,6 @@ func TestGetPartnerPaymentGateways(t *testing.T) {
 }
 
 func TestGetPartnerStores(t *testing.T) {
-	clientOpt := &logger.ClientOptions{
-		Service:             consts.AppName,
-		LogLevel:            logLevel,
-		IncludeRequestDump:  false,
-		IncludeResponseDump: true,
-	}
-	_ = logger.InitLogger(clientOpt)
 
 	testCases := []struct {
 		name          string
@@ -2397,13 +2218,6 @@ func TestGetPartnerStores(t *testing.T) {
 }
 
 func TestIsPartnerExists(t *testing.T) {
-	clientOpt := &logger.ClientOptions{
-		Service:             consts.AppName,
-		LogLevel:            logLevel,
-		IncludeRequestDump:  false,
-		IncludeResponseDump: true,
-	}
-	_ = logger.InitLogger(clientOpt)
 	testCases := []struct {
 		name          string
 		partnerId     string
@@ -2464,14 +2278,6 @@ func TestIsPartnerExists(t *testing.T) {
 
 func TestCreatePartnerStores(t *testing.T) {
 
-	clientOpt := &logger.ClientOptions{
-		Service:             consts.AppName,
-		LogLevel:            logLevel,
-		IncludeRequestDump:  false,
-		IncludeResponseDump: true,
-	}
-	_ = logger.InitLogger(clientOpt)
-
 	testCases := []struct {
 		name          string
 		partnerId     string
@@ -2556,13 +2362,6 @@ func TestCreatePartnerStores(t *testing.T) {
 
 func TestUpdatePartnerStatus(t *testing.T) {
 
-	clientOpt := &logger.ClientOptions{
-		Service:             consts.AppName,
-		LogLevel:            logLevel,
-		IncludeRequestDump:  false,
-		IncludeResponseDump: true,
-	}
-	_ = logger.InitLogger(clientOpt)
 	// Create a mock HTTP request
 	req, err := http.NewRequest("GET", "http://localhost:8031/api/v1/partners", nil)
 	if err != nil {
@@ -4,7 +4,6 @@ import (
 	"context"
 	"crypto/rand"
 	"crypto/sha256"
-	"database/sql"
 	"encoding/base64"
 	"encoding/hex"
 	"encoding/json"
@@ -249,7 +248,7 @@ func IsCurrencyIsoExists(ctx context.Context, cache cacheConf.Cache, currencyIso
 	apiURL := fmt.Sprintf("%s/currencies/exists/%s", apiUrl, currencyIso)
 	fmt.Println("urlllllllllllllllllllllllll", apiURL)
 	headers["Content-Type"] = "application/json"
-	response, err := utils.APIRequest(http.MethodHead, apiURL, headers, nil)
+	response, err := utils.APIRequestWithContext(ctx, http.MethodHead, apiURL, headers, nil)
 	if err != nil {
 		log.Printf("currency service failed  :failed to make API request: %v", err)
 		return false, consts.ErrUtilityServiceConnectionLost
@@ -290,7 +289,7 @@ func GetCurrencyName(ctx context.Context, cache cacheConf.Cache, id int, apiUrl
 	// retieve data from url if data is not available in the cache
 	apiURL := fmt.Sprintf("%s/currencies/%d", apiUrl, id)
 	headers["Content-Type"] = "application/json"
-	response, err := utils.APIRequest(http.MethodGet, apiURL, headers, nil)
+	response, err := utils.APIRequestWithContext(ctx, http.MethodGet, apiURL, headers, nil)
 	fmt.Println("urlllllllllllllllllllllllll", apiURL)
 	if err != nil {
 		log.Printf("failed to make API request: %v", err)
@@ -347,7 +346,7 @@ func GetCurrencyId(ctx context.Context, cache cacheConf.Cache, iso string, apiUr
 	fmt.Println("urlllllllllllllllllllllllll", apiURL)
 	headers["Content-Type"] = "application/json"
 
-	response, err := utils.APIRequest(http.MethodGet, apiURL, headers, nil)
+	response, err := utils.APIRequestWithContext(ctx, http.MethodGet, apiURL, headers, nil)
 	if err != nil {
 		log.Printf("failed to connect currency service: %v", err)
 		return 0, consts.ErrUtilityServiceConnectionLost
@@ -396,7 +395,7 @@ func IsCountryExists(ctx context.Context, cache cacheConf.Cache, country string,
 	headers := make(map[string]interface{})
 	headers["Content-Type"] = "application/json"
 
-	response, err := utils.APIRequest(http.MethodGet, apiURL, headers, nil)
+	response, err := utils.APIRequestWithContext(ctx, http.MethodGet, apiURL, headers, nil)
 	if err != nil {
 		log.Printf("failed to connect country service: %v", err)
 		return false, consts.ErrUtilityServiceConnectionLost
@@ -438,7 +437,7 @@ func IsLanguageIsoExists(ctx context.Context, cache cacheConf.Cache, languageIso
 	fmt.Println("urlllllllllllllllllllllllll", apiURL)
 	headers["Content-Type"] = "application/json"
 
-	response, err := utils.APIRequest(http.MethodHead, apiURL, headers, nil)
+	response, err := utils.APIRequestWithContext(ctx, http.MethodHead, apiURL, headers, nil)
 	if err != nil {
 		log.Printf("failed to connect language service :failed to make API request: %v", err)
 		return false, consts.ErrUtilityServiceConnectionLost
@@ -454,8 +453,9 @@ func IsLanguageIsoExists(ctx context.Context, cache cacheConf.Cache, languageIso
 
 }
 
-// function to update terms and conditions of a member by using partner_id and member id
-func UpdateMemberTermsAndConditions(ctx *sql.Tx, termsAndConditionsId int, ischecked bool, partnerID string, apiUrl string) (bool, error) {
+// function to update terms and conditions of a member by using partner_id and member id,
+// the trace of ctx is propagated to the member service
+func UpdateMemberTermsAndConditions(ctx context.Context, termsAndConditionsId int, ischecked bool, partnerID string, apiUrl string) (bool, error) {
 
 	apiURL := fmt.Sprintf("%s/members/terms-and-conditions", apiUrl)
 	fmt.Println("urlllllllllllllllllllllllll", apiURL)
@@ -468,7 +468,7 @@ func UpdateMemberTermsAndConditions(ctx *sql.Tx, termsAndConditionsId int, ische
 		"partner_id":                  partnerID,
 	}
 
-	response, err := utils.APIRequest(http.MethodPatch, apiURL, headers, body)
+	response, err := utils.APIRequestWithContext(ctx, http.MethodPatch, apiURL, headers, body)
 
 	if err != nil {
 		log.Print("failed to connect member service", err)
@@ -509,7 +509,7 @@ func GetLookupId(ctx context.Context, cache cacheConf.Cache, lookupType string,
 	// Make the API request
 	headers["Content-Type"] = "application/json"
 
-	response, err := utils.APIRequest(http.MethodGet, apiURL, headers, nil)
+	response, err := utils.APIRequestWithContext(ctx, http.MethodGet, apiURL, headers, nil)
 	if err != nil {
 		log.Printf("failed to connect lookup service ,err=%s", err)
 		return 0, consts.ErrUtilityServiceConnectionLost
@@ -567,7 +567,7 @@ func GetLookupName(ctx context.Context, cache cacheConf.Cache, id int, apiUrl st
 	// Make the API request
 	headers["Content-Type"] = "application/json"
 
-	response, err := utils.APIRequest(http.MethodGet, apiURL, headers, nil)
+	response, err := utils.APIRequestWithContext(ctx, http.MethodGet, apiURL, headers, nil)
 	if err != nil {
 		log.Printf("lookup service failed :failed to make API request: %v", err)
 		return "", consts.ErrUtilityServiceConnectionLost
@@ -624,7 +624,7 @@ func GetSubscriptionDurationId(ctx context.Context, cache cacheConf.Cache, name
 	// Make the API request
 	headers["Content-Type"] = "application/json"
 
-	response, err := utils.APIRequest(http.MethodGet, apiURL, headers, nil)
+	response, err := utils.APIRequestWithContext(ctx, http.MethodGet, apiURL, headers, nil)
 	if err != nil {
 		log.Printf("subscription service failed :failed to make API request: %v", err)
 		return 0, consts.ErrSubscriptionServiceConnectionLost
@@ -681,7 +681,7 @@ func GetSubcriptionDurationName(ctx context.Context, cache cacheConf.Cache, dura
 	// Make the API request
 	headers["Content-Type"] = "application/json"
 
-	response, err := utils.APIRequest(http.MethodGet, apiURL, headers, nil)
+	response, err := utils.APIRequestWithContext(ctx, http.MethodGet, apiURL, headers, nil)
 	if err != nil {
 		log.Printf("subscription service failed :failed to make API request: %v", err)
 		return "", consts.ErrSubscriptionServiceConnectionLost
@@ -718,7 +718,7 @@ func IsMemberExists(ctx context.Context, memberId string, partnerId string, apiU
 	headers := make(map[string]interface{})
 	headers["Content-Type"] = "application/json"
 
-	response, err := utils.APIRequest(http.MethodHead, apiURL, headers, nil)
+	response, err := utils.APIRequestWithContext(ctx, http.MethodHead, apiURL, headers, nil)
 	if err != nil {
 		log.Printf("failed to connect member service:failed to make API request: %v", err)
 		return false, consts.ErrMemberServiceConnectionLost
@@ -746,7 +746,7 @@ func IsStateIsoExists(ctx context.Context, cache cacheConf.Cache, countryCode st
 	fmt.Println("urlllllllllllllllllllllllll", apiURL)
 	headers["Content-Type"] = "application/json"
 
-	response, err := utils.APIRequest(http.MethodHead, apiURL, headers, nil)
+	response, err := utils.APIRequestWithContext(ctx, http.MethodHead, apiURL, headers, nil)
 	if err != nil {
 		log.Printf("failed to connect country state service:failed to make API request: %v", err)
 		return false, consts.ErrUtilityServiceConnectionLost
@@ -789,7 +789,7 @@ func GetTheme(ctx context.Context, cache cacheConf.Cache, id int, apiUrl string)
 	// Make the API request
 	headers["Content-Type"] = "application/json"
 
-	response, err := utils.APIRequest(http.MethodGet, apiURL, headers, nil)
+	response, err := utils.APIRequestWithContext(ctx, http.MethodGet, apiURL, headers, nil)
 	if err != nil {
 		log.Printf("theme service failed :failed to make API request: %v", err)
 		return "", consts.ErrUtilityServiceConnectionLost
@@ -827,7 +827,7 @@ func GetGenre(ctx context.Context, id string, apiUrl string) (string, error) {
 
 	headers := make(map[string]interface{})
 	headers["Content-Type"] = "application/json"
-	response, err := utils.APIRequest(http.MethodGet, apiURL, headers, nil)
+	response, err := utils.APIRequestWithContext(ctx, http.MethodGet, apiURL, headers, nil)
 	if err != nil {
 		log.Printf("genre service failed :failed to make API request: %v", err)
 		return "", consts.ErrUtilityServiceConnectionLost
@@ -875,7 +875,7 @@ func GetPaymentGatewayName(ctx context.Context, cache cacheConf.Cache, id string
 
 	headers := make(map[string]interface{})
 	headers["Content-Type"] = "application/json"
-	response, err := utils.APIRequest(http.MethodGet, apiURL, headers, nil)
+	response, err := utils.APIRequestWithContext(ctx, http.MethodGet, apiURL, headers, nil)
 	if err != nil {
 		log.Printf("payment gateway service failed :failed to make API request: %v", err)
 		return "", consts.ErrUtilityServiceConnectionLost
@@ -932,7 +932,7 @@ func GetPaymentGatewayId(ctx context.Context, cache cacheConf.Cache, name string
 
 	headers := make(map[string]interface{})
 	headers["Content-Type"] = "application/json"
-	response, err := utils.APIRequest(http.MethodGet, apiURL, headers, nil)
+	response, err := utils.APIRequestWithContext(ctx, http.MethodGet, apiURL, headers, nil)
 	if err != nil {
 		log.Printf("payment gateway service failed :failed to make API request: %v", err)
 		return "", consts.ErrUtilityServiceConnectionLost
@@ -969,7 +969,7 @@ func GetAristRole(ctx context.Context, id string, apiUrl string) (string, error)
 
 	headers := make(map[string]interface{})
 	headers["Content-Type"] = "application/json"
-	response, err := utils.APIRequest(http.MethodGet, apiURL, headers, nil)
+	response, err := utils.APIRequestWithContext(ctx, http.MethodGet, apiURL, headers, nil)
 	if err != nil {
 		log.Printf("artist role service failed :failed to make API request: %v", err)
 		return "", consts.ErrUtilityServiceConnectionLost
@@ -1019,7 +1019,7 @@ func GetOauthProviderId(ctx context.Context, cache cacheConf.Cache, name string,
 	// Make the API request
 	headers["Content-Type"] = "application/json"
 
-	response, err := utils.APIRequest(http.MethodGet, apiURL, headers, nil)
+	response, err := utils.APIRequestWithContext(ctx, http.MethodGet, apiURL, headers, nil)
 	if err != nil {
 		log.Printf("oauth service failed :failed to make API request: %v", err)
 		return "", consts.ErrOauthServiceConnectionLost
@@ -1060,7 +1060,7 @@ func GetPartnerUserCount(ctx context.Context, partnerId string, apiUrl string) (
 	// Make the API request
 	headers["Content-Type"] = "application/json"
 
-	response, err := utils.APIRequest(http.MethodGet, apiURL, headers, nil)
+	response, err := utils.APIRequestWithContext(ctx, http.MethodGet, apiURL, headers, nil)
 	if err != nil {
 		log.Printf("member service failed :failed to make API request: %v", err)
 		return 0, consts.ErrMemberServiceConnectionLost
@@ -1113,7 +1113,7 @@ func GetStores(ctx context.Context, cache cacheConf.Cache, apiUrl string) ([]ent
 	// retieve data from url if data is not available in the cache
 	headers["Content-Type"] = "application/json"
 	apiURL := fmt.Sprintf("%s/stores", apiUrl)
-	response, err := utils.APIRequest(http.MethodGet, apiURL, headers, payload)
+	response, err := utils.APIRequestWithContext(ctx, http.MethodGet, apiURL, headers, payload)
 	if err != nil {
 		log.Printf("failed to connect store service :%v", err)
 		return nil, consts.ErrStoreServiceConnectionLost
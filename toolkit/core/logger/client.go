@@ -1,20 +1,13 @@
 package logger
 
 import (
-	"slices"
-
 	"github.com/sirupsen/logrus"
 )
 
 type (
 	clientOptions struct {
-		service      string
-		logPath      string
-		logfileName  string
-		logMaxSize   int64
-		logMaxBackup int
-		logMaxAge    int
-		logLevel     logrus.Level
+		service  string
+		logLevel logrus.Level
 
 		//include request dump data
 		includeRequestDump bool
@@ -22,27 +15,19 @@ type (
 		//include response dump
 		includeResponseDump bool
 
-		//record the logs in external service
-		recordOptions *recordOptions
-
-		//set the log mode to save the logs in a file or console
-		//by default it will set to save logs in console
-		logMode []logMode
+		//formatter used by the sinks without their own formatter
+		formatter logrus.Formatter
 	}
 
 	recordOptions struct {
 		url    string
 		secret string
 		token  string
+		// degraded is set when the log service was not reachable at start
+		degraded bool
 	}
 )
 
-func (clientOpt *clientOptions) appendLogModes(mode logMode) {
-	if !slices.Contains(clientOpt.logMode, mode) {
-		clientOpt.logMode = append(clientOpt.logMode, mode)
-	}
-}
-
 // set request dump in log request
 func (clientOpt *clientOptions) setRequestData(includeDump bool) {
 	clientOpt.includeRequestDump = includeDump
@@ -54,16 +39,35 @@ func (clientOpt *clientOptions) setResponseData(includeResponseDump bool) {
 }
 
 // log level - possible values trace, debug, info, warn, error, fatal, panic
-func (clientOpt *clientOptions) setLogLevel(logLevel string) {
-	clientOpt.logLevel, _ = logrus.ParseLevel(logLevel)
-}
-func (clientOpt *clientOptions) setRequestDumpData() {
-	includeRequestDumpData = clientOpt.includeRequestDump
+// an empty level defaults to panic
+func (clientOpt *clientOptions) setLogLevel(logLevel string) error {
+	if logLevel == "" {
+		clientOpt.logLevel = logrus.PanicLevel
+		return nil
+	}
+	level, err := logrus.ParseLevel(logLevel)
+	if err != nil {
+		return err
+	}
+	clientOpt.logLevel = level
+	return nil
 }
 
-func (clientOpt *clientOptions) setServiceName() {
-	service = clientOpt.service
-}
-func (clientOpt *clientOptions) setResponseDumpData() {
-	includeResponseDumpData = clientOpt.includeResponseDump
+// text formatter by default, json formatter when enabled, the encoding wins
+// over both when it is set
+func (clientOpt *clientOptions) setFormatter(encoding Encoding, jsonFormat bool) error {
+	if encoding != "" {
+		formatter, err := NewFormatter(encoding, clientOpt.service)
+		if err != nil {
+			return err
+		}
+		clientOpt.formatter = formatter
+		return nil
+	}
+	if jsonFormat {
+		clientOpt.formatter = &logrus.JSONFormatter{}
+		return nil
+	}
+	clientOpt.formatter = &logrus.TextFormatter{}
+	return nil
 }
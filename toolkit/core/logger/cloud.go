@@ -0,0 +1,429 @@
+package logger
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gitlab.com/tuneverse/toolkit/core/queue"
+	"gitlab.com/tuneverse/toolkit/utils"
+)
+
+// DropPolicy decides which record is discarded when the cloud buffer is full.
+type DropPolicy int
+
+const (
+	// DropNewest discards the incoming record and keeps the buffered ones.
+	DropNewest DropPolicy = iota
+	// DropOldest evicts the oldest buffered record to make room for the new one.
+	DropOldest
+)
+
+// shipper buffers log records in memory and sends them to the log service in
+// batches from a background goroutine, so callers never wait on the network.
+// Batches that cannot be delivered go to the disk spool and are replayed once
+// the log service is healthy again.
+type shipper struct {
+	url            string
+	healthURL      string
+	token          string
+	healthInterval time.Duration
+	batchSize      int
+	flushInterval  time.Duration
+	maxRetries     int
+	retryBackoff   time.Duration
+	policy         DropPolicy
+
+	buffer  chan map[string]interface{}
+	dropped atomic.Uint64
+
+	// flushes asks the worker to send the buffered records, the channel is
+	// closed once they are delivered or spooled
+	flushes chan chan struct{}
+	// stop is closed by close, the goroutines drain and return
+	stop     chan struct{}
+	stopOnce sync.Once
+	workers  sync.WaitGroup
+
+	// spool keeps the undelivered batches, nil when spooling is disabled
+	spool   *spool
+	healthy atomic.Bool
+
+	// queue carries the batches instead of the HTTP API when set
+	queue queue.Queue
+
+	// errLog reports delivery failures, it must not go through the shipper
+	errLog *logrus.Logger
+}
+
+// newShipper creates a shipper for the given transport and starts its worker
+func newShipper(rc *recordOptions, cloud *CloudMode) *shipper {
+	s := &shipper{
+		url:            fmt.Sprintf("%s/%s", rc.url, "logs"),
+		healthURL:      fmt.Sprintf("%s/%s", rc.url, "health"),
+		token:          rc.token,
+		healthInterval: cloud.HealthInterval,
+		batchSize:      cloud.BatchSize,
+		flushInterval:  cloud.FlushInterval,
+		maxRetries:     cloud.MaxRetries,
+		retryBackoff:   cloud.RetryBackoff,
+		policy:         cloud.DropPolicy,
+		spool:          cloud.spool,
+		queue:          cloud.Queue,
+		errLog:         logrus.New(),
+	}
+	s.healthy.Store(!rc.degraded)
+	bufferSize := cloud.BufferSize
+	if bufferSize < DefaultMinOne {
+		bufferSize = DefaultBufferSize
+	}
+	if s.batchSize < DefaultMinOne {
+		s.batchSize = DefaultBatchSize
+	}
+	if s.flushInterval <= 0 {
+		s.flushInterval = DefaultFlushInterval
+	}
+	if s.maxRetries < 0 {
+		s.maxRetries = 0
+	} else if s.maxRetries == 0 {
+		s.maxRetries = DefaultMaxRetries
+	}
+	if s.retryBackoff <= 0 {
+		s.retryBackoff = DefaultRetryBackoff
+	}
+	if s.healthInterval <= 0 {
+		s.healthInterval = DefaultHealthInterval
+	}
+	s.buffer = make(chan map[string]interface{}, bufferSize)
+	s.flushes = make(chan chan struct{})
+	s.stop = make(chan struct{})
+
+	s.workers.Add(1)
+	go s.run()
+	if s.spool != nil {
+		s.workers.Add(1)
+		go s.watch()
+	}
+	return s
+}
+
+// stopping reports whether close was called
+func (s *shipper) stopping() bool {
+	select {
+	case <-s.stop:
+		return true
+	default:
+		return false
+	}
+}
+
+// flush waits until the records buffered before the call are delivered or
+// spooled
+func (s *shipper) flush(ctx context.Context) error {
+	done := make(chan struct{})
+	select {
+	case s.flushes <- done:
+	case <-s.stop:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("flushing logs failed: %w", ctx.Err())
+	}
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("flushing logs failed: %w", ctx.Err())
+	}
+}
+
+// close sends the buffered records and stops the goroutines. The records
+// which cannot be delivered go to the spool without further retries.
+func (s *shipper) close(ctx context.Context) error {
+	s.stopOnce.Do(func() {
+		close(s.stop)
+	})
+	stopped := make(chan struct{})
+	go func() {
+		s.workers.Wait()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		return fmt.Errorf("closing the log shipper failed: %w", ctx.Err())
+	}
+	if s.spool != nil {
+		return s.spool.close()
+	}
+	return nil
+}
+
+// enqueue adds a record to the buffer without blocking. When the buffer is
+// full the drop policy decides which record is lost.
+func (s *shipper) enqueue(record map[string]interface{}) {
+	if s.stopping() {
+		s.dropped.Add(1)
+		return
+	}
+	select {
+	case s.buffer <- record:
+		return
+	default:
+	}
+
+	if s.policy == DropOldest {
+		select {
+		case <-s.buffer:
+			s.dropped.Add(1)
+		default:
+		}
+		select {
+		case s.buffer <- record:
+			return
+		default:
+		}
+	}
+	s.dropped.Add(1)
+}
+
+// run collects records into batches and sends them when the batch is full or
+// when the flush interval elapses.
+func (s *shipper) run() {
+	defer s.workers.Done()
+	ticker := time.NewTicker(s.flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]map[string]interface{}, 0, s.batchSize)
+	for {
+		select {
+		case record := <-s.buffer:
+			batch = append(batch, record)
+			if len(batch) >= s.batchSize {
+				s.send(batch)
+				batch = make([]map[string]interface{}, 0, s.batchSize)
+			}
+		case <-ticker.C:
+			if len(batch) > 0 {
+				s.send(batch)
+				batch = make([]map[string]interface{}, 0, s.batchSize)
+			}
+		case done := <-s.flushes:
+			batch = s.drain(batch)
+			close(done)
+		case <-s.stop:
+			s.drain(batch)
+			return
+		}
+	}
+}
+
+// drain sends the pending batch and every buffered record, it returns an
+// empty batch
+func (s *shipper) drain(batch []map[string]interface{}) []map[string]interface{} {
+	for {
+		select {
+		case record := <-s.buffer:
+			batch = append(batch, record)
+			if len(batch) < s.batchSize {
+				continue
+			}
+		default:
+		}
+		if len(batch) > 0 {
+			s.send(batch)
+			batch = make([]map[string]interface{}, 0, s.batchSize)
+		}
+		if len(s.buffer) == 0 {
+			return batch
+		}
+	}
+}
+
+// send posts a batch to the log service, retrying with exponential backoff.
+// The batch is spooled when the service is down or keeps failing.
+func (s *shipper) send(batch []map[string]interface{}) {
+	if s.spool != nil && !s.healthy.Load() {
+		s.spoolBatch(batch)
+		return
+	}
+	backoff := s.retryBackoff
+	for attempt := 0; ; attempt++ {
+		retry, err := s.post(batch)
+		if err == nil {
+			return
+		}
+		// retries are skipped on shutdown, the batch is spooled right away
+		if retry && attempt < s.maxRetries && s.wait(backoff) {
+			backoff *= 2
+			if backoff > DefaultMaxBackoff {
+				backoff = DefaultMaxBackoff
+			}
+			continue
+		}
+		if retry && s.spool != nil {
+			s.errLog.Warnf("log service unavailable, spooling %d records, err=%s", len(batch), err.Error())
+			s.healthy.Store(false)
+			s.spoolBatch(batch)
+			return
+		}
+		s.dropped.Add(uint64(len(batch)))
+		s.errLog.Errorf("capturing logs failed, dropping %d records, err=%s", len(batch), err.Error())
+		return
+	}
+}
+
+// wait sleeps for d, it returns false when close is called meanwhile
+func (s *shipper) wait(d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-s.stop:
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// spoolBatch writes a batch to the spool, it is dropped when the spool is full
+func (s *shipper) spoolBatch(batch []map[string]interface{}) {
+	if err := s.spool.append(batch); err != nil {
+		s.dropped.Add(uint64(len(batch)))
+		s.errLog.Errorf("spooling logs failed, dropping %d records, err=%s", len(batch), err.Error())
+	}
+}
+
+// watch checks the health of the log service and replays the spool once it
+// is reachable
+func (s *shipper) watch() {
+	defer s.workers.Done()
+	ticker := time.NewTicker(s.healthInterval)
+	defer ticker.Stop()
+	for {
+		s.recover()
+		select {
+		case <-ticker.C:
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+// recover pings the log service when it is down or when records are
+// spooled, and replays the spool when the ping succeeds
+func (s *shipper) recover() {
+	if s.healthy.Load() && s.spool.empty() {
+		return
+	}
+	// a queue has no health check, the replay tells whether it is back
+	if s.queue == nil {
+		if err := ping(s.healthURL, s.token); err != nil {
+			s.healthy.Store(false)
+			return
+		}
+	}
+	if !s.healthy.Swap(true) {
+		s.errLog.Info("log service is reachable again, replaying spooled logs")
+	}
+	s.replay()
+}
+
+// replay sends the spooled records in batches. It stops at the first
+// transient failure and keeps the remaining records for the next attempt.
+func (s *shipper) replay() {
+	for {
+		path, ok := s.spool.take()
+		if !ok {
+			return
+		}
+		lines, err := readLines(path)
+		if err != nil {
+			s.errLog.Errorf("reading spooled logs failed, err=%s", err.Error())
+			return
+		}
+		for i := 0; i < len(lines); i += s.batchSize {
+			// the remaining records are kept for the next run
+			if s.stopping() {
+				if err := s.spool.done(path, lines[i:]); err != nil {
+					s.errLog.Errorf("updating the log spool failed, err=%s", err.Error())
+				}
+				return
+			}
+			end := i + s.batchSize
+			if end > len(lines) {
+				end = len(lines)
+			}
+			batch := make([]json.RawMessage, 0, end-i)
+			for _, line := range lines[i:end] {
+				batch = append(batch, line)
+			}
+			retry, err := s.post(batch)
+			if err == nil {
+				continue
+			}
+			if retry {
+				s.healthy.Store(false)
+				if err := s.spool.done(path, lines[i:]); err != nil {
+					s.errLog.Errorf("updating the log spool failed, err=%s", err.Error())
+				}
+				return
+			}
+			s.dropped.Add(uint64(len(batch)))
+			s.errLog.Errorf("replaying logs failed, dropping %d records, err=%s", len(batch), err.Error())
+		}
+		if err := s.spool.done(path, nil); err != nil {
+			s.errLog.Errorf("updating the log spool failed, err=%s", err.Error())
+			return
+		}
+	}
+}
+
+// post sends one batch and reports whether a failure is worth retrying
+func (s *shipper) post(batch interface{}) (bool, error) {
+	if s.queue != nil {
+		return s.publish(batch)
+	}
+	headers := map[string]interface{}{
+		"Authorization": s.token,
+	}
+	resp, err := utils.APIRequest(http.MethodPost, s.url, headers, map[string]interface{}{
+		"logs": batch,
+	})
+	if err != nil {
+		return true, fmt.Errorf("api call failed: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode == http.StatusCreated || resp.StatusCode == http.StatusOK {
+		return false, nil
+	}
+	retry := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError
+	return retry, fmt.Errorf("api responsecode=%v", resp.StatusCode)
+}
+
+// publish sends one batch as a queue message. A failed send is worth
+// retrying, a batch which can not be encoded is not.
+func (s *shipper) publish(batch interface{}) (bool, error) {
+	body, err := json.Marshal(map[string]interface{}{
+		"logs": batch,
+	})
+	if err != nil {
+		return false, fmt.Errorf("encoding logs failed: %w", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultQueueTimeout)
+	defer cancel()
+	message, err := s.queue.ComposeMessage(ctx, body)
+	if err != nil {
+		return false, fmt.Errorf("composing the queue message failed: %w", err)
+	}
+	if err := s.queue.Send(ctx, message); err != nil {
+		return true, fmt.Errorf("queue send failed: %w", err)
+	}
+	return false, nil
+}
@@ -0,0 +1,392 @@
+package logger
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+// logServer is a fake log service that records every batch it receives
+type logServer struct {
+	mu      sync.Mutex
+	batches [][]map[string]interface{}
+	status  atomic.Int32
+	calls   atomic.Int32
+	block   chan struct{}
+}
+
+func newLogServer(t *testing.T) (*logServer, *httptest.Server) {
+	ls := &logServer{}
+	ls.status.Store(http.StatusCreated)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/health" {
+			if int(ls.status.Load()) == http.StatusCreated {
+				w.WriteHeader(http.StatusOK)
+				return
+			}
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		ls.calls.Add(1)
+		if ls.block != nil {
+			<-ls.block
+		}
+		var body struct {
+			Logs []map[string]interface{} `json:"logs"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		status := int(ls.status.Load())
+		if status == http.StatusCreated {
+			ls.mu.Lock()
+			ls.batches = append(ls.batches, body.Logs)
+			ls.mu.Unlock()
+		}
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(srv.Close)
+	return ls, srv
+}
+
+func (ls *logServer) received() int {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	count := 0
+	for _, batch := range ls.batches {
+		count += len(batch)
+	}
+	return count
+}
+
+// fakeQueue records the messages sent, Send fails while down is set
+type fakeQueue struct {
+	mu       sync.Mutex
+	messages [][]byte
+	down     atomic.Bool
+}
+
+func (q *fakeQueue) ComposeMessage(ctx context.Context, message []byte) (interface{}, error) {
+	return message, nil
+}
+
+func (q *fakeQueue) Send(ctx context.Context, message interface{}) error {
+	if q.down.Load() {
+		return errors.New("broker unavailable")
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.messages = append(q.messages, message.([]byte))
+	return nil
+}
+
+func (q *fakeQueue) Receive(ctx context.Context) (interface{}, error) { return nil, nil }
+
+func (q *fakeQueue) Delete(ctx context.Context, receiptHandle string) error { return nil }
+
+func (q *fakeQueue) Close() error { return nil }
+
+func (q *fakeQueue) received() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	count := 0
+	for _, message := range q.messages {
+		var body struct {
+			Logs []map[string]interface{} `json:"logs"`
+		}
+		_ = json.Unmarshal(message, &body)
+		count += len(body.Logs)
+	}
+	return count
+}
+
+func TestShipper(t *testing.T) {
+	t.Run("flush on batch size", func(t *testing.T) {
+		ls, srv := newLogServer(t)
+		s := newShipper(&recordOptions{url: srv.URL, token: "token"}, &CloudMode{
+			BatchSize:     5,
+			FlushInterval: time.Hour,
+		})
+		for i := 0; i < 10; i++ {
+			s.enqueue(map[string]interface{}{"message": i})
+		}
+		require.Eventually(t, func() bool { return ls.received() == 10 }, 2*time.Second, 10*time.Millisecond)
+		ls.mu.Lock()
+		defer ls.mu.Unlock()
+		require.Len(t, ls.batches, 2)
+	})
+
+	t.Run("flush on interval", func(t *testing.T) {
+		ls, srv := newLogServer(t)
+		s := newShipper(&recordOptions{url: srv.URL, token: "token"}, &CloudMode{
+			BatchSize:     100,
+			FlushInterval: 20 * time.Millisecond,
+		})
+		s.enqueue(map[string]interface{}{"message": "one"})
+		require.Eventually(t, func() bool { return ls.received() == 1 }, 2*time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("retry with backoff", func(t *testing.T) {
+		ls, srv := newLogServer(t)
+		ls.status.Store(http.StatusServiceUnavailable)
+		s := newShipper(&recordOptions{url: srv.URL, token: "token"}, &CloudMode{
+			BatchSize:    1,
+			MaxRetries:   5,
+			RetryBackoff: 10 * time.Millisecond,
+		})
+		s.enqueue(map[string]interface{}{"message": "retry"})
+		require.Eventually(t, func() bool { return ls.calls.Load() >= 2 }, 2*time.Second, 5*time.Millisecond)
+		ls.status.Store(http.StatusCreated)
+		require.Eventually(t, func() bool { return ls.received() == 1 }, 2*time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("drop when buffer is full", func(t *testing.T) {
+		ls, srv := newLogServer(t)
+		ls.block = make(chan struct{})
+		defer close(ls.block)
+
+		cloud := &CloudMode{
+			BufferSize:    2,
+			BatchSize:     1,
+			FlushInterval: time.Hour,
+		}
+		cloud.shipper = newShipper(&recordOptions{url: srv.URL, token: "token"}, cloud)
+
+		start := time.Now()
+		for i := 0; i < 20; i++ {
+			cloud.shipper.enqueue(map[string]interface{}{"message": i})
+		}
+		require.Less(t, time.Since(start), time.Second)
+		require.Eventually(t, func() bool { return cloud.Dropped() >= 17 }, 2*time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("drop oldest keeps the newest record", func(t *testing.T) {
+		s := &shipper{
+			policy: DropOldest,
+			buffer: make(chan map[string]interface{}, 2),
+		}
+		for i := 0; i < 3; i++ {
+			s.enqueue(map[string]interface{}{"message": i})
+		}
+		require.Equal(t, uint64(1), s.dropped.Load())
+		require.Equal(t, 1, (<-s.buffer)["message"])
+		require.Equal(t, 2, (<-s.buffer)["message"])
+	})
+
+	t.Run("spool while the service is down and replay", func(t *testing.T) {
+		ls, srv := newLogServer(t)
+		ls.status.Store(http.StatusServiceUnavailable)
+		cloud := &CloudMode{
+			BatchSize:      2,
+			FlushInterval:  10 * time.Millisecond,
+			MaxRetries:     -1,
+			HealthInterval: 20 * time.Millisecond,
+		}
+		var err error
+		cloud.spool, err = openSpool(t.TempDir(), DefaultSpoolMaxSize)
+		require.NoError(t, err)
+		s := newShipper(&recordOptions{url: srv.URL, token: "token"}, cloud)
+
+		for i := 0; i < 5; i++ {
+			s.enqueue(map[string]interface{}{"message": i})
+		}
+		require.Eventually(t, func() bool { return !s.spool.empty() && !s.healthy.Load() }, 2*time.Second, 10*time.Millisecond)
+		require.Equal(t, 0, ls.received())
+
+		ls.status.Store(http.StatusCreated)
+		require.Eventually(t, func() bool { return ls.received() == 5 }, 2*time.Second, 10*time.Millisecond)
+		require.Eventually(t, s.spool.empty, 2*time.Second, 10*time.Millisecond)
+		require.Zero(t, s.dropped.Load())
+	})
+
+	t.Run("spool survives a restart", func(t *testing.T) {
+		dir := t.TempDir()
+		previous, err := openSpool(dir, DefaultSpoolMaxSize)
+		require.NoError(t, err)
+		require.NoError(t, previous.append([]map[string]interface{}{{"message": "one"}, {"message": "two"}}))
+		// a record cut by a crash is skipped
+		_, err = previous.file.WriteString(`{"message":"thr`)
+		require.NoError(t, err)
+
+		ls, srv := newLogServer(t)
+		cloud := &CloudMode{HealthInterval: time.Hour}
+		cloud.spool, err = openSpool(dir, DefaultSpoolMaxSize)
+		require.NoError(t, err)
+		newShipper(&recordOptions{url: srv.URL, token: "token"}, cloud)
+
+		require.Eventually(t, func() bool { return ls.received() == 2 }, 2*time.Second, 10*time.Millisecond)
+		require.Eventually(t, cloud.spool.empty, 2*time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("spool size cap", func(t *testing.T) {
+		sp, err := openSpool(t.TempDir(), 64)
+		require.NoError(t, err)
+		require.NoError(t, sp.append([]map[string]interface{}{{"message": "fits"}}))
+		require.ErrorIs(t, sp.append([]map[string]interface{}{{"message": strings.Repeat("x", 64)}}), errSpoolFull)
+	})
+
+	t.Run("degraded start", func(t *testing.T) {
+		ls, srv := newLogServer(t)
+		ls.status.Store(http.StatusServiceUnavailable)
+		cloud := &CloudMode{
+			URL:            srv.URL,
+			Secret:         "secret",
+			SpoolDir:       t.TempDir(),
+			FlushInterval:  10 * time.Millisecond,
+			HealthInterval: 20 * time.Millisecond,
+		}
+		require.NoError(t, cloud.Init(SinkConfig{Service: "service", Formatter: &logrus.JSONFormatter{}}))
+		require.False(t, cloud.shipper.healthy.Load())
+
+		require.NoError(t, cloud.Write(&logrus.Entry{Message: "degraded", Data: logrus.Fields{}}))
+		require.Eventually(t, func() bool { return !cloud.spool.empty() }, 2*time.Second, 10*time.Millisecond)
+		require.Zero(t, ls.calls.Load())
+
+		ls.status.Store(http.StatusCreated)
+		require.Eventually(t, func() bool { return ls.received() == 1 }, 2*time.Second, 10*time.Millisecond)
+	})
+	t.Run("flush sends the partial batch", func(t *testing.T) {
+		ls, srv := newLogServer(t)
+		s := newShipper(&recordOptions{url: srv.URL, token: "token"}, &CloudMode{
+			BatchSize:     100,
+			FlushInterval: time.Hour,
+		})
+		for i := 0; i < 3; i++ {
+			s.enqueue(map[string]interface{}{"message": i})
+		}
+		require.NoError(t, s.flush(context.Background()))
+		require.Equal(t, 3, ls.received())
+	})
+
+	t.Run("flush gives up at the deadline", func(t *testing.T) {
+		ls, srv := newLogServer(t)
+		ls.block = make(chan struct{})
+		defer close(ls.block)
+		s := newShipper(&recordOptions{url: srv.URL, token: "token"}, &CloudMode{
+			BatchSize:     100,
+			FlushInterval: time.Hour,
+		})
+		s.enqueue(map[string]interface{}{"message": "blocked"})
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		require.ErrorIs(t, s.flush(ctx), context.DeadlineExceeded)
+	})
+
+	t.Run("close spools the failed batches without retries", func(t *testing.T) {
+		ls, srv := newLogServer(t)
+		cloud := &CloudMode{
+			BatchSize:      100,
+			FlushInterval:  time.Hour,
+			MaxRetries:     100,
+			RetryBackoff:   time.Hour,
+			HealthInterval: time.Hour,
+		}
+		var err error
+		cloud.spool, err = openSpool(t.TempDir(), DefaultSpoolMaxSize)
+		require.NoError(t, err)
+		s := newShipper(&recordOptions{url: srv.URL, token: "token"}, cloud)
+		ls.status.Store(http.StatusServiceUnavailable)
+		for i := 0; i < 3; i++ {
+			s.enqueue(map[string]interface{}{"message": i})
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		require.NoError(t, s.close(ctx))
+		require.False(t, s.spool.empty())
+		require.Nil(t, s.spool.file)
+
+		s.enqueue(map[string]interface{}{"message": "late"})
+		require.Equal(t, uint64(1), s.dropped.Load())
+	})
+	t.Run("close interrupts the backoff", func(t *testing.T) {
+		ls, srv := newLogServer(t)
+		cloud := &CloudMode{
+			BatchSize:      1,
+			FlushInterval:  time.Hour,
+			MaxRetries:     100,
+			RetryBackoff:   time.Hour,
+			HealthInterval: time.Hour,
+		}
+		var err error
+		cloud.spool, err = openSpool(t.TempDir(), DefaultSpoolMaxSize)
+		require.NoError(t, err)
+		s := newShipper(&recordOptions{url: srv.URL, token: "token"}, cloud)
+		ls.status.Store(http.StatusServiceUnavailable)
+		s.enqueue(map[string]interface{}{"message": "backing off"})
+		require.Eventually(t, func() bool { return ls.calls.Load() == 1 }, 2*time.Second, 5*time.Millisecond)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		require.NoError(t, s.close(ctx))
+		require.Equal(t, int32(1), ls.calls.Load())
+		require.False(t, s.spool.empty())
+	})
+
+	t.Run("queue transport", func(t *testing.T) {
+		q := &fakeQueue{}
+		q.down.Store(true)
+		cloud := &CloudMode{
+			BatchSize:      2,
+			FlushInterval:  10 * time.Millisecond,
+			MaxRetries:     -1,
+			HealthInterval: 20 * time.Millisecond,
+			SpoolDir:       t.TempDir(),
+			Queue:          q,
+		}
+		require.NoError(t, cloud.Init(SinkConfig{Service: "partner", Formatter: &logrus.JSONFormatter{}}))
+		defer cloud.Close(context.Background())
+
+		for i := 0; i < 3; i++ {
+			require.NoError(t, cloud.Write(&logrus.Entry{Message: "queued", Data: logrus.Fields{}}))
+		}
+		// the batches failing to send are spooled and replayed once the
+		// broker is back
+		require.Eventually(t, func() bool { return !cloud.spool.empty() }, 2*time.Second, 10*time.Millisecond)
+		q.down.Store(false)
+		require.Eventually(t, func() bool { return q.received() == 3 }, 2*time.Second, 10*time.Millisecond)
+
+		q.mu.Lock()
+		var body struct {
+			Logs []map[string]interface{} `json:"logs"`
+		}
+		require.NoError(t, json.Unmarshal(q.messages[0], &body))
+		q.mu.Unlock()
+		require.Equal(t, "partner", body.Logs[0]["service"])
+		require.Zero(t, cloud.Dropped())
+	})
+
+	t.Run("fatal delivers the buffered records before exiting", func(t *testing.T) {
+		var code atomic.Int32
+		code.Store(-1)
+		exit = func(c int) { code.Store(int32(c)) }
+		t.Cleanup(func() { exit = os.Exit })
+
+		q := &fakeQueue{}
+		cloud := &CloudMode{
+			BatchSize:      100,
+			FlushInterval:  time.Hour,
+			HealthInterval: time.Hour,
+			SpoolDir:       t.TempDir(),
+			Queue:          q,
+		}
+		log, err := New(&ClientOptions{Service: "partner", LogLevel: "info"}, &ConsoleMode{Output: io.Discard}, cloud)
+		require.NoError(t, err)
+
+		log.Info("queued")
+		log.Fatal("unable to start")
+		require.Equal(t, int32(1), code.Load())
+		require.Equal(t, 2, q.received())
+	})
+}
@@ -0,0 +1,273 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/sirupsen/logrus"
+	"gitlab.com/tuneverse/toolkit/consts"
+)
+
+// Encoding selects the output format of a sink
+type Encoding string
+
+const (
+	// EncodingText is the logrus text format
+	EncodingText Encoding = "text"
+	// EncodingJSON is the logrus JSON format
+	EncodingJSON Encoding = "json"
+	// EncodingLogfmt renders key=value pairs, see https://brandur.org/logfmt
+	EncodingLogfmt Encoding = "logfmt"
+	// EncodingECS renders Elastic Common Schema JSON documents
+	EncodingECS Encoding = "ecs"
+	// EncodingGELF renders Graylog Extended Log Format 1.1 messages
+	EncodingGELF Encoding = "gelf"
+)
+
+// ECSVersion is the version of the Elastic Common Schema produced
+const ECSVersion = "8.11.0"
+
+// NewFormatter returns the formatter of an encoding. The service is written
+// by the ECS and GELF encodings.
+func NewFormatter(encoding Encoding, service string) (logrus.Formatter, error) {
+	switch encoding {
+	case EncodingText:
+		return &logrus.TextFormatter{}, nil
+	case EncodingJSON:
+		return &logrus.JSONFormatter{}, nil
+	case EncodingLogfmt:
+		return &LogfmtFormatter{}, nil
+	case EncodingECS:
+		return &ECSFormatter{Service: service}, nil
+	case EncodingGELF:
+		return &GELFFormatter{Service: service}, nil
+	default:
+		return nil, fmt.Errorf("unknown log encoding %q", encoding)
+	}
+}
+
+// LogfmtFormatter renders an entry as a line of key=value pairs: time, level
+// and msg first, then the fields sorted by key. Values holding spaces, quotes
+// or "=" are quoted, maps and structures are rendered as JSON.
+type LogfmtFormatter struct{}
+
+// Format renders the entry
+func (f *LogfmtFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	var b bytes.Buffer
+	writePair(&b, "time", entry.Time.UTC().Format(time.RFC3339Nano))
+	writePair(&b, "level", entry.Level.String())
+	writePair(&b, "msg", entry.Message)
+	for _, key := range sortedKeys(entry.Data) {
+		if key == consts.ContextMessage {
+			continue
+		}
+		writePair(&b, key, fieldText(entry.Data[key]))
+	}
+	b.WriteByte('\n')
+	return b.Bytes(), nil
+}
+
+// writePair appends a logfmt pair
+func writePair(b *bytes.Buffer, key, value string) {
+	if b.Len() > 0 {
+		b.WriteByte(' ')
+	}
+	b.WriteString(key)
+	b.WriteByte('=')
+	if needsQuote(value) {
+		b.WriteString(strconv.Quote(value))
+		return
+	}
+	b.WriteString(value)
+}
+
+// needsQuote reports whether a logfmt value has to be quoted
+func needsQuote(value string) bool {
+	if value == "" {
+		return true
+	}
+	for _, r := range value {
+		if r == ' ' || r == '=' || r == '"' || r == '\\' || !unicode.IsPrint(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// ECSFormatter renders an entry as an Elastic Common Schema document. The
+// toolkit fields are mapped to their ECS names (trace_id to trace.id, method
+// to http.request.method, ...), the other fields are kept as they are.
+type ECSFormatter struct {
+	// Service is written as service.name when the entry has no service field
+	Service string
+}
+
+// ecsFields maps the toolkit fields to ECS field names
+var ecsFields = map[string]string{
+	consts.ContextService:       "service.name",
+	consts.ContextTraceID:       "trace.id",
+	consts.ContextSpanID:        "span.id",
+	consts.ContextParentSpanID:  "parent.id",
+	consts.ContextRequestID:     "http.request.id",
+	consts.ContextRequestMethod: "http.request.method",
+	consts.ContextRequestStatus: "http.response.status_code",
+	consts.ContextRequestURI:    "url.full",
+	consts.ContextRequestIP:     "client.ip",
+	"func":                      "log.origin.function",
+	"file":                      "log.origin.file.name",
+	"line":                      "log.origin.file.line",
+	"error":                     "error.message",
+	"error_type":                "error.type",
+}
+
+// Format renders the entry
+func (f *ECSFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	doc := make(map[string]interface{}, len(entry.Data)+5)
+	for key, value := range entry.Data {
+		switch key {
+		case consts.ContextMessage:
+			continue
+		case "error_stack":
+			if stack, ok := value.([]string); ok {
+				value = strings.Join(stack, "\n")
+			}
+			doc["error.stack_trace"] = value
+			continue
+		}
+		if name, ok := ecsFields[key]; ok {
+			key = name
+		}
+		doc[key] = jsonValue(value)
+	}
+	if _, ok := doc["service.name"]; !ok && f.Service != "" {
+		doc["service.name"] = f.Service
+	}
+	doc["@timestamp"] = entry.Time.UTC().Format(time.RFC3339Nano)
+	doc["log.level"] = entry.Level.String()
+	doc["message"] = entry.Message
+	doc["ecs.version"] = ECSVersion
+	return marshalLine(doc)
+}
+
+// GELFFormatter renders an entry as a GELF 1.1 message, one per line. The
+// fields are sent as additional fields, prefixed with "_".
+type GELFFormatter struct {
+	// Service is sent as _service when the entry has no service field
+	Service string
+	// Host is the source of the messages, it defaults to the host name
+	Host string
+}
+
+// hostname is the default source of the GELF messages
+var hostname, _ = os.Hostname()
+
+// gelfKey matches the characters allowed in a GELF field name
+var gelfKey = regexp.MustCompile(`[^\w.\-]`)
+
+// Format renders the entry
+func (f *GELFFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	host := f.Host
+	if host == "" {
+		host = hostname
+	}
+	msg := map[string]interface{}{
+		"version":       "1.1",
+		"host":          host,
+		"short_message": entry.Message,
+		"timestamp":     float64(entry.Time.UnixNano()) / float64(time.Second),
+		"level":         syslogLevel(entry.Level),
+	}
+	for key, value := range entry.Data {
+		if key == consts.ContextMessage {
+			continue
+		}
+		key = "_" + gelfKey.ReplaceAllString(key, "_")
+		// _id is reserved by GELF
+		if key == "_id" {
+			key = "__id"
+		}
+		switch v := value.(type) {
+		case string, bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+			msg[key] = v
+		default:
+			msg[key] = fieldText(v)
+		}
+	}
+	if _, ok := msg["_"+consts.ContextService]; !ok && f.Service != "" {
+		msg["_"+consts.ContextService] = f.Service
+	}
+	return marshalLine(msg)
+}
+
+// syslogLevel maps a logrus level to a syslog severity
+func syslogLevel(level logrus.Level) int {
+	switch level {
+	case logrus.PanicLevel:
+		return 1
+	case logrus.FatalLevel:
+		return 2
+	case logrus.ErrorLevel:
+		return 3
+	case logrus.WarnLevel:
+		return 4
+	case logrus.InfoLevel:
+		return 6
+	default:
+		return 7
+	}
+}
+
+// fieldText renders a field value as a string
+func fieldText(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case error:
+		return v.Error()
+	case fmt.Stringer:
+		return v.String()
+	case bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return fmt.Sprint(v)
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
+
+// jsonValue replaces errors by their message, encoding/json renders them as
+// empty objects
+func jsonValue(value interface{}) interface{} {
+	if err, ok := value.(error); ok {
+		return err.Error()
+	}
+	return value
+}
+
+// marshalLine encodes a document followed by a newline
+func marshalLine(doc map[string]interface{}) ([]byte, error) {
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal fields to JSON, %w", err)
+	}
+	return append(data, '\n'), nil
+}
+
+// sortedKeys returns the keys of the fields in order
+func sortedKeys(fields logrus.Fields) []string {
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,107 @@
+package logger_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+	"gitlab.com/tuneverse/toolkit/consts"
+	"gitlab.com/tuneverse/toolkit/core/logger"
+)
+
+// encodingEntry is the entry rendered by every encoding test
+func encodingEntry() *logrus.Entry {
+	return &logrus.Entry{
+		Time:    time.Date(2024, 5, 1, 10, 30, 0, 0, time.UTC),
+		Level:   logrus.WarnLevel,
+		Message: "partner update failed",
+		Data: logrus.Fields{
+			consts.ContextMessage:       "partner update failed",
+			consts.ContextTraceID:       "4bf92f3577b34da6a3ce929d0e0e4736",
+			consts.ContextRequestMethod: "PATCH",
+			consts.ContextRequestStatus: 500,
+			"partner_id":                "p 1",
+			"error":                     errors.New("timeout"),
+			"id":                        7,
+			"tags":                      []string{"a", "b"},
+		},
+	}
+}
+
+func TestEncodings(t *testing.T) {
+	t.Run("logfmt", func(t *testing.T) {
+		data, err := (&logger.LogfmtFormatter{}).Format(encodingEntry())
+		require.NoError(t, err)
+		require.Equal(t, `time=2024-05-01T10:30:00Z level=warning msg="partner update failed" `+
+			`error=timeout id=7 method=PATCH partner_id="p 1" response_code=500 tags="[\"a\",\"b\"]" `+
+			`trace_id=4bf92f3577b34da6a3ce929d0e0e4736`+"\n", string(data))
+	})
+
+	t.Run("ecs", func(t *testing.T) {
+		data, err := (&logger.ECSFormatter{Service: "partner"}).Format(encodingEntry())
+		require.NoError(t, err)
+		var doc map[string]interface{}
+		require.NoError(t, json.Unmarshal(data, &doc))
+		require.Equal(t, "2024-05-01T10:30:00Z", doc["@timestamp"])
+		require.Equal(t, "warning", doc["log.level"])
+		require.Equal(t, "partner update failed", doc["message"])
+		require.Equal(t, "partner", doc["service.name"])
+		require.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", doc["trace.id"])
+		require.Equal(t, "PATCH", doc["http.request.method"])
+		require.EqualValues(t, 500, doc["http.response.status_code"])
+		require.Equal(t, "timeout", doc["error.message"])
+		require.Equal(t, logger.ECSVersion, doc["ecs.version"])
+		require.Equal(t, "p 1", doc["partner_id"])
+		require.NotContains(t, doc, consts.ContextTraceID)
+	})
+
+	t.Run("gelf", func(t *testing.T) {
+		data, err := (&logger.GELFFormatter{Service: "partner", Host: "pod-1"}).Format(encodingEntry())
+		require.NoError(t, err)
+		var msg map[string]interface{}
+		require.NoError(t, json.Unmarshal(data, &msg))
+		require.Equal(t, "1.1", msg["version"])
+		require.Equal(t, "pod-1", msg["host"])
+		require.Equal(t, "partner update failed", msg["short_message"])
+		require.EqualValues(t, 1714559400, msg["timestamp"])
+		require.EqualValues(t, 4, msg["level"])
+		require.Equal(t, "partner", msg["_service"])
+		require.Equal(t, "timeout", msg["_error"])
+		require.Equal(t, `["a","b"]`, msg["_tags"])
+		require.EqualValues(t, 7, msg["__id"])
+		require.NotContains(t, msg, "_id")
+	})
+
+	t.Run("selected per sink", func(t *testing.T) {
+		var ecs, logfmt bytes.Buffer
+		log, err := logger.New(&logger.ClientOptions{
+			Service:  "partner",
+			LogLevel: "info",
+			Encoding: logger.EncodingLogfmt,
+		},
+			&logger.ConsoleMode{Output: &logfmt},
+			&logger.WriterSink{Writer: &ecs, SinkOptions: logger.SinkOptions{Encoding: logger.EncodingECS}},
+		)
+		require.NoError(t, err)
+		log.Info("hello")
+
+		require.True(t, strings.HasPrefix(logfmt.String(), "time="), logfmt.String())
+		var doc map[string]interface{}
+		require.NoError(t, json.Unmarshal(ecs.Bytes(), &doc))
+		require.Equal(t, "partner", doc["service.name"])
+		require.Equal(t, "hello", doc["message"])
+	})
+
+	t.Run("unknown encoding", func(t *testing.T) {
+		_, err := logger.New(&logger.ClientOptions{Service: "partner", Encoding: "xml"})
+		require.Error(t, err)
+		_, err = logger.New(&logger.ClientOptions{Service: "partner"},
+			&logger.ConsoleMode{SinkOptions: logger.SinkOptions{Encoding: "xml"}})
+		require.Error(t, err)
+	})
+}
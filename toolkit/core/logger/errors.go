@@ -0,0 +1,114 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"runtime"
+	"strconv"
+)
+
+// maxStackDepth is the number of frames captured by Wrap
+const maxStackDepth = 32
+
+// stackError annotates an error with a message and, at the first wrap of a
+// chain, the stack of the caller
+type stackError struct {
+	msg   string
+	err   error
+	stack []uintptr
+}
+
+// Error returns the message followed by the wrapped error
+func (e *stackError) Error() string {
+	if e.msg == "" {
+		return e.err.Error()
+	}
+	return e.msg + ": " + e.err.Error()
+}
+
+// Unwrap returns the wrapped error
+func (e *stackError) Unwrap() error {
+	return e.err
+}
+
+// Wrap annotates err with a message. The stack is captured when no error of
+// the chain carries one yet, so it points at the place the error entered the
+// code. Wrap returns nil when err is nil.
+func Wrap(err error, message string) error {
+	if err == nil {
+		return nil
+	}
+	return wrap(err, message)
+}
+
+// Wrapf annotates err with a formatted message, see Wrap
+func Wrapf(err error, format string, args ...interface{}) error {
+	if err == nil {
+		return nil
+	}
+	return wrap(err, fmt.Sprintf(format, args...))
+}
+
+// wrap keeps the caller frame of Wrap and Wrapf at the same depth
+func wrap(err error, message string) error {
+	wrapped := &stackError{msg: message, err: err}
+	var inner *stackError
+	if !errors.As(err, &inner) {
+		pcs := make([]uintptr, maxStackDepth)
+		wrapped.stack = pcs[:runtime.Callers(3, pcs)]
+	}
+	return wrapped
+}
+
+// errorFields describes err as log fields: the message, the type of the root
+// cause, every error of the Unwrap chain and the stack captured by Wrap.
+func errorFields(err error) map[string]interface{} {
+	var (
+		chain []string
+		stack []uintptr
+		root  = err
+	)
+	for current := err; current != nil; current = errors.Unwrap(current) {
+		root = current
+		if wrapped, ok := current.(*stackError); ok {
+			if wrapped.stack != nil {
+				stack = wrapped.stack
+			}
+			if wrapped.msg == "" {
+				continue
+			}
+			chain = append(chain, wrapped.msg)
+			continue
+		}
+		chain = append(chain, typeName(current)+": "+current.Error())
+	}
+
+	fields := map[string]interface{}{
+		"error":       err.Error(),
+		"error_type":  typeName(root),
+		"error_chain": chain,
+	}
+	if stack != nil {
+		fields["error_stack"] = stackFrames(stack)
+	}
+	return fields
+}
+
+// typeName returns the dynamic type of an error, e.g. *pq.Error
+func typeName(err error) string {
+	return reflect.TypeOf(err).String()
+}
+
+// stackFrames renders a stack as "function file:line" lines
+func stackFrames(stack []uintptr) []string {
+	frames := runtime.CallersFrames(stack)
+	lines := make([]string, 0, len(stack))
+	for {
+		frame, more := frames.Next()
+		lines = append(lines, frame.Function+" "+frame.File+":"+strconv.Itoa(frame.Line))
+		if !more {
+			return lines
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package logger_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gitlab.com/tuneverse/toolkit/core/logger"
+)
+
+// notFoundError is the root cause returned by findPartner
+type notFoundError struct {
+	id int
+}
+
+func (e *notFoundError) Error() string {
+	return fmt.Sprintf("partner %d not found", e.id)
+}
+
+// findPartner fails at the bottom of the call chain
+func findPartner() error {
+	return logger.Wrap(&notFoundError{id: 7}, "find partner")
+}
+
+// updatePartner adds context to the error of findPartner
+func updatePartner() error {
+	return logger.Wrapf(fmt.Errorf("update: %w", findPartner()), "update partner %d", 7)
+}
+
+func TestWithError(t *testing.T) {
+	sink := &fieldSink{}
+	logger.InitLogger(&logger.ClientOptions{
+		Service:  "service",
+		LogLevel: "info",
+	}, sink)
+
+	t.Run("error chain and stack", func(t *testing.T) {
+		sink.reset()
+		err := updatePartner()
+		logger.Log().WithError(err).Error("partner update failed")
+
+		entry := sink.all()[0]
+		require.Equal(t, err.Error(), entry["error"])
+		require.Equal(t, "*logger_test.notFoundError", entry["error_type"])
+		require.Equal(t, []string{
+			"update partner 7",
+			"*fmt.wrapError: " + fmt.Errorf("update: %w", findPartner()).Error(),
+			"find partner",
+			"*logger_test.notFoundError: partner 7 not found",
+		}, entry["error_chain"])
+
+		stack := entry["error_stack"].([]string)
+		require.True(t, strings.HasPrefix(stack[0], "gitlab.com/tuneverse/toolkit/core/logger_test.findPartner "), stack[0])
+		var notFound *notFoundError
+		require.True(t, errors.As(err, &notFound))
+	})
+
+	t.Run("plain error", func(t *testing.T) {
+		sink.reset()
+		logger.Log().WithError(errors.New("boom")).Warn("failed")
+
+		entry := sink.all()[0]
+		require.Equal(t, "boom", entry["error"])
+		require.Equal(t, "*errors.errorString", entry["error_type"])
+		require.NotContains(t, entry, "error_stack")
+	})
+
+	t.Run("nil error", func(t *testing.T) {
+		require.Nil(t, logger.Wrap(nil, "message"))
+		require.Nil(t, logger.Wrapf(nil, "message %d", 1))
+		log := logger.Log()
+		require.Same(t, log, log.WithError(nil))
+	})
+
+	t.Run("caller on every level", func(t *testing.T) {
+		sink.reset()
+		logger.Log().Info("info")
+		logger.Log().Warnf("warn %d", 1)
+
+		for _, entry := range sink.all() {
+			require.Equal(t, "gitlab.com/tuneverse/toolkit/core/logger_test.TestWithError.func4", entry["func"])
+			require.Equal(t, "errors_test.go", entry["file"])
+			require.NotZero(t, entry["line"])
+		}
+	})
+}
@@ -0,0 +1,258 @@
+package logger
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gitlab.com/tuneverse/toolkit/consts"
+)
+
+// AtomicLevel is a log level that can be read and changed concurrently
+type AtomicLevel struct {
+	level atomic.Uint32
+}
+
+// NewAtomicLevel returns an AtomicLevel set to the given level
+func NewAtomicLevel(level logrus.Level) *AtomicLevel {
+	l := &AtomicLevel{}
+	l.SetLevel(level)
+	return l
+}
+
+// Level returns the current level
+func (l *AtomicLevel) Level() logrus.Level {
+	return logrus.Level(l.level.Load())
+}
+
+// SetLevel changes the level
+func (l *AtomicLevel) SetLevel(level logrus.Level) {
+	l.level.Store(uint32(level))
+}
+
+// Enabled reports whether an entry of the given level passes
+func (l *AtomicLevel) Enabled(level logrus.Level) bool {
+	return level <= l.Level()
+}
+
+// LevelState is the level configuration of the logger. Packages and routes
+// override the global level, more or less verbose, for the entries logged
+// from a package (matched by import path prefix) or for a route template
+// such as /api/:version/partners/:partner_id.
+type LevelState struct {
+	Level    string            `json:"level"`
+	Packages map[string]string `json:"packages,omitempty"`
+	Routes   map[string]string `json:"routes,omitempty"`
+}
+
+// levelController decides whether an entry is written. It holds the global
+// level and the optional overrides.
+type levelController struct {
+	level *AtomicLevel
+
+	mu          sync.RWMutex
+	packages    map[string]logrus.Level
+	routes      map[string]logrus.Level
+	hasPackages atomic.Bool
+	hasRoutes   atomic.Bool
+
+	// initial is the state the logger was set up with, used by reset
+	initial LevelState
+	revert  *time.Timer
+}
+
+// newLevelController creates a controller with no overrides
+func newLevelController(level logrus.Level) *levelController {
+	lc := &levelController{
+		level:    NewAtomicLevel(level),
+		packages: map[string]logrus.Level{},
+		routes:   map[string]logrus.Level{},
+	}
+	lc.initial = lc.state()
+	return lc
+}
+
+// mayEnable reports whether an entry of the given level can be written,
+// before its fields and caller are known
+func (lc *levelController) mayEnable(level logrus.Level) bool {
+	return lc.level.Enabled(level) || lc.hasRoutes.Load() || lc.hasPackages.Load()
+}
+
+// enabled reports whether an entry of the given level and fields is written.
+// An override for the route or the calling package replaces the global
+// level, so it can make them quieter as well as more verbose; the most
+// verbose one wins when several match.
+func (lc *levelController) enabled(level logrus.Level, fields logrus.Fields, caller func() (runtime.Frame, bool)) bool {
+	if !lc.hasRoutes.Load() && !lc.hasPackages.Load() {
+		return lc.level.Enabled(level)
+	}
+
+	lc.mu.RLock()
+	defer lc.mu.RUnlock()
+	var (
+		matched bool
+		allowed logrus.Level
+	)
+	match := func(override logrus.Level) {
+		if !matched || override > allowed {
+			allowed = override
+		}
+		matched = true
+	}
+	if route, ok := fields[consts.ContextRequestURITemplate].(string); ok {
+		if override, ok := lc.routes[route]; ok {
+			match(override)
+		}
+	}
+	if len(lc.packages) > 0 {
+		frame, _ := caller()
+		pkg := packageName(frame.Function)
+		for prefix, override := range lc.packages {
+			if strings.HasPrefix(pkg, prefix) {
+				match(override)
+			}
+		}
+	}
+	if matched {
+		return level <= allowed
+	}
+	return lc.level.Enabled(level)
+}
+
+// state returns the current configuration
+func (lc *levelController) state() LevelState {
+	lc.mu.RLock()
+	defer lc.mu.RUnlock()
+	state := LevelState{
+		Level:    lc.level.Level().String(),
+		Packages: make(map[string]string, len(lc.packages)),
+		Routes:   make(map[string]string, len(lc.routes)),
+	}
+	for pkg, level := range lc.packages {
+		state.Packages[pkg] = level.String()
+	}
+	for route, level := range lc.routes {
+		state.Routes[route] = level.String()
+	}
+	return state
+}
+
+// apply replaces the configuration. When revertAfter is positive the
+// previous configuration is restored once it elapses.
+func (lc *levelController) apply(state LevelState, revertAfter time.Duration) error {
+	level, err := logrus.ParseLevel(state.Level)
+	if err != nil {
+		return err
+	}
+	packages, err := parseLevels(state.Packages)
+	if err != nil {
+		return err
+	}
+	routes, err := parseLevels(state.Routes)
+	if err != nil {
+		return err
+	}
+
+	previous := lc.state()
+
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+	lc.level.SetLevel(level)
+	lc.packages = packages
+	lc.routes = routes
+	lc.hasPackages.Store(len(packages) > 0)
+	lc.hasRoutes.Store(len(routes) > 0)
+
+	if lc.revert != nil {
+		lc.revert.Stop()
+		lc.revert = nil
+	}
+	if revertAfter > 0 {
+		lc.revert = time.AfterFunc(revertAfter, func() {
+			_ = lc.apply(previous, 0)
+		})
+	}
+	return nil
+}
+
+// reset restores the configuration the logger was set up with
+func (lc *levelController) reset() {
+	_ = lc.apply(lc.initial, 0)
+}
+
+// parseLevels parses the levels of an override map
+func parseLevels(levels map[string]string) (map[string]logrus.Level, error) {
+	parsed := make(map[string]logrus.Level, len(levels))
+	for key, value := range levels {
+		level, err := logrus.ParseLevel(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid level for %s: %w", key, err)
+		}
+		parsed[key] = level
+	}
+	return parsed, nil
+}
+
+// callerFrame returns the first frame outside this package
+func callerFrame() (runtime.Frame, bool) {
+	pcs := make([]uintptr, 16)
+	n := runtime.Callers(3, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		frame, more := frames.Next()
+		if !strings.HasPrefix(frame.Function, loggerPackage+".") {
+			return frame, true
+		}
+		if !more {
+			return runtime.Frame{}, false
+		}
+	}
+}
+
+// packageName extracts the import path from a qualified function name
+func packageName(function string) string {
+	slash := strings.LastIndex(function, "/")
+	dot := strings.Index(function[slash+1:], ".")
+	if dot < 0 {
+		return function
+	}
+	return function[:slash+1+dot]
+}
+
+// GetLevel returns the current level configuration of the default logger
+func GetLevel() LevelState {
+	return Log().GetLevel()
+}
+
+// SetLevel changes the level configuration of the default logger while the
+// service runs. When revertAfter is positive the previous configuration is
+// restored once it elapses.
+func SetLevel(state LevelState, revertAfter time.Duration) error {
+	return Log().SetLevel(state, revertAfter)
+}
+
+// ResetLevel restores the level configuration the default logger was set up
+// with
+func ResetLevel() {
+	Log().ResetLevel()
+}
+
+// GetLevel returns the current level configuration of the logger
+func (log *Logger) GetLevel() LevelState {
+	return log.resolve().levels.state()
+}
+
+// SetLevel changes the level configuration of the logger and of every logger
+// derived from it, see the package level SetLevel
+func (log *Logger) SetLevel(state LevelState, revertAfter time.Duration) error {
+	return log.resolve().levels.apply(state, revertAfter)
+}
+
+// ResetLevel restores the level configuration the logger was set up with
+func (log *Logger) ResetLevel() {
+	log.resolve().levels.reset()
+}
@@ -0,0 +1,142 @@
+package logger
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gitlab.com/tuneverse/toolkit/models/api"
+	"gitlab.com/tuneverse/toolkit/utils"
+)
+
+// LevelRequest is the body accepted by LevelHandler on PUT
+type LevelRequest struct {
+	LevelState
+
+	// RevertAfter restores the previous configuration once it elapses, for
+	// example "15m". The change is permanent when it is empty.
+	RevertAfter string `json:"revert_after,omitempty"`
+}
+
+// LevelHandlerOptions configures LevelHandler
+type LevelHandlerOptions struct {
+	// Logger is the logger whose level is read and changed, it defaults to
+	// the default logger
+	Logger *Logger
+	// Authorize accepts or rejects a request, a rejected request is answered
+	// with 401. The level can not be changed when it is nil, so the handler
+	// is never left open on a public router. See AuthorizeToken.
+	Authorize func(ctx *gin.Context) error
+}
+
+// AuthorizeToken accepts the requests whose Authorization header holds a
+// token signed with secret by utils.GenerateJWTAuthToken, raw or in the
+// "Bearer" form. Every request is rejected when secret is empty.
+func AuthorizeToken(secret string) func(ctx *gin.Context) error {
+	return func(ctx *gin.Context) error {
+		token := strings.TrimSpace(ctx.GetHeader("Authorization"))
+		if len(token) > 7 && strings.EqualFold(token[:7], "Bearer ") {
+			token = strings.TrimSpace(token[7:])
+		}
+		return utils.VerifyJWTAuthToken(secret, token)
+	}
+}
+
+// LevelHandler reads (GET) and changes (PUT) the level configuration of the
+// logger at runtime. Mount it on an admin route with an Authorize hook, for
+// example
+//
+//	levels := logger.LevelHandler(logger.LevelHandlerOptions{Authorize: logger.AuthorizeToken(secret)})
+//	api.GET("/:version/admin/log-level", levels)
+//	api.PUT("/:version/admin/log-level", levels)
+//
+// Every change is logged, whatever the level.
+func LevelHandler(option ...LevelHandlerOptions) gin.HandlerFunc {
+	opt := LevelHandlerOptions{}
+	if len(option) > 0 {
+		opt = option[0]
+	}
+
+	return func(ctx *gin.Context) {
+		log := opt.Logger
+		if log == nil {
+			log = Log()
+		}
+		if opt.Authorize != nil {
+			if err := opt.Authorize(ctx); err != nil {
+				log.WithContext(ctx.Request.Context()).Warnf("log level request from %s rejected, err=%s", ctx.ClientIP(), err.Error())
+				levelDenied(ctx, http.StatusUnauthorized)
+				return
+			}
+		}
+		if ctx.Request.Method == http.MethodGet {
+			ctx.JSON(http.StatusOK, api.Response{
+				Status:  "success",
+				Message: "log level",
+				Code:    http.StatusOK,
+				Data:    log.GetLevel(),
+				Errors:  map[string]string{},
+			})
+			return
+		}
+
+		if opt.Authorize == nil {
+			levelDenied(ctx, http.StatusForbidden)
+			return
+		}
+
+		var req LevelRequest
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			levelError(ctx, err.Error())
+			return
+		}
+		if req.Level == "" {
+			req.Level = log.GetLevel().Level
+		}
+		var revertAfter time.Duration
+		if req.RevertAfter != "" {
+			var err error
+			revertAfter, err = time.ParseDuration(req.RevertAfter)
+			if err != nil {
+				levelError(ctx, "invalid revert_after: "+err.Error())
+				return
+			}
+		}
+		if err := log.SetLevel(req.LevelState, revertAfter); err != nil {
+			levelError(ctx, err.Error())
+			return
+		}
+
+		log.WithContext(ctx.Request.Context()).auditf("log level changed to %s by %s, revert_after=%q", req.Level, ctx.ClientIP(), req.RevertAfter)
+		ctx.JSON(http.StatusOK, api.Response{
+			Status:  "success",
+			Message: "log level updated",
+			Code:    http.StatusOK,
+			Data:    log.GetLevel(),
+			Errors:  map[string]string{},
+		})
+	}
+}
+
+// levelError answers a rejected level change
+func levelError(ctx *gin.Context, message string) {
+	ctx.AbortWithStatusJSON(http.StatusBadRequest, api.Response{
+		Status:  "failure",
+		Message: "validation error",
+		Code:    http.StatusBadRequest,
+		Data:    struct{}{},
+		Errors:  map[string]string{"level": message},
+	})
+}
+
+// levelDenied answers a request which is not authorized
+func levelDenied(ctx *gin.Context, status int) {
+	ctx.AbortWithStatusJSON(status, api.Response{
+		Status:  "failure",
+		Message: http.StatusText(status),
+		Code:    status,
+		Data:    struct{}{},
+		Errors:  map[string]string{},
+	})
+}
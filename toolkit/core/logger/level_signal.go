@@ -0,0 +1,80 @@
+package logger
+
+import (
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// LevelSignalOptions configures HandleLevelSignals
+type LevelSignalOptions struct {
+	// Level is set when the raise signal is received. It defaults to debug.
+	Level string
+
+	// RevertAfter restores the initial configuration once it elapses
+	// after a raise. The raised level stays until the reset signal when it
+	// is zero.
+	RevertAfter time.Duration
+
+	// Logger is the logger whose level is changed, it defaults to the
+	// default logger
+	Logger *Logger
+}
+
+// HandleLevelSignals raises the log level on SIGUSR1 and restores the level
+// the logger was set up with on SIGUSR2. The returned function stops the
+// handling.
+func HandleLevelSignals(option ...LevelSignalOptions) (stop func()) {
+	opt := LevelSignalOptions{}
+	if len(option) > 0 {
+		opt = option[0]
+	}
+	if opt.Level == "" {
+		opt.Level = logrus.DebugLevel.String()
+	}
+	log := opt.Logger
+	if log == nil {
+		log = Log()
+	}
+
+	raise, reset := levelSignals()
+	if raise == nil {
+		return func() {}
+	}
+
+	signals := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	signal.Notify(signals, raise, reset)
+
+	go func() {
+		defer close(stopped)
+		for {
+			select {
+			case sig := <-signals:
+				if sig == raise {
+					state := log.GetLevel()
+					state.Level = opt.Level
+					if err := log.SetLevel(state, opt.RevertAfter); err != nil {
+						log.Errorf("log level signal failed, err=%s", err.Error())
+						continue
+					}
+					log.auditf("log level raised to %s by signal, revert_after=%s", opt.Level, opt.RevertAfter)
+				} else {
+					log.ResetLevel()
+					log.auditf("log level reset to %s by signal", log.GetLevel().Level)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		signal.Stop(signals)
+		close(done)
+		<-stopped
+	}
+}
@@ -0,0 +1,13 @@
+//go:build !windows
+
+package logger
+
+import (
+	"os"
+	"syscall"
+)
+
+// levelSignals returns the raise and reset signals
+func levelSignals() (os.Signal, os.Signal) {
+	return syscall.SIGUSR1, syscall.SIGUSR2
+}
@@ -0,0 +1,10 @@
+//go:build windows
+
+package logger
+
+import "os"
+
+// levelSignals returns no signals, windows has no user defined signals
+func levelSignals() (os.Signal, os.Signal) {
+	return nil, nil
+}
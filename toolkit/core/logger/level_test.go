@@ -0,0 +1,197 @@
+package logger_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"gitlab.com/tuneverse/toolkit/consts"
+	"gitlab.com/tuneverse/toolkit/core/logger"
+	"gitlab.com/tuneverse/toolkit/models/api"
+	"gitlab.com/tuneverse/toolkit/utils"
+)
+
+const levelSecret = "level-secret"
+
+func initLevelLogger(t *testing.T) *memorySink {
+	sink := &memorySink{}
+	logger.InitLogger(&logger.ClientOptions{
+		Service:  "service",
+		LogLevel: "info",
+	}, &logger.ConsoleMode{Output: io.Discard}, sink)
+	return sink
+}
+
+func TestLevel(t *testing.T) {
+	t.Run("invalid level is rejected", func(t *testing.T) {
+		initLevelLogger(t)
+		require.Error(t, logger.SetLevel(logger.LevelState{Level: "verbose"}, 0))
+		require.Equal(t, "info", logger.GetLevel().Level)
+	})
+
+	t.Run("level changes at runtime", func(t *testing.T) {
+		sink := initLevelLogger(t)
+		logger.Log().Debug("hidden")
+		require.NoError(t, logger.SetLevel(logger.LevelState{Level: "debug"}, 0))
+		logger.Log().Debug("visible")
+		logger.ResetLevel()
+		logger.Log().Debug("hidden again")
+
+		require.Equal(t, []string{"visible"}, sink.messages)
+	})
+
+	t.Run("route override", func(t *testing.T) {
+		sink := initLevelLogger(t)
+		require.NoError(t, logger.SetLevel(logger.LevelState{
+			Level:  "info",
+			Routes: map[string]string{"/api/:version/partners": "debug"},
+		}, 0))
+		ctx := context.WithValue(context.Background(), consts.LogData, map[string]interface{}{
+			consts.ContextRequestURITemplate: "/api/:version/partners",
+		})
+
+		logger.Log().WithContext(ctx).Debug("partner debug")
+		logger.Log().Debug("other debug")
+
+		require.Equal(t, []string{"partner debug"}, sink.messages)
+	})
+
+	t.Run("package override", func(t *testing.T) {
+		sink := initLevelLogger(t)
+		require.NoError(t, logger.SetLevel(logger.LevelState{
+			Level:    "info",
+			Packages: map[string]string{"gitlab.com/tuneverse/toolkit/core/logger_test": "debug"},
+		}, 0))
+
+		logger.Log().Debug("package debug")
+
+		require.Equal(t, []string{"package debug"}, sink.messages)
+	})
+
+	t.Run("quieter overrides", func(t *testing.T) {
+		sink := initLevelLogger(t)
+		require.NoError(t, logger.SetLevel(logger.LevelState{
+			Level:    "info",
+			Routes:   map[string]string{"/health": "error"},
+			Packages: map[string]string{"gitlab.com/tuneverse/toolkit/core/logger_test": "warn"},
+		}, 0))
+		ctx := context.WithValue(context.Background(), consts.LogData, map[string]interface{}{
+			consts.ContextRequestURITemplate: "/health",
+		})
+
+		logger.Log().Info("package info")
+		logger.Log().Warn("package warn")
+		// the most verbose matching override wins
+		logger.Log().WithContext(ctx).Warn("health warn")
+		logger.Log().WithContext(ctx).Info("health info")
+
+		require.Equal(t, []string{"package warn", "health warn"}, sink.messages)
+	})
+
+	t.Run("revert after timeout", func(t *testing.T) {
+		initLevelLogger(t)
+		require.NoError(t, logger.SetLevel(logger.LevelState{Level: "trace"}, 20*time.Millisecond))
+		require.Equal(t, "trace", logger.GetLevel().Level)
+		require.Eventually(t, func() bool {
+			return logger.GetLevel().Level == "info"
+		}, time.Second, 5*time.Millisecond)
+	})
+
+	t.Run("signals", func(t *testing.T) {
+		initLevelLogger(t)
+		stop := logger.HandleLevelSignals(logger.LevelSignalOptions{Level: "trace"})
+		defer stop()
+
+		require.NoError(t, syscall.Kill(syscall.Getpid(), syscall.SIGUSR1))
+		require.Eventually(t, func() bool {
+			return logger.GetLevel().Level == "trace"
+		}, time.Second, 5*time.Millisecond)
+
+		require.NoError(t, syscall.Kill(syscall.Getpid(), syscall.SIGUSR2))
+		require.Eventually(t, func() bool {
+			return logger.GetLevel().Level == "info"
+		}, time.Second, 5*time.Millisecond)
+	})
+}
+
+func TestLevelHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	sink := initLevelLogger(t)
+	levels := logger.LevelHandler(logger.LevelHandlerOptions{Authorize: logger.AuthorizeToken(levelSecret)})
+	router := gin.New()
+	router.GET("/admin/log-level", levels)
+	router.PUT("/admin/log-level", levels)
+	router.PUT("/open/log-level", logger.LevelHandler())
+
+	token, err := utils.GenerateJWTAuthToken(levelSecret, map[string]interface{}{})
+	require.NoError(t, err)
+	serve := func(method, path, token, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("get", func(t *testing.T) {
+		w := serve(http.MethodGet, "/admin/log-level", token, "")
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var resp api.Response
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		require.Equal(t, "info", resp.Data.(map[string]interface{})["level"])
+	})
+
+	t.Run("put", func(t *testing.T) {
+		w := serve(http.MethodPut, "/admin/log-level", token, `{"level":"debug","revert_after":"1m"}`)
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, "debug", logger.GetLevel().Level)
+		logger.ResetLevel()
+	})
+
+	t.Run("put invalid level", func(t *testing.T) {
+		w := serve(http.MethodPut, "/admin/log-level", token, `{"level":"loud"}`)
+		require.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("unauthorized", func(t *testing.T) {
+		forged, err := utils.GenerateJWTAuthToken("other-secret", map[string]interface{}{})
+		require.NoError(t, err)
+		for _, token := range []string{"", "not-a-token", forged} {
+			require.Equal(t, http.StatusUnauthorized, serve(http.MethodGet, "/admin/log-level", token, "").Code)
+			require.Equal(t, http.StatusUnauthorized, serve(http.MethodPut, "/admin/log-level", token, `{"level":"trace"}`).Code)
+		}
+		require.Equal(t, "info", logger.GetLevel().Level)
+	})
+
+	t.Run("put without authorize hook", func(t *testing.T) {
+		w := serve(http.MethodPut, "/open/log-level", token, `{"level":"trace"}`)
+		require.Equal(t, http.StatusForbidden, w.Code)
+		require.Equal(t, "info", logger.GetLevel().Level)
+	})
+
+	t.Run("change is logged above warn", func(t *testing.T) {
+		require.NoError(t, logger.SetLevel(logger.LevelState{Level: "error"}, 0))
+		defer logger.ResetLevel()
+		sink.mu.Lock()
+		sink.messages = nil
+		sink.mu.Unlock()
+
+		w := serve(http.MethodPut, "/admin/log-level", token, `{"level":"fatal"}`)
+		require.Equal(t, http.StatusOK, w.Code)
+		sink.mu.Lock()
+		defer sink.mu.Unlock()
+		require.Len(t, sink.messages, 1)
+		require.Contains(t, sink.messages[0], "log level changed to fatal")
+	})
+}
@@ -2,37 +2,48 @@ package logger
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log"
+	"log/slog"
 	"net/http"
 	"os"
-	"reflect"
+	"path/filepath"
 	"runtime"
-	"strings"
-	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
 	"gitlab.com/tuneverse/toolkit/consts"
+	"gitlab.com/tuneverse/toolkit/core/trace"
 	"gitlab.com/tuneverse/toolkit/utils"
-	"gopkg.in/natefinch/lumberjack.v1"
 )
 
-var (
-	recordLog               bool
-	logToken                string
-	logServiceURL           string
-	logger                  *logrus.Logger
-	includeRequestDumpData  bool
-	includeResponseDumpData bool
-	service                 string
-)
+// loggerPackage is the import path of this package, its frames are skipped
+// when looking for the caller
+const loggerPackage = "gitlab.com/tuneverse/toolkit/core/logger"
 
-const (
-	logModeFile logMode = iota
-	logModeCloud
-)
+// fatalCloseTimeout bounds the draining of the sinks before a fatal entry
+// exits the process
+const fatalCloseTimeout = 5 * time.Second
+
+// exit ends the process after a fatal entry, replaced by the tests
+var exit = os.Exit
+
+// defaultCore writes the entries of the default logger. It drops everything
+// until InitLogger is called, so packages can log from unit tests which do
+// not set a logger up.
+var defaultCore atomic.Pointer[core]
+
+func init() {
+	defaultCore.Store(&core{
+		logrus:    newLogrus(nil),
+		levels:    newLevelController(logrus.InfoLevel),
+		redaction: newRedactor(nil),
+	})
+}
 
 type ClientOptions struct {
 	// Service describes the application name to be logged
@@ -50,81 +61,165 @@ type ClientOptions struct {
 
 	//JsonFormater
 	JSONFormater bool
+
+	// Encoding is the output format of the sinks without their own encoding
+	// or formatter: text, json, logfmt, ecs or gelf. It replaces JSONFormater
+	// when set.
+	Encoding Encoding
+
+	// Redact masks secrets and personal data in the request and response
+	// dumps, the messages and the string fields. Nil applies the defaults.
+	Redact *RedactOptions
+
+	// SlogDefault makes InitLogger route the log/slog default logger, and with
+	// it the standard log package, through the default logger
+	SlogDefault bool
+
+	// Sampling bounds the number of identical entries written, nil writes
+	// every entry
+	Sampling *SamplingOptions
 }
 
+// Logger is immutable, With, WithFields and WithContext return a new logger
+// with a copy of the fields, so a logger can be shared between goroutines.
 type Logger struct {
-	ctx        context.Context
-	entry      *logrus.Entry
-	fields     map[string]interface{}
-	jsonFormat bool
-	mu         *sync.RWMutex
+	ctx    context.Context
+	fields map[string]interface{}
+
+	// core is the configuration of the logger returned by New or
+	// InitLogger, nil for the loggers derived from Log
+	core *core
+}
+
+// core is the configuration shared by a logger and every logger derived from
+// it: the service, the dump flags, the levels, the redaction and the sinks.
+type core struct {
+	service             string
+	includeRequestDump  bool
+	includeResponseDump bool
+	levels              *levelController
+	redaction           *redactor
+	logrus              *logrus.Logger
+	sinks               []Sink
+
+	// sampler drops the entries repeated too often, nil without sampling
+	sampler *sampler
+
+	// closed is set by Close, the entries logged afterwards are dropped
+	closed atomic.Bool
 }
 
-var logObject *Logger
+// logObject follows the logger set up by the last InitLogger
+var logObject = &Logger{
+	fields: make(map[string]interface{}),
+}
 
+// Log returns the default logger. It writes through the logger set up by the
+// last InitLogger, or through the logger LogMiddleware was given when it is
+// bound to a request context.
 func Log() *Logger {
 	return logObject
 }
 
-// logger initialization
-func InitLogger(clientOpt *ClientOptions, logType ...loggerImply) *Logger {
+// New returns a self-contained logger, the default logger is not changed.
+// Several loggers with their own service, levels and sinks can run side by
+// side in one process.
+// ClientOptions.LogLevel decides which entries are written and can be changed
+// at runtime, every sink can restrict it further with its own level. A console
+// sink is added when none of the sinks is a ConsoleMode
+func New(clientOpt *ClientOptions, sinks ...Sink) (*Logger, error) {
 	clientOpts := &clientOptions{}
 	if utils.IsEmpty(clientOpt.Service) {
-		log.Fatal("service name is required")
+		return nil, fmt.Errorf("service name is required")
 	}
 
-	for _, logger := range logType {
-		clientOpts = logger.setLogger(clientOpts)
-	}
 	clientOpts.service = clientOpt.Service
 	clientOpts.setRequestData(clientOpt.IncludeRequestDump)
 	clientOpts.setResponseData(clientOpt.IncludeResponseDump)
-	clientOpts.setLogLevel(clientOpt.LogLevel)
-
-	logger = logrus.New()
-	clientOpts.setRequestDumpData()
-	clientOpts.setResponseDumpData()
-	clientOpts.setServiceName()
+	if err := clientOpts.setLogLevel(clientOpt.LogLevel); err != nil {
+		return nil, fmt.Errorf("invalid log level %q: %w", clientOpt.LogLevel, err)
+	}
+	if err := clientOpts.setFormatter(clientOpt.Encoding, clientOpt.JSONFormater); err != nil {
+		return nil, err
+	}
 
-	for _, v := range clientOpts.logMode {
-		err := v.init(clientOpts)
-		if err != nil {
-			log.Fatal("logger initialisation failed %w", err)
+	hasConsole := false
+	for _, sink := range sinks {
+		if _, ok := sink.(*ConsoleMode); ok {
+			hasConsole = true
 		}
 	}
+	if !hasConsole {
+		sinks = append(sinks, &ConsoleMode{})
+	}
 
-	logObject = &Logger{
-		fields:     make(map[string]interface{}),
-		jsonFormat: clientOpt.JSONFormater,
-		mu:         &sync.RWMutex{},
+	c := &core{
+		service:             clientOpts.service,
+		includeRequestDump:  clientOpts.includeRequestDump,
+		includeResponseDump: clientOpts.includeResponseDump,
+		levels:              newLevelController(clientOpts.logLevel),
+		redaction:           newRedactor(clientOpt.Redact),
+	}
+	cfg := SinkConfig{
+		Service:   c.service,
+		Level:     c.levels.level,
+		Formatter: clientOpts.formatter,
+	}
+	for _, sink := range sinks {
+		if err := sink.Init(cfg); err != nil {
+			return nil, fmt.Errorf("logger initialisation failed %w", err)
+		}
+	}
+	c.logrus = newLogrus(sinks)
+	c.sinks = sinks
+	if clientOpt.Sampling != nil {
+		c.sampler = newSampler(clientOpt.Sampling, c.reportSuppressed)
 	}
-	return logObject
-}
 
-func (lm logMode) init(cl *clientOptions) error {
+	return &Logger{
+		fields: make(map[string]interface{}),
+		core:   c,
+	}, nil
+}
 
-	logger.SetLevel(cl.logLevel)
-	logger.SetFormatter(&logrus.TextFormatter{})
+// InitLogger creates a logger with New and makes it the default logger
+// returned by Log. The process exits when the options are invalid.
+func InitLogger(clientOpt *ClientOptions, sinks ...Sink) *Logger {
+	instance, err := New(clientOpt, sinks...)
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+	defaultCore.Store(instance.core)
+	if clientOpt.SlogDefault {
+		slog.SetDefault(slog.New(NewSlogHandler(Log())))
+	}
+	return instance
+}
+
+// SetDefault makes log the default logger returned by Log, like InitLogger
+// does, and returns a function restoring the previous default. restore
+// reports false and leaves the default alone when it was replaced by someone
+// else meanwhile.
+func SetDefault(log *Logger) (restore func() bool) {
+	installed := log.resolve()
+	previous := defaultCore.Swap(installed)
+	return func() bool {
+		return defaultCore.CompareAndSwap(installed, previous)
+	}
+}
 
-	switch lm {
-	case logModeCloud:
-		cl.recordOptions.setLogServiceCredentials()
-	case logModeFile:
-		if _, err := os.Stat(cl.logPath); os.IsNotExist(err) {
-			err := os.MkdirAll(cl.logPath, os.ModePerm)
-			if err != nil {
-				return err
-			}
-		}
-		logger.SetOutput(io.MultiWriter(&lumberjack.Logger{
-			NameFormat: cl.logfileName,
-			Dir:        cl.logPath,
-			MaxSize:    cl.logMaxSize,
-			MaxBackups: cl.logMaxBackup,
-			MaxAge:     cl.logMaxAge,
-		}, os.Stdout))
+// newLogrus returns a logrus logger handing the entries to the sinks. The
+// level controller decides which entries are written, logrus passes
+// everything through. Without sinks every entry is dropped.
+func newLogrus(sinks []Sink) *logrus.Logger {
+	log := logrus.New()
+	log.SetLevel(logrus.TraceLevel)
+	log.SetOutput(io.Discard)
+	log.SetFormatter(discardFormatter{})
+	if len(sinks) > 0 {
+		log.AddHook(&sinkHook{sinks: sinks})
 	}
-	return nil
+	return log
 }
 
 func initTransportOptions(recordLogs bool, url, tokenSecret string) (*recordOptions, error) {
@@ -138,11 +233,6 @@ func initTransportOptions(recordLogs bool, url, tokenSecret string) (*recordOpti
 		if err != nil {
 			return transport, fmt.Errorf("token generation failed, err=%s", err.Error())
 		}
-		//initial ping to the logger service
-		err = ping(fmt.Sprintf("%s/%s", url, "health"), token)
-		if err != nil {
-			return transport, err
-		}
 		transport.url = url
 		transport.secret = tokenSecret
 		transport.token = token
@@ -168,253 +258,413 @@ func ping(url, token string) error {
 	return nil
 }
 
-func (rc *recordOptions) setLogServiceCredentials() {
-	recordLog = true
-	logServiceURL = rc.url
-	logToken = rc.token
-}
-
+// GetRequestDumpStatus reports whether the default logger dumps the requests
 func GetRequestDumpStatus() bool {
-	return includeRequestDumpData
+	return Log().IncludeRequestDump()
 }
 
+// GetResponseDumpStatus reports whether the default logger dumps the
+// responses
 func GetResponseDumpStatus() bool {
-	return includeResponseDumpData
+	return Log().IncludeResponseDump()
 }
 
+// GetService returns the service of the default logger
 func GetService() string {
-	return service
+	return Log().Service()
 }
 
-// Get the context
-func (log *Logger) Context() context.Context {
-	return log.ctx
+// Service returns the service the logger was set up with
+func (log *Logger) Service() string {
+	return log.resolve().service
 }
 
-// With Context
-func (log Logger) WithContext(ctx context.Context) Logger {
-	log.ctx = ctx
-	return log
+// IncludeRequestDump reports whether the request dumps are logged
+func (log *Logger) IncludeRequestDump() bool {
+	return log.resolve().includeRequestDump
 }
 
-// Entry
-func (log Logger) Entry() *logrus.Entry {
-	return log.entry
+// IncludeResponseDump reports whether the response dumps are logged
+func (log *Logger) IncludeResponseDump() bool {
+	return log.resolve().includeResponseDump
 }
 
-// WithEntry
-func (log *Logger) WithEntry(entry *logrus.Entry) *Logger {
-	log.entry = entry
-	return log
+// resolve returns the configuration writing the entries of the logger. The
+// loggers derived from Log use the one of the scoped logger of their context,
+// so handlers write through the logger given to LogMiddleware, and fall back
+// to the one set up by the last InitLogger.
+func (log *Logger) resolve() *core {
+	if log.core != nil {
+		return log.core
+	}
+	if log.ctx != nil {
+		if scoped, ok := contextValue(log.ctx, scopedLoggerKey{}).(*Logger); ok && scoped.core != nil {
+			return scoped.core
+		}
+	}
+	return defaultCore.Load()
+}
+
+// Flush waits until the sinks buffering entries, such as CloudMode, have
+// delivered the entries logged so far. It returns the error of ctx when the
+// deadline passes first.
+func (log *Logger) Flush(ctx context.Context) error {
+	var errs []error
+	for _, sink := range log.resolve().sinks {
+		if flusher, ok := sink.(Flusher); ok {
+			if err := flusher.Flush(ctx); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
 }
 
-// Fields
-func (log *Logger) Fields() map[string]interface{} {
-	return log.fields
+// Close drains and closes every sink within the deadline of ctx. It is meant
+// for the end of a graceful shutdown: the logger and every logger derived
+// from it drop their entries once Close is called.
+func (log *Logger) Close(ctx context.Context) error {
+	return log.resolve().close(ctx)
 }
 
-// logFunc
-func (log Logger) logFunc(fFunc bool, fn string, message string, args ...interface{}) {
-	log.mu.Lock()
-	defer log.mu.Unlock()
-
-	// Get the level from the fn name
-	level, err := logrus.ParseLevel(fn)
-	if err != nil {
-		level = logrus.FatalLevel
+// close drains and closes the sampler and the sinks, once
+func (c *core) close(ctx context.Context) error {
+	if c.closed.Swap(true) {
+		return nil
 	}
+	var errs []error
+	if c.sampler != nil {
+		if err := c.sampler.close(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for _, sink := range c.sinks {
+		var err error
+		switch closable := sink.(type) {
+		case Closer:
+			err = closable.Close(ctx)
+		case Flusher:
+			err = closable.Flush(ctx)
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
 
-	// get the existing fields
-	var fields = log.Fields()
+// scopedLoggerKey is the context key of the request scoped logger
+type scopedLoggerKey struct{}
 
-	fields[consts.ContextMessage] = message
-
-	if fFunc {
-		fields[consts.ContextMessage] = fmt.Sprintf(message, args...)
-	} else {
-		fields["args"] = fmt.Sprint(args...)
+// contextValue looks key up in ctx. A *gin.Context only exposes its own keys,
+// the request context is searched as well so handlers can pass either.
+func contextValue(ctx context.Context, key interface{}) interface{} {
+	if value := ctx.Value(key); value != nil {
+		return value
 	}
+	if gc, ok := ctx.(*gin.Context); ok && gc.Request != nil {
+		return gc.Request.Context().Value(key)
+	}
+	return nil
+}
 
-	obje := log.WithFields(fields).Log(level).Entry()
-
-	callableMethod := reflect.ValueOf(obje).MethodByName(fn)
-	if callableMethod.IsValid() {
-		inputs := make([]reflect.Value, 0)
-		inputs = append(inputs, reflect.ValueOf(message))
+// NewContext returns a copy of ctx carrying the given logger. Loggers created
+// with WithContext on the returned context inherit its fields.
+func NewContext(ctx context.Context, log *Logger) context.Context {
+	return context.WithValue(ctx, scopedLoggerKey{}, log)
+}
 
-		for _, v := range args {
-			inputs = append(inputs, reflect.ValueOf(v))
+// FromContext returns the logger stored in ctx by NewContext, or the default
+// logger bound to ctx when there is none.
+func FromContext(ctx context.Context) *Logger {
+	if scoped, ok := contextValue(ctx, scopedLoggerKey{}).(*Logger); ok {
+		return scoped.WithContext(ctx)
+	}
+	return Log().WithContext(ctx)
+}
+
+// ContextWithFields returns a copy of ctx carrying the request fields of ctx
+// and the key value pairs kv, e.g. ContextWithFields(ctx, "partner_id", id).
+// Every logger bound to the returned context logs the fields. The fields of
+// ctx are copied, never modified. A key which is not a string is formatted
+// with fmt.Sprint, a trailing key without value gets a nil value.
+func ContextWithFields(ctx context.Context, kv ...interface{}) context.Context {
+	fields := FieldsFromContext(ctx)
+	for i := 0; i < len(kv); i += 2 {
+		key, ok := kv[i].(string)
+		if !ok {
+			key = fmt.Sprint(kv[i])
 		}
+		var value interface{}
+		if i+1 < len(kv) {
+			value = kv[i+1]
+		}
+		fields[key] = value
+	}
+	return context.WithValue(ctx, consts.LogData, fields)
+}
 
-		callableMethod.Call(inputs)
-		return
+// FieldsFromContext returns a copy of the request fields carried by ctx: the
+// fields of the scoped logger bound by LogMiddleware or NewContext, then the
+// fields added with ContextWithFields, which win on a shared key.
+func FieldsFromContext(ctx context.Context) map[string]interface{} {
+	fields := map[string]interface{}{}
+	if ctx == nil {
+		return fields
 	}
+	contextFields(ctx, fields)
+	return fields
 }
 
-// captureLogs
-func (log Logger) captureLogs(ctx context.Context, fields map[string]interface{}, incomingLogLevel logrus.Level) {
-	if logger.Level >= incomingLogLevel && recordLog {
-		logg := logrus.New()
-		headers := map[string]interface{}{
-			"Authorization": logToken,
+// contextFields copies the request fields carried by ctx to fields, with the
+// precedence of FieldsFromContext
+func contextFields(ctx context.Context, fields map[string]interface{}) {
+	if scoped, ok := contextValue(ctx, scopedLoggerKey{}).(*Logger); ok {
+		for key, value := range scoped.fields {
+			fields[key] = value
 		}
-		resp, err := utils.APIRequest(http.MethodPost, fmt.Sprintf("%s/%s", logServiceURL, "logs"), headers, fields)
-		if err != nil {
-			logg.Errorf("capturing logs failed, api call failed, err=%s", err.Error())
-		}
-		if resp.StatusCode != http.StatusCreated {
-			logg.Errorf("capturing logs failed, api responsecode=%v", resp.StatusCode)
+	}
+	if ctxFields, ok := contextValue(ctx, consts.LogData).(map[string]interface{}); ok {
+		for key, value := range ctxFields {
+			fields[key] = value
 		}
 	}
 }
 
-// WithFields
-func (log Logger) WithFields(fields map[string]interface{}) Logger {
-	log.fields = fields
-	return log
+// Get the context
+func (log *Logger) Context() context.Context {
+	return log.ctx
 }
 
-// Log
-func (log Logger) Log(incomingLogLevel logrus.Level) Logger {
-	fields := log.Fields()
-	ctx := log.Context()
-
-	if ctx != nil {
-		if ctxFields, ok := ctx.Value(consts.LogData).(map[string]interface{}); ok {
-			for key, value := range ctxFields {
-				fields[key] = value
-			}
-		}
+// WithContext returns a new logger bound to ctx. The request fields carried by
+// ctx are added to every entry of the returned logger.
+func (log *Logger) WithContext(ctx context.Context) *Logger {
+	return &Logger{
+		ctx:    ctx,
+		fields: log.fields,
+		core:   log.core,
 	}
+}
 
-	lf := make(logrus.Fields)
-	entry := logger.WithFields(lf)
-	for key, element := range fields {
-		entry = entry.WithField(key, element)
+// With returns a new logger with the field added
+func (log *Logger) With(key string, value interface{}) *Logger {
+	fields := make(map[string]interface{}, len(log.fields)+1)
+	for k, v := range log.fields {
+		fields[k] = v
+	}
+	fields[key] = value
+	return &Logger{
+		ctx:    log.ctx,
+		fields: fields,
+		core:   log.core,
 	}
-	fields[consts.ContextTimeStamp] = time.Now()
-	fields[consts.ContextLogLevel] = incomingLogLevel.String()
-	log.captureLogs(ctx, fields, incomingLogLevel)
+}
 
-	// enable to set it as json formater
-	if log.jsonFormat {
-		entry.Logger.SetFormatter(&logrus.JSONFormatter{})
+// WithFields returns a new logger with the fields added
+func (log *Logger) WithFields(fields map[string]interface{}) *Logger {
+	merged := make(map[string]interface{}, len(log.fields)+len(fields))
+	for k, v := range log.fields {
+		merged[k] = v
+	}
+	for k, v := range fields {
+		merged[k] = v
 	}
+	return &Logger{
+		ctx:    log.ctx,
+		fields: merged,
+		core:   log.core,
+	}
+}
 
-	// log entry
-	log.WithEntry(entry)
+// WithError returns a new logger describing err: the message, the type of the
+// root cause, the Unwrap chain and the stack captured by Wrap or Wrapf.
+func (log *Logger) WithError(err error) *Logger {
+	if err == nil {
+		return log
+	}
+	return log.WithFields(errorFields(err))
+}
 
-	return log
+// Fields returns a copy of the fields of the logger
+func (log *Logger) Fields() map[string]interface{} {
+	fields := make(map[string]interface{}, len(log.fields))
+	for k, v := range log.fields {
+		fields[k] = v
+	}
+	return fields
+}
+
+// entryFields collects the fields of an entry. The request fields of the
+// context come first, as returned by FieldsFromContext, then the span of the
+// context and finally the fields of this logger.
+func (log *Logger) entryFields() logrus.Fields {
+	fields := make(logrus.Fields, len(log.fields)+4)
+	if log.ctx != nil {
+		contextFields(log.ctx, fields)
+		if span, ok := trace.FromContext(log.ctx); ok {
+			fields[consts.ContextTraceID] = span.TraceID
+			fields[consts.ContextSpanID] = span.SpanID
+		}
+	}
+	for key, value := range log.fields {
+		fields[key] = value
+	}
+	return fields
 }
 
-// Trace
-func (log Logger) Trace(message string, args ...interface{}) {
-	log.logFunc(false, "Trace", message, args...)
+// logFunc builds the entry and writes it to the sinks
+func (log *Logger) logFunc(level logrus.Level, fields logrus.Fields, template, message string) {
+	log.write(level, fields, template, message, callerFrame)
 }
 
-// Tracef
-func (log Logger) Tracef(message string, args ...interface{}) {
-	log.logFunc(true, "Trace", message, args...)
+// write writes the entry to the sinks. template is the message before
+// formatting, the sampler counts the entries per template. caller returns the
+// frame of the code logging the entry, it is only called when the entry is
+// written or a package level override has to be checked.
+func (log *Logger) write(level logrus.Level, fields logrus.Fields, template, message string, caller func() (runtime.Frame, bool)) {
+	c := log.resolve()
+	if c.closed.Load() || !c.levels.enabled(level, fields, caller) {
+		return
+	}
+	if c.sampler != nil && !c.sampler.allow(level, template) {
+		return
+	}
+	c.emit(level, fields, message, caller)
 }
 
-// Errorf
-func (log Logger) Errorf(message string, args ...interface{}) {
-	var fields = log.Fields()
-	_, file, no, ok := runtime.Caller(1)
-	if ok {
-		fName := strings.Split(file, "/")
-		fields["file"] = fName[len(fName)-1]
-		fields["line"] = no
-		fields[consts.ContextMessage] = fmt.Sprintf(message, args...)
+// auditf logs the formatted message at warn level whatever the levels of the
+// logger and of its sinks, so changes such as the level ones are always
+// traced. The entry carries the audit field and is never sampled.
+func (log *Logger) auditf(message string, args ...interface{}) {
+	c := log.resolve()
+	if c.closed.Load() {
+		return
+	}
+	fields := log.entryFields()
+	fields[consts.ContextAudit] = true
+	c.emit(logrus.WarnLevel, fields, fmt.Sprintf(message, args...), callerFrame)
+}
+
+// emit redacts the entry, adds the caller and hands it to the sinks. A fatal
+// entry closes the sinks before exiting, so the buffered entries such as the
+// CloudMode batches are delivered with it.
+func (c *core) emit(level logrus.Level, fields logrus.Fields, message string, caller func() (runtime.Frame, bool)) {
+	if c.redaction != nil {
+		c.redaction.fields(fields)
+		message = c.redaction.text(message)
+	}
+	fields[consts.ContextMessage] = message
+	if frame, ok := caller(); ok {
+		fields["func"] = frame.Function
+		fields["file"] = filepath.Base(frame.File)
+		fields["line"] = frame.Line
+	}
+	entry := c.logrus.WithFields(fields)
+
+	switch level {
+	case logrus.FatalLevel:
+		entry.Log(level, message)
+		ctx, cancel := context.WithTimeout(context.Background(), fatalCloseTimeout)
+		_ = c.close(ctx)
+		cancel()
+		exit(1)
+	case logrus.PanicLevel:
+		entry.Panic(message)
+	default:
+		entry.Log(level, message)
 	}
+}
 
-	// set the message
-	fields[consts.ContextMessage] = fmt.Sprintf(message, args...)
+// print logs the message, args are kept in their own field
+func (log *Logger) print(level logrus.Level, message string, args ...interface{}) {
+	fields := log.entryFields()
+	if len(args) > 0 {
+		fields["args"] = fmt.Sprint(args...)
+	}
+	log.logFunc(level, fields, message, message)
+}
 
-	log.
-		WithFields(fields).
-		Log(logrus.ErrorLevel).
-		Entry().Errorf(message, args...)
+// printf logs the formatted message
+func (log *Logger) printf(level logrus.Level, message string, args ...interface{}) {
+	log.logFunc(level, log.entryFields(), message, fmt.Sprintf(message, args...))
+}
 
+// Trace
+func (log *Logger) Trace(message string, args ...interface{}) {
+	log.print(logrus.TraceLevel, message, args...)
 }
 
-// Error
-func (log Logger) Error(message string, args ...interface{}) {
-
-	var fields = log.Fields()
-	_, file, no, ok := runtime.Caller(1)
-	if ok {
-		fName := strings.Split(file, "/")
-		fields["file"] = fName[len(fName)-1]
-		fields["line"] = no
-		fields[consts.ContextMessage] = message
-		fields["args"] = fmt.Sprint(args...)
-	}
+// Tracef
+func (log *Logger) Tracef(message string, args ...interface{}) {
+	log.printf(logrus.TraceLevel, message, args...)
+}
 
-	fields[consts.ContextMessage] = message
+// Errorf
+func (log *Logger) Errorf(message string, args ...interface{}) {
+	log.printf(logrus.ErrorLevel, message, args...)
+}
 
-	log.
-		WithFields(fields).
-		Log(logrus.ErrorLevel).
-		Entry().Error(args...)
+// Error
+func (log *Logger) Error(message string, args ...interface{}) {
+	log.print(logrus.ErrorLevel, message, args...)
 }
 
 // Print
-func (log Logger) Print(message string, args ...interface{}) {
-	log.logFunc(false, "Print", message, args...)
+func (log *Logger) Print(message string, args ...interface{}) {
+	log.print(logrus.InfoLevel, message, args...)
 }
 
 // Printf
-func (log Logger) Printf(message string, args ...interface{}) {
-	log.logFunc(true, "Print", message, args...)
+func (log *Logger) Printf(message string, args ...interface{}) {
+	log.printf(logrus.InfoLevel, message, args...)
 }
 
 // Info
-func (log Logger) Info(message string, args ...interface{}) {
-	log.logFunc(false, "Info", message, args...)
+func (log *Logger) Info(message string, args ...interface{}) {
+	log.print(logrus.InfoLevel, message, args...)
 }
 
 // Infof
-func (log Logger) Infof(message string, args ...interface{}) {
-	log.logFunc(true, "Info", message, args...)
+func (log *Logger) Infof(message string, args ...interface{}) {
+	log.printf(logrus.InfoLevel, message, args...)
 }
 
 // Debug
-func (log Logger) Debug(message string, args ...interface{}) {
-	log.logFunc(false, "Debug", message, args...)
+func (log *Logger) Debug(message string, args ...interface{}) {
+	log.print(logrus.DebugLevel, message, args...)
 }
 
 // Debugf
-func (log Logger) Debugf(message string, args ...interface{}) {
-	log.logFunc(true, "Debug", message, args...)
+func (log *Logger) Debugf(message string, args ...interface{}) {
+	log.printf(logrus.DebugLevel, message, args...)
 }
 
 // Warn
-func (log Logger) Warn(message string, args ...interface{}) {
-	log.logFunc(false, "Warn", message, args...)
+func (log *Logger) Warn(message string, args ...interface{}) {
+	log.print(logrus.WarnLevel, message, args...)
 }
 
 // Warnf
-func (log Logger) Warnf(message string, args ...interface{}) {
-	log.logFunc(true, "Warn", message, args...)
+func (log *Logger) Warnf(message string, args ...interface{}) {
+	log.printf(logrus.WarnLevel, message, args...)
 }
 
 // Fatal
-func (log Logger) Fatal(message string, args ...interface{}) {
-	log.logFunc(false, "Fatal", message, args...)
+func (log *Logger) Fatal(message string, args ...interface{}) {
+	log.print(logrus.FatalLevel, message, args...)
 }
 
 // Fatalf
-func (log Logger) Fatalf(message string, args ...interface{}) {
-	log.logFunc(true, "Fatalf", message, args...)
+func (log *Logger) Fatalf(message string, args ...interface{}) {
+	log.printf(logrus.FatalLevel, message, args...)
 }
 
 // Panic
-func (log Logger) Panic(message string, args ...interface{}) {
-	log.logFunc(false, "Panic", message, args...)
+func (log *Logger) Panic(message string, args ...interface{}) {
+	log.print(logrus.PanicLevel, message, args...)
 }
 
 // Panicf
-func (log Logger) Panicf(message string, args ...interface{}) {
-	log.logFunc(true, "Panicf", message, args...)
+func (log *Logger) Panicf(message string, args ...interface{}) {
+	log.printf(logrus.PanicLevel, message, args...)
 }
@@ -1,8 +1,16 @@
 package logger
 
 import (
-	"log"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
 
+	"github.com/sirupsen/logrus"
+	"gitlab.com/tuneverse/toolkit/consts"
+	"gitlab.com/tuneverse/toolkit/core/queue"
 	"gitlab.com/tuneverse/toolkit/utils"
 )
 
@@ -12,21 +20,35 @@ var (
 	DefaultMinOne         = 1
 	DefaultMinBackupCount = 3
 	DefaultLogMaxSize     = int64(DefaultSizeUnit*DefaultSizeUnit) * 5
-)
 
-type logMode int
+	DefaultLogMaxTotalSize = int64(DefaultSizeUnit*DefaultSizeUnit) * 100
 
-type loggerImply interface {
-	setLogger(clientOpts *clientOptions) *clientOptions
-}
+	DefaultBufferSize    = 1024
+	DefaultBatchSize     = 100
+	DefaultFlushInterval = 2 * time.Second
+	DefaultMaxRetries    = 3
+	DefaultRetryBackoff  = 500 * time.Millisecond
+	DefaultMaxBackoff    = 10 * time.Second
+	DefaultQueueTimeout  = 10 * time.Second
+
+	DefaultSpoolMaxSize   = int64(DefaultSizeUnit*DefaultSizeUnit) * 64
+	DefaultHealthInterval = 30 * time.Second
+)
 
 type FileMode struct {
+	SinkOptions
+
 	// LogPath determines the directory in which to store log files.
 	// It defaults to os.TempDir() if empty.
 	LogPath string
-	// LogfileName is the time formatting layout used to generate filenames.
-	// It defaults to "2006-01-02T15-04-05.000.log".
+	// LogfileName is the name of the log file being written, e.g.
+	// "partner.log". The rotated files are named after it with the period
+	// they cover, e.g. "partner-2024-05-01.log.gz". It defaults to
+	// "<service>.log".
 	LogfileName string
+	// Rotation decides when a new log file is started. It defaults to
+	// RotateDaily.
+	Rotation Rotation
 	// LogMaxSize is the maximum size in bytes of the log file before it gets
 	// rolled. It defaults to 5 megabytes.
 	LogMaxSize int64
@@ -37,51 +59,204 @@ type FileMode struct {
 	// FileInfo.ModTime. Note that a day is defined as 24 hours and may not
 	// exactly correspond to calendar days due to daylight savings, leap seconds, etc.
 	LogMaxAge int
+	// LogMaxTotalSize is the maximum size in bytes of the log file and its
+	// backups together, the oldest backups are removed once it is reached. A
+	// negative value disables the cap. It defaults to 100 megabytes.
+	LogMaxTotalSize int64
+	// DisableCompression keeps the rotated files as they are, they are
+	// compressed with gzip by default.
+	DisableCompression bool
+
+	writer WriterSink
 }
 
 type CloudMode struct {
+	// LogLevel is the minimum level sent to the log service, it defaults to
+	// ClientOptions.LogLevel. Records are always sent as JSON documents so a
+	// Formatter is not used by this sink.
+	SinkOptions
+
 	// URL of the external service to send the logs
 	URL string
 	// Secret is the client secret to generate the token
 	Secret string
+	// BufferSize is the number of records held in memory while waiting to be
+	// shipped. It defaults to 1024.
+	BufferSize int
+	// BatchSize is the number of records sent in a single request. A batch is
+	// flushed as soon as it is full. It defaults to 100.
+	BatchSize int
+	// FlushInterval is the longest a partial batch waits before it is sent.
+	// It defaults to 2 seconds.
+	FlushInterval time.Duration
+	// MaxRetries is the number of times a failed batch is resent before it is
+	// given up. A negative value disables retries. It defaults to 3.
+	MaxRetries int
+	// RetryBackoff is the wait before the first retry. It doubles on every
+	// attempt up to DefaultMaxBackoff. It defaults to 500 milliseconds.
+	RetryBackoff time.Duration
+	// DropPolicy decides which record is discarded when the buffer is full.
+	// It defaults to DropNewest.
+	DropPolicy DropPolicy
+	// SpoolDir is the directory of the spool keeping the records that could
+	// not be delivered. They are replayed once the log service is healthy
+	// again, also after a restart. It defaults to logs/<service>-log-spool.
+	SpoolDir string
+	// SpoolMaxSize is the maximum size in bytes of the spool, records are
+	// dropped once it is reached. A negative value disables the spool. It
+	// defaults to 64 megabytes.
+	SpoolMaxSize int64
+	// HealthInterval is the period of the health checks while the log service
+	// is down or records are spooled. It defaults to 30 seconds.
+	HealthInterval time.Duration
+	// Queue, when set, carries the batches instead of the HTTP API: every
+	// batch is sent as one {"logs": [...]} message consumed by the log
+	// service, and URL and Secret are not used. The caller closes the queue
+	// after the logger. BatchSize must keep the messages under the size
+	// limit of the broker, 256 KB on SQS.
+	Queue queue.Queue
+
+	service string
+	shipper *shipper
+	spool   *spool
 }
 
-// set the client options for file mode
-func (file *FileMode) setLogger(clientOpts *clientOptions) *clientOptions {
-	if utils.IsEmpty(file.LogPath) {
-		clientOpts.logPath = utils.TempDir()
-	} else {
-		clientOpts.logPath = file.LogPath
+// Init creates the log directory and opens the rolling log file
+func (file *FileMode) Init(cfg SinkConfig) error {
+	if err := file.Apply(cfg); err != nil {
+		return err
 	}
-	if !utils.IsEmpty(file.LogfileName) {
-		clientOpts.logfileName = file.LogfileName
+	if utils.IsEmpty(file.LogPath) {
+		file.LogPath = utils.TempDir()
 	}
 	if file.LogMaxSize < DefaultLogMaxSize {
-		clientOpts.logMaxSize = DefaultLogMaxSize
-	} else {
-		clientOpts.logMaxSize = file.LogMaxSize
+		file.LogMaxSize = DefaultLogMaxSize
 	}
 	if file.LogMaxAge < DefaultMinOne {
-		clientOpts.logMaxAge = DefaultLogMaxAge
-	} else {
-		clientOpts.logMaxAge = file.LogMaxAge
+		file.LogMaxAge = DefaultLogMaxAge
 	}
 	if file.LogMaxBackup < DefaultMinOne {
-		clientOpts.logMaxBackup = DefaultMinBackupCount
-	} else {
-		clientOpts.logMaxBackup = file.LogMaxBackup
+		file.LogMaxBackup = DefaultMinBackupCount
+	}
+	if file.LogMaxTotalSize == 0 {
+		file.LogMaxTotalSize = DefaultLogMaxTotalSize
+	}
+	if utils.IsEmpty(file.LogfileName) {
+		file.LogfileName = cfg.Service + ".log"
+	}
+	if _, err := os.Stat(file.LogPath); os.IsNotExist(err) {
+		err := os.MkdirAll(file.LogPath, os.ModePerm)
+		if err != nil {
+			return err
+		}
+	}
+	file.writer.SinkOptions = file.SinkOptions
+	file.writer.Writer = newRotatingFile(file, file.LogfileName)
+	return nil
+}
+
+// Write appends the entry to the log file
+func (file *FileMode) Write(entry *logrus.Entry) error {
+	return file.writer.Write(entry)
+}
+
+// Close closes the log file
+func (file *FileMode) Close(ctx context.Context) error {
+	closer, ok := file.writer.Writer.(io.Closer)
+	if !ok {
+		return nil
+	}
+	file.writer.mu.Lock()
+	defer file.writer.mu.Unlock()
+	return closer.Close()
+}
+
+// Init opens the spool and starts the background shipper. An unreachable log
+// service does not fail the start, the records are spooled until it is back.
+func (cloud *CloudMode) Init(cfg SinkConfig) error {
+	if err := cloud.Apply(cfg); err != nil {
+		return err
+	}
+	cloud.service = cfg.Service
+	var (
+		transport = &recordOptions{}
+		pingErr   error
+		err       error
+	)
+	// the queue buffers the batches itself, the log service does not need
+	// to be reachable
+	if cloud.Queue == nil {
+		transport, err = initTransportOptions(true, cloud.URL, cloud.Secret)
+		if err != nil {
+			return err
+		}
+		pingErr = ping(fmt.Sprintf("%s/%s", transport.url, "health"), transport.token)
+		transport.degraded = pingErr != nil
+	}
+
+	if cloud.SpoolMaxSize >= 0 {
+		if cloud.SpoolMaxSize == 0 {
+			cloud.SpoolMaxSize = DefaultSpoolMaxSize
+		}
+		if utils.IsEmpty(cloud.SpoolDir) {
+			cloud.SpoolDir = filepath.Join(utils.TempDir(), cfg.Service+"-log-spool")
+		}
+		cloud.spool, err = openSpool(cloud.SpoolDir, cloud.SpoolMaxSize)
+		if err != nil {
+			return err
+		}
+	}
+	cloud.shipper = newShipper(transport, cloud)
+	if pingErr != nil {
+		cloud.shipper.errLog.Warnf("log service unreachable, starting in degraded mode, err=%s", pingErr.Error())
+	}
+	return nil
+}
+
+// Write converts the entry into a log record and queues it for shipping. It
+// never blocks on the log service.
+func (cloud *CloudMode) Write(entry *logrus.Entry) error {
+	record := make(map[string]interface{}, len(entry.Data)+3)
+	for key, value := range entry.Data {
+		record[key] = value
+	}
+	if _, ok := record[consts.ContextMessage]; !ok {
+		record[consts.ContextMessage] = entry.Message
+	}
+	// the log service requires the service of every record, the entries
+	// logged outside of a request do not carry it
+	if _, ok := record[consts.ContextService]; !ok {
+		record[consts.ContextService] = cloud.service
+	}
+	record[consts.ContextTimeStamp] = entry.Time
+	record[consts.ContextLogLevel] = entry.Level.String()
+	cloud.shipper.enqueue(record)
+	return nil
+}
+
+// Flush waits until the records queued so far are delivered to the log
+// service, or spooled when it is down
+func (cloud *CloudMode) Flush(ctx context.Context) error {
+	if cloud.shipper == nil {
+		return nil
+	}
+	return cloud.shipper.flush(ctx)
+}
+
+// Close sends the queued records and stops the background shipper. A failed
+// batch is spooled without retries and replayed by the next run.
+func (cloud *CloudMode) Close(ctx context.Context) error {
+	if cloud.shipper == nil {
+		return nil
 	}
-	clientOpts.appendLogModes(logModeFile)
-	return clientOpts
+	return cloud.shipper.close(ctx)
 }
 
-// set the client options for cloud mode
-func (cloud *CloudMode) setLogger(clientOpts *clientOptions) *clientOptions {
-	var err error
-	clientOpts.recordOptions, err = initTransportOptions(true, cloud.URL, cloud.Secret)
-	if err != nil {
-		log.Fatalf("SetTransportOptions failed error=%s", err.Error())
+// Dropped returns the number of records discarded because the buffer or the
+// spool was full, or because the log service rejected them
+func (cloud *CloudMode) Dropped() uint64 {
+	if cloud.shipper == nil {
+		return 0
 	}
-	clientOpts.appendLogModes(logModeCloud)
-	return clientOpts
+	return cloud.shipper.dropped.Load()
 }
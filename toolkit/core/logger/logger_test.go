@@ -1,11 +1,51 @@
 package logger_test
 
 import (
+	"context"
+	"io"
+	"sync"
 	"testing"
 
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+	"gitlab.com/tuneverse/toolkit/consts"
 	"gitlab.com/tuneverse/toolkit/core/logger"
 )
 
+// fieldSink is a custom sink collecting the fields of every entry
+type fieldSink struct {
+	logger.SinkOptions
+	mu      sync.Mutex
+	entries []map[string]interface{}
+}
+
+func (sink *fieldSink) Init(cfg logger.SinkConfig) error {
+	return sink.Apply(cfg)
+}
+
+func (sink *fieldSink) Write(entry *logrus.Entry) error {
+	fields := make(map[string]interface{}, len(entry.Data))
+	for key, value := range entry.Data {
+		fields[key] = value
+	}
+	sink.mu.Lock()
+	defer sink.mu.Unlock()
+	sink.entries = append(sink.entries, fields)
+	return nil
+}
+
+func (sink *fieldSink) all() []map[string]interface{} {
+	sink.mu.Lock()
+	defer sink.mu.Unlock()
+	return append([]map[string]interface{}{}, sink.entries...)
+}
+
+func (sink *fieldSink) reset() {
+	sink.mu.Lock()
+	defer sink.mu.Unlock()
+	sink.entries = nil
+}
+
 func TestLogger(t *testing.T) {
 	t.Run("init-logger", func(t *testing.T) {
 		logger.InitLogger(&logger.ClientOptions{
@@ -22,3 +62,183 @@ func TestLogger(t *testing.T) {
 		logger.Log().Error("demo-001 %v", "1")
 	})
 }
+
+func TestChildLoggers(t *testing.T) {
+	sink := &fieldSink{}
+	logger.InitLogger(&logger.ClientOptions{
+		Service:  "service",
+		LogLevel: "info",
+	}, &logger.ConsoleMode{Output: io.Discard}, sink)
+
+	t.Run("children do not share fields", func(t *testing.T) {
+		parent := logger.Log().With("parent", true)
+		child := parent.With("child", true)
+
+		require.Equal(t, map[string]interface{}{"parent": true}, parent.Fields())
+		require.Equal(t, map[string]interface{}{"parent": true, "child": true}, child.Fields())
+	})
+
+	t.Run("context fields are not mutated", func(t *testing.T) {
+		ctxFields := map[string]interface{}{"req_id": "1"}
+		ctx := context.WithValue(context.Background(), consts.LogData, ctxFields)
+
+		logger.Log().WithContext(ctx).With("extra", 1).Info("message")
+
+		require.Equal(t, map[string]interface{}{"req_id": "1"}, ctxFields)
+	})
+
+	t.Run("parallel loggers", func(t *testing.T) {
+		sink.reset()
+		base := logger.Log().With("service", "parallel")
+
+		var wg sync.WaitGroup
+		for i := 0; i < 50; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				ctx := logger.NewContext(context.Background(), base.With("req_id", i))
+				log := logger.FromContext(ctx)
+				log.With("worker", i).Info("working")
+				log.Errorf("failed %d", i)
+			}(i)
+		}
+		wg.Wait()
+
+		entries := sink.all()
+		require.Len(t, entries, 100)
+		for _, fields := range entries {
+			if worker, ok := fields["worker"]; ok {
+				require.Equal(t, worker, fields["req_id"])
+			}
+			require.Equal(t, "parallel", fields["service"])
+		}
+	})
+}
+
+func TestInstances(t *testing.T) {
+	billingSink, catalogSink := &fieldSink{}, &fieldSink{}
+	billing, err := logger.New(&logger.ClientOptions{
+		Service:            "billing",
+		LogLevel:           "debug",
+		IncludeRequestDump: true,
+	}, &logger.ConsoleMode{Output: io.Discard}, billingSink)
+	require.NoError(t, err)
+	catalog, err := logger.New(&logger.ClientOptions{
+		Service:  "catalog",
+		LogLevel: "warn",
+	}, &logger.ConsoleMode{Output: io.Discard}, catalogSink)
+	require.NoError(t, err)
+
+	t.Run("settings are kept apart", func(t *testing.T) {
+		require.Equal(t, "billing", billing.Service())
+		require.Equal(t, "catalog", catalog.Service())
+		require.True(t, billing.IncludeRequestDump())
+		require.False(t, catalog.IncludeRequestDump())
+
+		billing.Debug("billing debug")
+		catalog.Debug("catalog debug")
+		catalog.Warn("catalog warn")
+
+		require.Len(t, billingSink.all(), 1)
+		require.Equal(t, "billing debug", billingSink.all()[0][consts.ContextMessage])
+		require.Len(t, catalogSink.all(), 1)
+		require.Equal(t, "catalog warn", catalogSink.all()[0][consts.ContextMessage])
+	})
+
+	t.Run("levels are per logger", func(t *testing.T) {
+		billingSink.reset()
+		require.NoError(t, billing.With("child", true).SetLevel(logger.LevelState{Level: "error"}, 0))
+		defer billing.ResetLevel()
+
+		require.Equal(t, "error", billing.GetLevel().Level)
+		require.Equal(t, "warning", catalog.GetLevel().Level)
+		billing.Warn("dropped")
+		require.Empty(t, billingSink.all())
+	})
+
+	t.Run("default logger follows the scoped logger", func(t *testing.T) {
+		catalogSink.reset()
+		ctx := logger.NewContext(context.Background(), catalog.With("req_id", "1"))
+		logger.Log().WithContext(ctx).Error("handler failed")
+
+		entries := catalogSink.all()
+		require.Len(t, entries, 1)
+		require.Equal(t, "1", entries[0]["req_id"])
+	})
+
+	t.Run("invalid options", func(t *testing.T) {
+		_, err := logger.New(&logger.ClientOptions{})
+		require.Error(t, err)
+		_, err = logger.New(&logger.ClientOptions{Service: "service", LogLevel: "loud"})
+		require.Error(t, err)
+	})
+}
+
+// flushSink counts the calls of Flush and Close
+type flushSink struct {
+	fieldSink
+	flushed, closed int
+}
+
+func (sink *flushSink) Flush(ctx context.Context) error {
+	sink.flushed++
+	return nil
+}
+
+func (sink *flushSink) Close(ctx context.Context) error {
+	sink.closed++
+	return nil
+}
+
+func TestClose(t *testing.T) {
+	sink := &flushSink{}
+	log, err := logger.New(&logger.ClientOptions{
+		Service:  "service",
+		LogLevel: "info",
+	}, &logger.ConsoleMode{Output: io.Discard}, sink, &logger.FileMode{LogPath: t.TempDir()})
+	require.NoError(t, err)
+
+	log.Info("before close")
+	require.NoError(t, log.Flush(context.Background()))
+	require.Equal(t, 1, sink.flushed)
+
+	require.NoError(t, log.With("child", true).Close(context.Background()))
+	require.NoError(t, log.Close(context.Background()))
+	require.Equal(t, 1, sink.closed)
+
+	log.Info("after close")
+	require.Len(t, sink.all(), 1)
+}
+
+func TestContextFields(t *testing.T) {
+	sink := &fieldSink{}
+	log, err := logger.New(&logger.ClientOptions{
+		Service:  "service",
+		LogLevel: "info",
+	}, &logger.ConsoleMode{Output: io.Discard}, sink)
+	require.NoError(t, err)
+
+	parent := logger.ContextWithFields(context.Background(), "partner_id", "p-1")
+	child := logger.ContextWithFields(parent, "member_id", "m-1", "partner_id", "p-2", 7, "seven", "dangling")
+
+	require.Equal(t, map[string]interface{}{"partner_id": "p-1"}, logger.FieldsFromContext(parent))
+	require.Equal(t, map[string]interface{}{
+		"partner_id": "p-2",
+		"member_id":  "m-1",
+		"7":          "seven",
+		"dangling":   nil,
+	}, logger.FieldsFromContext(child))
+
+	// the returned map is a copy
+	logger.FieldsFromContext(parent)["partner_id"] = "changed"
+	require.Equal(t, "p-1", logger.FieldsFromContext(parent)["partner_id"])
+
+	log.WithContext(parent).Info("parent")
+	log.WithContext(child).Info("child")
+	entries := sink.all()
+	require.Equal(t, "p-1", entries[0]["partner_id"])
+	require.NotContains(t, entries[0], "member_id")
+	require.Equal(t, "m-1", entries[1]["member_id"])
+
+	require.Empty(t, logger.FieldsFromContext(context.Background()))
+}
@@ -0,0 +1,201 @@
+// Package loggertest records the entries of the toolkit logger in memory, so
+// unit tests can check what a package logs without writing files or
+// reaching the log service.
+//
+//	rec := loggertest.New(t)
+//	err := usecase.GetPartner(ctx, id)
+//	rec.AssertLogged(logrus.ErrorLevel, "get partner failed", map[string]interface{}{
+//		"partner_id": id,
+//	})
+//
+// The default logger drops every entry until InitLogger is called, tests
+// which do not look at the logs need no setup at all.
+package loggertest
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"gitlab.com/tuneverse/toolkit/core/logger"
+)
+
+// Service is the service name the recorder initializes the logger with
+const Service = "loggertest"
+
+// Entry is a recorded log entry. Fields holds every field of the entry, the
+// request fields, the caller and the message included.
+type Entry struct {
+	Level   logrus.Level
+	Message string
+	Fields  map[string]interface{}
+	Time    time.Time
+}
+
+// String renders the entry in failure messages
+func (e Entry) String() string {
+	keys := make([]string, 0, len(e.Fields))
+	for key := range e.Fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %q", e.Level, e.Message)
+	for _, key := range keys {
+		fmt.Fprintf(&b, " %s=%v", key, e.Fields[key])
+	}
+	return b.String()
+}
+
+// Recorder is a logger.Sink keeping every entry in memory
+type Recorder struct {
+	t   testing.TB
+	log *logger.Logger
+
+	mu      sync.Mutex
+	entries []Entry
+}
+
+// New makes a Recorder at trace level the default logger for the duration of
+// the test and returns it; the previous default is restored when the test
+// ends. The console output is discarded. The failures of the assertions are
+// reported to t.
+//
+// The default logger is shared by the whole process: tests calling New must
+// not run in parallel, the restore fails the test when another one replaced
+// the default meanwhile. Code taking a logger can be given Logger instead.
+func New(t testing.TB) *Recorder {
+	t.Helper()
+	rec := &Recorder{t: t}
+	log, err := logger.New(&logger.ClientOptions{
+		Service:  Service,
+		LogLevel: logrus.TraceLevel.String(),
+	}, rec, &logger.ConsoleMode{Output: io.Discard})
+	if err != nil {
+		t.Fatalf("creating the recorder failed: %s", err.Error())
+	}
+	rec.log = log
+	restore := logger.SetDefault(log)
+	t.Cleanup(func() {
+		if !restore() {
+			t.Errorf("the default logger was replaced while the recorder was installed, tests using loggertest.New must not run in parallel")
+		}
+	})
+	return rec
+}
+
+// Logger returns the logger writing to the recorder, to be passed to the
+// code taking a logger such as middleware.LogMiddleware
+func (r *Recorder) Logger() *logger.Logger {
+	return r.log
+}
+
+// Init implements logger.Sink
+func (r *Recorder) Init(cfg logger.SinkConfig) error {
+	return nil
+}
+
+// Level implements logger.Sink, every entry is recorded
+func (r *Recorder) Level() logrus.Level {
+	return logrus.TraceLevel
+}
+
+// Write implements logger.Sink
+func (r *Recorder) Write(entry *logrus.Entry) error {
+	fields := make(map[string]interface{}, len(entry.Data))
+	for key, value := range entry.Data {
+		fields[key] = value
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries = append(r.entries, Entry{
+		Level:   entry.Level,
+		Message: entry.Message,
+		Fields:  fields,
+		Time:    entry.Time,
+	})
+	return nil
+}
+
+// Entries returns the recorded entries, oldest first
+func (r *Recorder) Entries() []Entry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Entry{}, r.entries...)
+}
+
+// Reset forgets the recorded entries
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries = nil
+}
+
+// Find returns the entries of the level whose message contains msgContains
+// and which carry the fields. The field values are compared after
+// conversion, an int matches an int64 of the same value.
+func (r *Recorder) Find(level logrus.Level, msgContains string, fields map[string]interface{}) []Entry {
+	var found []Entry
+	for _, entry := range r.Entries() {
+		if entry.Level == level && strings.Contains(entry.Message, msgContains) && hasFields(entry, fields) {
+			found = append(found, entry)
+		}
+	}
+	return found
+}
+
+// AssertLogged checks an entry of the level, with a message containing
+// msgContains and carrying the fields, was recorded. The recorded entries
+// are listed on failure.
+func (r *Recorder) AssertLogged(level logrus.Level, msgContains string, fields map[string]interface{}) bool {
+	r.t.Helper()
+	if len(r.Find(level, msgContains, fields)) > 0 {
+		return true
+	}
+	r.t.Errorf("no %s entry containing %q with fields %v\n%s", level, msgContains, fields, r.dump())
+	return false
+}
+
+// AssertNotLogged checks no entry of the level with a message containing
+// msgContains was recorded
+func (r *Recorder) AssertNotLogged(level logrus.Level, msgContains string) bool {
+	r.t.Helper()
+	found := r.Find(level, msgContains, nil)
+	if len(found) == 0 {
+		return true
+	}
+	r.t.Errorf("unexpected %s entry containing %q: %s", level, msgContains, found[0])
+	return false
+}
+
+// dump lists the recorded entries
+func (r *Recorder) dump() string {
+	entries := r.Entries()
+	if len(entries) == 0 {
+		return "no entry was recorded"
+	}
+	var b strings.Builder
+	b.WriteString("recorded entries:")
+	for _, entry := range entries {
+		b.WriteString("\n\t")
+		b.WriteString(entry.String())
+	}
+	return b.String()
+}
+
+// hasFields reports whether the entry carries the fields
+func hasFields(entry Entry, fields map[string]interface{}) bool {
+	for key, expected := range fields {
+		actual, ok := entry.Fields[key]
+		if !ok || !assert.ObjectsAreEqualValues(expected, actual) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,106 @@
+package loggertest_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+	"gitlab.com/tuneverse/toolkit/consts"
+	"gitlab.com/tuneverse/toolkit/core/logger"
+	"gitlab.com/tuneverse/toolkit/core/logger/loggertest"
+)
+
+// recordingT collects the failures of the assertions
+type recordingT struct {
+	testing.TB
+	failures []string
+}
+
+func (t *recordingT) Helper() {}
+
+func (t *recordingT) Errorf(format string, args ...interface{}) {
+	t.failures = append(t.failures, fmt.Sprintf(format, args...))
+}
+
+// getPartner stands for a usecase logging its failure
+func getPartner(ctx context.Context, id int) error {
+	err := errors.New("connection refused")
+	logger.Log().WithContext(ctx).With("partner_id", id).Errorf("get partner failed, err=%s", err.Error())
+	return err
+}
+
+// TestDefaultLogger runs first, before any test sets a logger up
+func TestDefaultLogger(t *testing.T) {
+	require.NotPanics(t, func() {
+		logger.Log().WithContext(context.Background()).Info("dropped")
+		logger.FromContext(context.Background()).With("key", "value").Errorf("dropped %d", 1)
+	})
+}
+
+func TestRecorder(t *testing.T) {
+	rec := loggertest.New(t)
+	ctx := context.WithValue(context.Background(), consts.LogData, map[string]interface{}{
+		consts.ContextRequestID: "req-1",
+	})
+
+	require.Error(t, getPartner(ctx, 7))
+	logger.Log().Debug("cache miss")
+
+	rec.AssertLogged(logrus.ErrorLevel, "get partner failed", map[string]interface{}{
+		"partner_id":            int64(7),
+		consts.ContextRequestID: "req-1",
+		"file":                  "loggertest_test.go",
+	})
+	rec.AssertLogged(logrus.DebugLevel, "cache miss", nil)
+	rec.AssertNotLogged(logrus.WarnLevel, "")
+	require.Len(t, rec.Entries(), 2)
+
+	t.Run("failures", func(t *testing.T) {
+		ft := &recordingT{TB: t}
+		failing := loggertest.New(ft)
+		require.Error(t, getPartner(ctx, 7))
+
+		require.False(t, failing.AssertLogged(logrus.ErrorLevel, "get partner failed", map[string]interface{}{"partner_id": 8}))
+		require.False(t, failing.AssertLogged(logrus.WarnLevel, "get partner failed", nil))
+		require.False(t, failing.AssertNotLogged(logrus.ErrorLevel, "get partner"))
+		require.Len(t, ft.failures, 3)
+		require.Contains(t, ft.failures[0], `error "get partner failed, err=connection refused"`)
+		require.Contains(t, ft.failures[0], "partner_id=7")
+	})
+
+	t.Run("reset", func(t *testing.T) {
+		rec := loggertest.New(t)
+		logger.Log().Info("first")
+		rec.Reset()
+		require.Empty(t, rec.Entries())
+	})
+}
+
+func TestRecorderRestoresDefault(t *testing.T) {
+	outer := loggertest.New(t)
+
+	t.Run("recording", func(t *testing.T) {
+		inner := loggertest.New(t)
+		logger.Log().Info("inner")
+		inner.AssertLogged(logrus.InfoLevel, "inner", nil)
+	})
+	logger.Log().Info("outer")
+
+	outer.AssertLogged(logrus.InfoLevel, "outer", nil)
+	outer.AssertNotLogged(logrus.InfoLevel, "inner")
+
+	t.Run("replaced meanwhile", func(t *testing.T) {
+		ft := &recordingT{TB: t}
+		t.Run("recording", func(t *testing.T) {
+			ft.TB = t
+			loggertest.New(ft)
+			// a parallel test installing its own logger
+			logger.SetDefault(outer.Logger())
+		})
+		require.Len(t, ft.failures, 1)
+		require.Contains(t, ft.failures[0], "must not run in parallel")
+	})
+}
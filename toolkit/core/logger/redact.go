@@ -0,0 +1,327 @@
+package logger
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"gitlab.com/tuneverse/toolkit/consts"
+	"gitlab.com/tuneverse/toolkit/utils"
+)
+
+// DefaultRedactMask replaces every redacted value
+const DefaultRedactMask = "[REDACTED]"
+
+var (
+	// DefaultRedactHeaders are the headers masked in request and response dumps
+	DefaultRedactHeaders = []string{
+		"Authorization",
+		"Proxy-Authorization",
+		"Cookie",
+		"Set-Cookie",
+		"X-Api-Key",
+		"Client_secret",
+	}
+
+	// DefaultRedactKeys are the JSON keys masked at any depth, they cover the
+	// partner OAuth credentials and the payment gateway settings
+	DefaultRedactKeys = []string{
+		"password",
+		"secret",
+		"client_secret",
+		"token",
+		"access_token",
+		"refresh_token",
+		"id_token",
+		"api_key",
+		"secret_key",
+		"private_key",
+		"authorization",
+		"card_number",
+		"cvv",
+		"cvc",
+		"otp",
+	}
+
+	// DefaultRedactPaths are the JSON paths masked in dumped bodies
+	DefaultRedactPaths = []string{
+		"payment.payment_gateways.*.email",
+		"payment.payment_gateways.*.client_id",
+		"payment_gateways.*.email",
+		"payment_gateways.*.client_id",
+	}
+
+	emailPattern = regexp.MustCompile(`[a-zA-Z0-9._%+\-]+@[a-zA-Z0-9.\-]+\.[a-zA-Z]{2,}`)
+	phonePattern = regexp.MustCompile(`\+\d[\d\s().\-]{7,18}\d`)
+	cardPattern  = regexp.MustCompile(`\b(?:\d[ \-]?){12,18}\d\b`)
+
+	// unredactedFields are generated by the toolkit and never hold user data
+	unredactedFields = map[string]bool{
+		consts.ContextRequestID:          true,
+		consts.ContextRequestURITemplate: true,
+		consts.ContextService:            true,
+		consts.ContextLogLevel:           true,
+		consts.ContextTimeStamp:          true,
+		consts.ContextRequestMethod:      true,
+		consts.ContextRequestStatus:      true,
+		consts.ContextRequestTimetaken:   true,
+		consts.ContextTraceID:            true,
+		consts.ContextSpanID:             true,
+		consts.ContextParentSpanID:       true,
+		"func":                           true,
+		"file":                           true,
+		"line":                           true,
+		"error_type":                     true,
+		"error_stack":                    true,
+	}
+)
+
+// RedactOptions configures the masking of secrets and personal data before an
+// entry reaches the sinks. The lists are added to the defaults.
+type RedactOptions struct {
+	// Disable turns redaction off
+	Disable bool
+
+	// Headers are masked in request and response dumps, matched case
+	// insensitively
+	Headers []string
+
+	// Keys are JSON keys masked at any depth of the dumped bodies and of the
+	// log fields, matched case insensitively
+	Keys []string
+
+	// Paths are dotted JSON paths masked in dumped bodies, "*" matches any key
+	// or array index, e.g. "payment.payment_gateways.*.email"
+	Paths []string
+
+	// Patterns are masked in messages and string values
+	Patterns []*regexp.Regexp
+
+	// KeepEmails, KeepPhones and KeepCards turn off the built-in patterns for
+	// email addresses, international phone numbers and card numbers
+	KeepEmails bool
+	KeepPhones bool
+	KeepCards  bool
+
+	// Mask replaces the redacted values, it defaults to DefaultRedactMask
+	Mask string
+}
+
+// redactor applies the redaction options to the log fields
+type redactor struct {
+	mask     string
+	headers  map[string]bool
+	keys     map[string]bool
+	paths    [][]string
+	patterns []*regexp.Regexp
+	cards    bool
+}
+
+// newRedactor compiles the options, nil options use the defaults
+func newRedactor(opt *RedactOptions) *redactor {
+	if opt == nil {
+		opt = &RedactOptions{}
+	}
+	if opt.Disable {
+		return nil
+	}
+	r := &redactor{
+		mask:    opt.Mask,
+		headers: map[string]bool{},
+		keys:    map[string]bool{},
+		cards:   !opt.KeepCards,
+	}
+	if r.mask == "" {
+		r.mask = DefaultRedactMask
+	}
+	for _, header := range append(append([]string{}, DefaultRedactHeaders...), opt.Headers...) {
+		r.headers[http.CanonicalHeaderKey(header)] = true
+	}
+	for _, key := range append(append([]string{}, DefaultRedactKeys...), opt.Keys...) {
+		r.keys[strings.ToLower(key)] = true
+	}
+	for _, path := range append(append([]string{}, DefaultRedactPaths...), opt.Paths...) {
+		r.paths = append(r.paths, strings.Split(path, "."))
+	}
+	if !opt.KeepEmails {
+		r.patterns = append(r.patterns, emailPattern)
+	}
+	if !opt.KeepPhones {
+		r.patterns = append(r.patterns, phonePattern)
+	}
+	r.patterns = append(r.patterns, opt.Patterns...)
+	return r
+}
+
+// fields masks the log fields in place. The values are replaced, never
+// modified, so maps shared with the caller stay untouched.
+func (r *redactor) fields(fields logrus.Fields) {
+	if r == nil {
+		return
+	}
+	for key, value := range fields {
+		if unredactedFields[key] {
+			continue
+		}
+		if r.keys[strings.ToLower(key)] {
+			fields[key] = r.mask
+			continue
+		}
+		fields[key] = r.value(value, nil)
+	}
+}
+
+// value returns a masked copy of a field value
+func (r *redactor) value(value interface{}, path []string) interface{} {
+	switch v := value.(type) {
+	case string:
+		return r.text(v)
+	case utils.RequestDataDump:
+		return utils.RequestDataDump{
+			Headers: r.header(v.Headers),
+			Body:    r.body(v.Body),
+		}
+	case *utils.RequestDataDump:
+		if v == nil {
+			return v
+		}
+		return r.value(*v, path)
+	case utils.ResponseData:
+		return utils.ResponseData{
+			StatusCode: v.StatusCode,
+			Headers:    r.header(v.Headers),
+			Body:       r.body(v.Body),
+		}
+	case []string:
+		masked := make([]string, len(v))
+		for i, item := range v {
+			masked[i] = r.text(item)
+		}
+		return masked
+	case http.Header:
+		return r.header(v)
+	case map[string][]string:
+		return r.header(v)
+	case map[string]interface{}:
+		masked := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			itemPath := append(append([]string{}, path...), key)
+			if r.keys[strings.ToLower(key)] || r.matchPath(itemPath) {
+				masked[key] = r.mask
+				continue
+			}
+			masked[key] = r.value(item, itemPath)
+		}
+		return masked
+	case []interface{}:
+		masked := make([]interface{}, len(v))
+		for i, item := range v {
+			itemPath := append(append([]string{}, path...), "*")
+			if r.matchPath(itemPath) {
+				masked[i] = r.mask
+				continue
+			}
+			masked[i] = r.value(item, itemPath)
+		}
+		return masked
+	default:
+		return value
+	}
+}
+
+// header returns a copy of the headers with the sensitive ones masked
+func (r *redactor) header(headers map[string][]string) map[string][]string {
+	if headers == nil {
+		return nil
+	}
+	masked := make(map[string][]string, len(headers))
+	for key, values := range headers {
+		if r.headers[http.CanonicalHeaderKey(key)] || r.keys[strings.ToLower(key)] {
+			masked[key] = []string{r.mask}
+			continue
+		}
+		copied := make([]string, len(values))
+		for i, value := range values {
+			copied[i] = r.text(value)
+		}
+		masked[key] = copied
+	}
+	return masked
+}
+
+// body masks a dumped body. JSON bodies are masked by key and path, other
+// bodies are scanned with the patterns only.
+func (r *redactor) body(body string) string {
+	if body == "" {
+		return body
+	}
+	var decoded interface{}
+	if err := json.Unmarshal([]byte(body), &decoded); err != nil {
+		return r.text(body)
+	}
+	encoded, err := json.Marshal(r.value(decoded, nil))
+	if err != nil {
+		return r.mask
+	}
+	return string(encoded)
+}
+
+// text masks the patterns found in a string
+func (r *redactor) text(text string) string {
+	for _, pattern := range r.patterns {
+		text = pattern.ReplaceAllString(text, r.mask)
+	}
+	if r.cards {
+		text = cardPattern.ReplaceAllStringFunc(text, func(match string) string {
+			if luhn(match) {
+				return r.mask
+			}
+			return match
+		})
+	}
+	return text
+}
+
+// matchPath reports whether a JSON path is configured to be masked
+func (r *redactor) matchPath(path []string) bool {
+	for _, pattern := range r.paths {
+		if len(pattern) != len(path) {
+			continue
+		}
+		matched := true
+		for i := range pattern {
+			if pattern[i] != "*" && !strings.EqualFold(pattern[i], path[i]) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+// luhn validates the check digit of a card like number
+func luhn(number string) bool {
+	sum, double, digits := 0, false, 0
+	for i := len(number) - 1; i >= 0; i-- {
+		c := number[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+		digits++
+	}
+	return digits >= 13 && sum%10 == 0
+}
@@ -0,0 +1,86 @@
+package logger_test
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gitlab.com/tuneverse/toolkit/consts"
+	"gitlab.com/tuneverse/toolkit/core/logger"
+	"gitlab.com/tuneverse/toolkit/utils"
+)
+
+func TestRedact(t *testing.T) {
+	sink := &fieldSink{}
+	logger.InitLogger(&logger.ClientOptions{
+		Service:  "service",
+		LogLevel: "info",
+		Redact: &logger.RedactOptions{
+			Keys:     []string{"member_pin"},
+			Patterns: []*regexp.Regexp{regexp.MustCompile(`ref-\d+`)},
+		},
+	}, sink)
+
+	t.Run("request dump", func(t *testing.T) {
+		sink.reset()
+		headers := map[string][]string{
+			"Authorization": {"Bearer abc"},
+			"Content-Type":  {"application/json"},
+		}
+		logger.Log().With(consts.ContextRequestDump, &utils.RequestDataDump{
+			Headers: headers,
+			Body: `{"name":"partner","oauth_credential":{"client_id":"id","client_secret":"s3cret"},` +
+				`"payment":{"payment_gateways":[{"gateway":"paypal","email":"pay@example.com","client_id":"pp-id","client_secret":"pp-secret"}]}}`,
+		}).Info("request")
+
+		dump := sink.all()[0][consts.ContextRequestDump].(utils.RequestDataDump)
+		require.Equal(t, []string{logger.DefaultRedactMask}, dump.Headers["Authorization"])
+		require.Equal(t, []string{"application/json"}, dump.Headers["Content-Type"])
+		require.Equal(t, []string{"Bearer abc"}, headers["Authorization"], "caller headers are not modified")
+		require.JSONEq(t, `{"name":"partner","oauth_credential":{"client_id":"id","client_secret":"[REDACTED]"},`+
+			`"payment":{"payment_gateways":[{"gateway":"paypal","email":"[REDACTED]","client_id":"[REDACTED]","client_secret":"[REDACTED]"}]}}`,
+			dump.Body)
+	})
+
+	t.Run("response dump", func(t *testing.T) {
+		sink.reset()
+		logger.Log().With("response_dump", utils.ResponseData{
+			StatusCode: 200,
+			Headers:    map[string][]string{"Set-Cookie": {"session=1"}},
+			Body:       `{"data":{"access_token":"tok","member_pin":"1234"}}`,
+		}).Info("response")
+
+		dump := sink.all()[0]["response_dump"].(utils.ResponseData)
+		require.Equal(t, 200, dump.StatusCode)
+		require.Equal(t, []string{logger.DefaultRedactMask}, dump.Headers["Set-Cookie"])
+		require.JSONEq(t, `{"data":{"access_token":"[REDACTED]","member_pin":"[REDACTED]"}}`, dump.Body)
+	})
+
+	t.Run("messages and fields", func(t *testing.T) {
+		sink.reset()
+		logger.Log().
+			With("password", "hunter2").
+			With("contact", "call +44 20 7946 0958").
+			With(consts.ContextRequestID, "4111111111111111").
+			Infof("payment by %s with card 4111 1111 1111 1111 order 1234567890123 %s", "john@example.com", "ref-42")
+
+		entry := sink.all()[0]
+		require.Equal(t, "payment by [REDACTED] with card [REDACTED] order 1234567890123 [REDACTED]", entry[consts.ContextMessage])
+		require.Equal(t, logger.DefaultRedactMask, entry["password"])
+		require.Equal(t, "call [REDACTED]", entry["contact"])
+		require.Equal(t, "4111111111111111", entry[consts.ContextRequestID])
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		sink := &fieldSink{}
+		logger.InitLogger(&logger.ClientOptions{
+			Service:  "service",
+			LogLevel: "info",
+			Redact:   &logger.RedactOptions{Disable: true},
+		}, sink)
+
+		logger.Log().With("password", "hunter2").Info("john@example.com")
+		require.Equal(t, "hunter2", sink.all()[0]["password"])
+		require.Equal(t, "john@example.com", sink.all()[0][consts.ContextMessage])
+	})
+}
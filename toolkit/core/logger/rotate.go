@@ -0,0 +1,365 @@
+package logger
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Rotation decides when FileMode starts a new log file. The file is rotated
+// as well when it grows over FileMode.LogMaxSize.
+type Rotation int
+
+const (
+	// RotateDaily starts a new file at midnight UTC
+	RotateDaily Rotation = iota
+	// RotateHourly starts a new file every hour
+	RotateHourly
+	// RotateSize rotates on size only
+	RotateSize
+)
+
+// compressExt is appended to the compressed backups
+const compressExt = ".gz"
+
+// stamp returns the layout naming the backups of the rotation. Daily and
+// hourly backups are named after the period they cover, size backups after
+// the time the file was opened.
+func (r Rotation) stamp() string {
+	switch r {
+	case RotateHourly:
+		return "2006-01-02T15"
+	case RotateSize:
+		return "2006-01-02T15-04-05"
+	default:
+		return "2006-01-02"
+	}
+}
+
+// period returns the start of the period t belongs to
+func (r Rotation) period(t time.Time) time.Time {
+	t = t.UTC()
+	switch r {
+	case RotateHourly:
+		return t.Truncate(time.Hour)
+	case RotateSize:
+		return t
+	default:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	}
+}
+
+// rotatingFile writes to <dir>/<base><ext> and moves it aside as
+// <base>-<stamp><ext> when the period ends or the size limit is reached. A
+// second backup of the same period gets a counter, <base>-<stamp>.1<ext>.
+// The backups are compressed and pruned by a background goroutine.
+type rotatingFile struct {
+	dir          string
+	base         string
+	ext          string
+	rotation     Rotation
+	maxSize      int64
+	maxBackups   int
+	maxAge       time.Duration
+	maxTotalSize int64
+	compress     bool
+	now          func() time.Time
+
+	mu     sync.Mutex
+	file   *os.File
+	size   int64
+	period time.Time
+	closed bool
+
+	// housekeeping wakes the goroutine compressing and pruning the backups
+	housekeeping chan struct{}
+	done         sync.WaitGroup
+}
+
+// newRotatingFile creates the writer and starts its housekeeping. The file
+// is opened on the first write.
+func newRotatingFile(file *FileMode, name string) *rotatingFile {
+	ext := filepath.Ext(name)
+	rf := &rotatingFile{
+		dir:          file.LogPath,
+		base:         strings.TrimSuffix(name, ext),
+		ext:          ext,
+		rotation:     file.Rotation,
+		maxSize:      file.LogMaxSize,
+		maxBackups:   file.LogMaxBackup,
+		maxAge:       time.Duration(file.LogMaxAge) * 24 * time.Hour,
+		maxTotalSize: file.LogMaxTotalSize,
+		compress:     !file.DisableCompression,
+		now:          time.Now,
+		housekeeping: make(chan struct{}, 1),
+	}
+	rf.done.Add(1)
+	go rf.keep()
+	// backups left by a previous run are compressed and pruned as well
+	rf.wake()
+	return rf
+}
+
+// Write appends p to the log file, rotating it first when it is due
+func (rf *rotatingFile) Write(p []byte) (int, error) {
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+	if rf.closed {
+		return 0, os.ErrClosed
+	}
+
+	now := rf.now()
+	if rf.file == nil {
+		if err := rf.open(now); err != nil {
+			return 0, err
+		}
+	}
+	if rf.due(now, int64(len(p))) {
+		if err := rf.rotate(now); err != nil {
+			return 0, err
+		}
+	}
+	n, err := rf.file.Write(p)
+	rf.size += int64(n)
+	return n, err
+}
+
+// Close closes the log file and waits for the housekeeping to finish
+func (rf *rotatingFile) Close() error {
+	rf.mu.Lock()
+	if rf.closed {
+		rf.mu.Unlock()
+		return nil
+	}
+	rf.closed = true
+	var err error
+	if rf.file != nil {
+		err = rf.file.Close()
+		rf.file = nil
+	}
+	rf.mu.Unlock()
+
+	close(rf.housekeeping)
+	rf.done.Wait()
+	return err
+}
+
+// due reports whether the file must be rotated before writing size bytes,
+// rf.mu must be held
+func (rf *rotatingFile) due(now time.Time, size int64) bool {
+	if rf.size == 0 {
+		// an empty file only changes its period
+		rf.period = rf.rotation.period(now)
+		return false
+	}
+	if rf.rotation != RotateSize && !rf.rotation.period(now).Equal(rf.period) {
+		return true
+	}
+	return rf.maxSize > 0 && rf.size+size > rf.maxSize
+}
+
+// open opens the log file for appending. The file left by a previous run
+// keeps the period of its last write, so it is rotated under the right name.
+func (rf *rotatingFile) open(now time.Time) error {
+	if err := os.MkdirAll(rf.dir, os.ModePerm); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(rf.path(), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	rf.file = file
+	rf.size = info.Size()
+	rf.period = rf.rotation.period(now)
+	if rf.size > 0 {
+		rf.period = rf.rotation.period(info.ModTime())
+	}
+	return nil
+}
+
+// rotate moves the log file aside and opens a new one, rf.mu must be held
+func (rf *rotatingFile) rotate(now time.Time) error {
+	if err := rf.file.Close(); err != nil {
+		return err
+	}
+	rf.file = nil
+	if err := os.Rename(rf.path(), rf.backupPath()); err != nil {
+		return err
+	}
+	if err := rf.open(now); err != nil {
+		return err
+	}
+	rf.wake()
+	return nil
+}
+
+// path returns the path of the log file
+func (rf *rotatingFile) path() string {
+	return filepath.Join(rf.dir, rf.base+rf.ext)
+}
+
+// backupPath returns the first free backup name of the current period
+func (rf *rotatingFile) backupPath() string {
+	name := rf.base + "-" + rf.period.Format(rf.rotation.stamp())
+	for i := 0; ; i++ {
+		candidate := name
+		if i > 0 {
+			candidate += "." + strconv.Itoa(i)
+		}
+		candidate = filepath.Join(rf.dir, candidate+rf.ext)
+		if !exists(candidate) && !exists(candidate+compressExt) {
+			return candidate
+		}
+	}
+}
+
+// wake schedules the housekeeping without blocking
+func (rf *rotatingFile) wake() {
+	select {
+	case rf.housekeeping <- struct{}{}:
+	default:
+	}
+}
+
+// keep compresses and prunes the backups every time it is woken up
+func (rf *rotatingFile) keep() {
+	defer rf.done.Done()
+	for range rf.housekeeping {
+		if rf.compress {
+			for _, backup := range rf.backups() {
+				if strings.HasSuffix(backup.path, compressExt) {
+					continue
+				}
+				// a failed compression is tried again on the next rotation
+				_ = compressFile(backup.path, backup.modTime)
+			}
+		}
+		rf.prune()
+	}
+}
+
+// backup is a rotated log file
+type backup struct {
+	path    string
+	size    int64
+	modTime time.Time
+}
+
+// backups returns the rotated files, newest first
+func (rf *rotatingFile) backups() []backup {
+	entries, err := os.ReadDir(rf.dir)
+	if err != nil {
+		return nil
+	}
+	var backups []backup
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !rf.isBackup(name) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		backups = append(backups, backup{
+			path:    filepath.Join(rf.dir, name),
+			size:    info.Size(),
+			modTime: info.ModTime(),
+		})
+	}
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].modTime.After(backups[j].modTime)
+	})
+	return backups
+}
+
+// isBackup reports whether name is a backup of the log file. The stamp is
+// checked against every rotation, so the backups of a previous setting are
+// recognised as well.
+func (rf *rotatingFile) isBackup(name string) bool {
+	name = strings.TrimSuffix(name, compressExt)
+	if !strings.HasPrefix(name, rf.base+"-") || !strings.HasSuffix(name, rf.ext) {
+		return false
+	}
+	stamp := strings.TrimSuffix(strings.TrimPrefix(name, rf.base+"-"), rf.ext)
+	if dot := strings.LastIndex(stamp, "."); dot > 0 {
+		if _, err := strconv.Atoi(stamp[dot+1:]); err == nil {
+			stamp = stamp[:dot]
+		}
+	}
+	for _, rotation := range []Rotation{RotateDaily, RotateHourly, RotateSize} {
+		if _, err := time.Parse(rotation.stamp(), stamp); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// prune removes the backups over the count, older than the maximum age or
+// pushing the directory over the total size, oldest first
+func (rf *rotatingFile) prune() {
+	rf.mu.Lock()
+	total := rf.size
+	rf.mu.Unlock()
+
+	cutoff := rf.now().Add(-rf.maxAge)
+	for i, backup := range rf.backups() {
+		total += backup.size
+		expired := rf.maxAge > 0 && backup.modTime.Before(cutoff)
+		tooMany := rf.maxBackups > 0 && i >= rf.maxBackups
+		tooBig := rf.maxTotalSize > 0 && total > rf.maxTotalSize
+		if expired || tooMany || tooBig {
+			_ = os.Remove(backup.path)
+		}
+	}
+}
+
+// compressFile gzips a backup next to it and removes the original. The
+// compressed file keeps the modification time so the backups stay ordered.
+func compressFile(path string, modTime time.Time) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	tmp := path + compressExt + ".tmp"
+	dst, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	zw := gzip.NewWriter(dst)
+	_, err = io.Copy(zw, src)
+	if closeErr := zw.Close(); err == nil {
+		err = closeErr
+	}
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp, path+compressExt)
+	}
+	if err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	_ = os.Chtimes(path+compressExt, modTime, modTime)
+	return os.Remove(path)
+}
+
+// exists reports whether a file exists
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
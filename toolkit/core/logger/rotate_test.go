@@ -0,0 +1,159 @@
+package logger
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// clock is a settable time source for the rotating file
+type clock struct {
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	return c.now
+}
+
+// newTestFile returns a rotating file in a temporary directory driven by a
+// fake clock
+func newTestFile(t *testing.T, file *FileMode) (*rotatingFile, *clock) {
+	file.LogPath = t.TempDir()
+	c := &clock{now: time.Date(2024, 5, 1, 10, 30, 0, 0, time.UTC)}
+	rf := newRotatingFile(file, "partner.log")
+	rf.now = c.Now
+	t.Cleanup(func() { rf.Close() })
+	return rf, c
+}
+
+// files lists the directory once the housekeeping is done
+func files(t *testing.T, rf *rotatingFile) []string {
+	t.Helper()
+	require.NoError(t, rf.Close())
+	entries, err := os.ReadDir(rf.dir)
+	require.NoError(t, err)
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	sort.Strings(names)
+	return names
+}
+
+// gunzip returns the content of a compressed backup
+func gunzip(t *testing.T, path string) string {
+	t.Helper()
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	require.NoError(t, err)
+	data, err := io.ReadAll(zr)
+	require.NoError(t, err)
+	return string(data)
+}
+
+func TestRotatingFile(t *testing.T) {
+	t.Run("daily rotation with compression", func(t *testing.T) {
+		rf, c := newTestFile(t, &FileMode{})
+		_, err := rf.Write([]byte("first day\n"))
+		require.NoError(t, err)
+		c.now = c.now.Add(24 * time.Hour)
+		_, err = rf.Write([]byte("second day\n"))
+		require.NoError(t, err)
+
+		require.Equal(t, []string{"partner-2024-05-01.log.gz", "partner.log"}, files(t, rf))
+		require.Equal(t, "first day\n", gunzip(t, filepath.Join(rf.dir, "partner-2024-05-01.log.gz")))
+		active, err := os.ReadFile(filepath.Join(rf.dir, "partner.log"))
+		require.NoError(t, err)
+		require.Equal(t, "second day\n", string(active))
+	})
+
+	t.Run("hourly rotation without compression", func(t *testing.T) {
+		rf, c := newTestFile(t, &FileMode{Rotation: RotateHourly, DisableCompression: true})
+		for i := 0; i < 3; i++ {
+			_, err := rf.Write([]byte("entry\n"))
+			require.NoError(t, err)
+			c.now = c.now.Add(time.Hour)
+		}
+		require.Equal(t, []string{
+			"partner-2024-05-01T10.log",
+			"partner-2024-05-01T11.log",
+			"partner.log",
+		}, files(t, rf))
+	})
+
+	t.Run("size rotation within a period gets a counter", func(t *testing.T) {
+		rf, _ := newTestFile(t, &FileMode{LogMaxSize: 10, DisableCompression: true})
+		for i := 0; i < 3; i++ {
+			_, err := rf.Write([]byte("0123456789"))
+			require.NoError(t, err)
+		}
+		require.Equal(t, []string{
+			"partner-2024-05-01.1.log",
+			"partner-2024-05-01.log",
+			"partner.log",
+		}, files(t, rf))
+	})
+
+	t.Run("backup count and total size caps", func(t *testing.T) {
+		rf, c := newTestFile(t, &FileMode{LogMaxBackup: 2, LogMaxTotalSize: 25, DisableCompression: true})
+		for i := 0; i < 5; i++ {
+			_, err := rf.Write([]byte("0123456789"))
+			require.NoError(t, err)
+			c.now = c.now.Add(24 * time.Hour)
+			// the backups are ordered by modification time
+			require.NoError(t, os.Chtimes(rf.path(), c.now, c.now))
+		}
+		// the active file and one backup fit in 25 bytes
+		require.Equal(t, []string{"partner-2024-05-04.log", "partner.log"}, files(t, rf))
+	})
+
+	t.Run("a restart rotates the file of a previous period", func(t *testing.T) {
+		dir := t.TempDir()
+		yesterday := time.Date(2024, 4, 30, 22, 0, 0, 0, time.UTC)
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "partner.log"), []byte("old run\n"), 0o644))
+		require.NoError(t, os.Chtimes(filepath.Join(dir, "partner.log"), yesterday, yesterday))
+
+		rf := newRotatingFile(&FileMode{LogPath: dir}, "partner.log")
+		rf.now = func() time.Time { return yesterday.Add(4 * time.Hour) }
+		_, err := rf.Write([]byte("new run\n"))
+		require.NoError(t, err)
+		require.Equal(t, []string{"partner-2024-04-30.log.gz", "partner.log"}, files(t, rf))
+	})
+
+	t.Run("other files are left alone", func(t *testing.T) {
+		rf, c := newTestFile(t, &FileMode{LogMaxBackup: 1})
+		require.NoError(t, os.WriteFile(filepath.Join(rf.dir, "partner-api.log"), []byte("api\n"), 0o644))
+		for i := 0; i < 3; i++ {
+			_, err := rf.Write([]byte("entry\n"))
+			require.NoError(t, err)
+			c.now = c.now.Add(24 * time.Hour)
+		}
+		names := files(t, rf)
+		require.Contains(t, names, "partner-api.log")
+		require.Len(t, names, 3)
+	})
+
+	t.Run("write after close", func(t *testing.T) {
+		rf, _ := newTestFile(t, &FileMode{})
+		require.NoError(t, rf.Close())
+		_, err := rf.Write([]byte("late\n"))
+		require.ErrorIs(t, err, os.ErrClosed)
+	})
+}
+
+func TestFileModeName(t *testing.T) {
+	file := &FileMode{LogPath: t.TempDir()}
+	require.NoError(t, file.Init(SinkConfig{Service: "billing"}))
+	defer file.writer.Writer.(io.Closer).Close()
+	require.Equal(t, "billing.log", file.LogfileName)
+	require.True(t, strings.HasSuffix(file.writer.Writer.(*rotatingFile).path(), "billing.log"))
+}
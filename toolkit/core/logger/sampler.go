@@ -0,0 +1,180 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gitlab.com/tuneverse/toolkit/consts"
+)
+
+const (
+	// DefaultSamplingInterval is the window the occurrences are counted in
+	DefaultSamplingInterval = time.Second
+	// DefaultSamplingFirst is the number of occurrences written per window
+	DefaultSamplingFirst = 100
+	// DefaultSamplingThereafter writes one occurrence out of it once the
+	// first ones are written
+	DefaultSamplingThereafter = 100
+)
+
+// SamplingOptions bounds the number of identical entries written. The
+// entries are counted per message template and level in each interval: the
+// First ones are written, then one out of Thereafter. The number of dropped
+// entries is logged at the end of the interval as "suppressed N similar
+// messages". Fatal and panic entries are always written.
+type SamplingOptions struct {
+	// Interval defaults to DefaultSamplingInterval
+	Interval time.Duration
+
+	// First defaults to DefaultSamplingFirst
+	First int
+
+	// Thereafter defaults to DefaultSamplingThereafter, a negative value
+	// drops every entry past the first ones
+	Thereafter int
+}
+
+// sampleKey identifies similar entries
+type sampleKey struct {
+	level    logrus.Level
+	template string
+}
+
+// sampleCount counts the entries of a key in the current interval
+type sampleCount struct {
+	seen       int
+	suppressed int
+}
+
+// sampler decides which entries are written and reports the dropped ones
+type sampler struct {
+	first      int
+	thereafter int
+
+	mu     sync.Mutex
+	counts map[sampleKey]*sampleCount
+
+	// report logs the summary of a key
+	report   func(key sampleKey, suppressed int)
+	stop     chan struct{}
+	stopOnce sync.Once
+	done     chan struct{}
+}
+
+// newSampler starts a sampler resetting its counts every interval
+func newSampler(opt *SamplingOptions, report func(key sampleKey, suppressed int)) *sampler {
+	s := &sampler{
+		first:      opt.First,
+		thereafter: opt.Thereafter,
+		counts:     map[sampleKey]*sampleCount{},
+		report:     report,
+		stop:       make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+	if s.first <= 0 {
+		s.first = DefaultSamplingFirst
+	}
+	if s.thereafter == 0 {
+		s.thereafter = DefaultSamplingThereafter
+	}
+	interval := opt.Interval
+	if interval <= 0 {
+		interval = DefaultSamplingInterval
+	}
+	go s.run(interval)
+	return s
+}
+
+// allow reports whether the entry is written, the dropped ones are counted
+func (s *sampler) allow(level logrus.Level, template string) bool {
+	if level <= logrus.FatalLevel {
+		return true
+	}
+	key := sampleKey{level: level, template: template}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	count, ok := s.counts[key]
+	if !ok {
+		count = &sampleCount{}
+		s.counts[key] = count
+	}
+	count.seen++
+	if count.seen <= s.first {
+		return true
+	}
+	if s.thereafter > 0 && (count.seen-s.first)%s.thereafter == 0 {
+		return true
+	}
+	count.suppressed++
+	return false
+}
+
+// run ends an interval on every tick until close
+func (s *sampler) run(interval time.Duration) {
+	defer close(s.done)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.flush()
+		case <-s.stop:
+			s.flush()
+			return
+		}
+	}
+}
+
+// flush resets the counts and reports the keys with dropped entries, in a
+// stable order
+func (s *sampler) flush() {
+	s.mu.Lock()
+	counts := s.counts
+	s.counts = make(map[sampleKey]*sampleCount, len(counts))
+	s.mu.Unlock()
+
+	keys := make([]sampleKey, 0, len(counts))
+	for key, count := range counts {
+		if count.suppressed > 0 {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].level != keys[j].level {
+			return keys[i].level < keys[j].level
+		}
+		return keys[i].template < keys[j].template
+	})
+	for _, key := range keys {
+		s.report(key, counts[key].suppressed)
+	}
+}
+
+// close stops the sampler once the pending summaries are reported
+func (s *sampler) close(ctx context.Context) error {
+	s.stopOnce.Do(func() { close(s.stop) })
+	select {
+	case <-s.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// reportSuppressed logs the summary of the entries dropped by the sampler
+func (c *core) reportSuppressed(key sampleKey, suppressed int) {
+	template := key.template
+	if c.redaction != nil {
+		template = c.redaction.text(template)
+	}
+	message := fmt.Sprintf("suppressed %d similar messages", suppressed)
+	c.logrus.WithFields(logrus.Fields{
+		consts.ContextMessage:        message,
+		consts.ContextSampledMessage: template,
+		consts.ContextSuppressed:     suppressed,
+	}).Log(key.level, message)
+}
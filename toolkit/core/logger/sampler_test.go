@@ -0,0 +1,93 @@
+package logger_test
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"gitlab.com/tuneverse/toolkit/consts"
+	"gitlab.com/tuneverse/toolkit/core/logger"
+)
+
+func TestSampling(t *testing.T) {
+	newLogger := func(t *testing.T, sampling *logger.SamplingOptions) (*logger.Logger, *fieldSink) {
+		sink := &fieldSink{}
+		log, err := logger.New(&logger.ClientOptions{
+			Service:  "service",
+			LogLevel: "debug",
+			Sampling: sampling,
+		}, &logger.ConsoleMode{Output: io.Discard}, sink)
+		require.NoError(t, err)
+		return log, sink
+	}
+	messages := func(entries []map[string]interface{}) []interface{} {
+		var messages []interface{}
+		for _, entry := range entries {
+			messages = append(messages, entry[consts.ContextMessage])
+		}
+		return messages
+	}
+
+	t.Run("first entries then one out of thereafter", func(t *testing.T) {
+		log, sink := newLogger(t, &logger.SamplingOptions{Interval: time.Hour, First: 2, Thereafter: 3})
+		for i := 0; i < 10; i++ {
+			log.Errorf("partner %d: store update failed", i)
+		}
+		require.Equal(t, []interface{}{
+			"partner 0: store update failed",
+			"partner 1: store update failed",
+			"partner 4: store update failed",
+			"partner 7: store update failed",
+		}, messages(sink.all()))
+
+		// the summary is logged at the end of the interval, or on close
+		require.NoError(t, log.Close(context.Background()))
+		entries := sink.all()
+		require.Len(t, entries, 5)
+		summary := entries[4]
+		require.Equal(t, "suppressed 6 similar messages", summary[consts.ContextMessage])
+		require.Equal(t, "partner %d: store update failed", summary[consts.ContextSampledMessage])
+		require.Equal(t, 6, summary[consts.ContextSuppressed])
+	})
+
+	t.Run("keys are messages and levels", func(t *testing.T) {
+		log, sink := newLogger(t, &logger.SamplingOptions{Interval: time.Hour, First: 1, Thereafter: -1})
+		for i := 0; i < 3; i++ {
+			log.Info("cache miss")
+			log.Warn("cache miss")
+			log.Info("cache hit")
+		}
+		require.Equal(t, []interface{}{"cache miss", "cache miss", "cache hit"}, messages(sink.all()))
+
+		require.NoError(t, log.Close(context.Background()))
+		require.Equal(t, []interface{}{
+			"suppressed 2 similar messages",
+			"suppressed 2 similar messages",
+			"suppressed 2 similar messages",
+		}, messages(sink.all()[3:]))
+	})
+
+	t.Run("counts restart every interval", func(t *testing.T) {
+		log, sink := newLogger(t, &logger.SamplingOptions{Interval: 20 * time.Millisecond, First: 1, Thereafter: -1})
+		defer log.Close(context.Background())
+		log.Info("polling")
+		log.Info("polling")
+		require.Eventually(t, func() bool {
+			return len(sink.all()) == 2
+		}, time.Second, 5*time.Millisecond)
+		require.Equal(t, "suppressed 1 similar messages", sink.all()[1][consts.ContextMessage])
+
+		log.Info("polling")
+		require.Equal(t, "polling", sink.all()[2][consts.ContextMessage])
+	})
+
+	t.Run("disabled by default", func(t *testing.T) {
+		log, sink := newLogger(t, nil)
+		for i := 0; i < 200; i++ {
+			log.Debug("tick")
+		}
+		require.Len(t, sink.all(), 200)
+	})
+}
@@ -0,0 +1,204 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+	"gitlab.com/tuneverse/toolkit/consts"
+)
+
+// Sink is an output of the logger. Every entry at or above the sink level is
+// handed to Write. FileMode, CloudMode and ConsoleMode are sinks, services can
+// plug in their own (syslog, a local TCP/UDP collector, ...) by implementing
+// this interface and passing the value to InitLogger.
+type Sink interface {
+	// Init prepares the sink. It is called once from InitLogger before the
+	// first entry is written.
+	Init(cfg SinkConfig) error
+
+	// Level returns the minimum level accepted by the sink.
+	Level() logrus.Level
+
+	// Write emits a single entry. It can be called from several goroutines.
+	Write(entry *logrus.Entry) error
+}
+
+// Flusher is implemented by the sinks buffering entries. Flush returns once
+// the entries written before the call reached their destination, or with the
+// error of ctx when it is done first.
+type Flusher interface {
+	Flush(ctx context.Context) error
+}
+
+// Closer is implemented by the sinks holding files, connections or
+// goroutines. Close flushes the sink and releases them, nothing is written
+// after it returns.
+type Closer interface {
+	Close(ctx context.Context) error
+}
+
+// SinkConfig carries the logger wide settings a sink falls back to
+type SinkConfig struct {
+	// Service is the name of the application being logged
+	Service string
+
+	// Level is the logger level, initialised from ClientOptions.LogLevel and
+	// adjustable at runtime
+	Level *AtomicLevel
+
+	// Formatter is the formatter selected through ClientOptions
+	Formatter logrus.Formatter
+}
+
+// SinkOptions holds the settings shared by every sink. Embed it in a custom
+// sink and call Apply from Init to get a per sink level and formatter.
+type SinkOptions struct {
+	// LogLevel is the minimum level written by the sink. Possible values are
+	// trace, debug, info, warn, error, fatal, panic. The logger level is
+	// applied first, so the sink level can only restrict it further. By
+	// default the sink writes every entry passing the logger level.
+	LogLevel string
+
+	// Encoding selects the output format of the sink: text, json, logfmt,
+	// ecs or gelf. It is ignored when Formatter is set.
+	Encoding Encoding
+
+	// Formatter renders the entries of the sink. It defaults to the format
+	// selected in ClientOptions.
+	Formatter logrus.Formatter
+
+	level logrus.Level
+}
+
+// Apply resolves the level and the formatter against the logger defaults
+func (opt *SinkOptions) Apply(cfg SinkConfig) error {
+	opt.level = logrus.TraceLevel
+	if opt.LogLevel != "" {
+		level, err := logrus.ParseLevel(opt.LogLevel)
+		if err != nil {
+			return err
+		}
+		opt.level = level
+	}
+	if opt.Formatter == nil && opt.Encoding != "" {
+		formatter, err := NewFormatter(opt.Encoding, cfg.Service)
+		if err != nil {
+			return err
+		}
+		opt.Formatter = formatter
+	}
+	if opt.Formatter == nil {
+		opt.Formatter = cfg.Formatter
+	}
+	return nil
+}
+
+// Level returns the minimum level accepted by the sink
+func (opt *SinkOptions) Level() logrus.Level {
+	return opt.level
+}
+
+// Format renders an entry with the sink formatter
+func (opt *SinkOptions) Format(entry *logrus.Entry) ([]byte, error) {
+	return opt.Formatter.Format(entry)
+}
+
+// WriterSink writes formatted entries to any io.Writer. It is the building
+// block for custom outputs such as a syslog writer or a net.Conn.
+type WriterSink struct {
+	SinkOptions
+
+	// Writer receives the formatted entries
+	Writer io.Writer
+
+	mu sync.Mutex
+}
+
+// Init validates the writer and resolves the sink options
+func (ws *WriterSink) Init(cfg SinkConfig) error {
+	if ws.Writer == nil {
+		return fmt.Errorf("writer sink requires a writer")
+	}
+	return ws.Apply(cfg)
+}
+
+// Write formats the entry and writes it to the underlying writer
+func (ws *WriterSink) Write(entry *logrus.Entry) error {
+	data, err := ws.Format(entry)
+	if err != nil {
+		return err
+	}
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	_, err = ws.Writer.Write(data)
+	return err
+}
+
+// ConsoleMode prints the logs on the standard output. It is added by
+// InitLogger when no ConsoleMode is passed explicitly.
+type ConsoleMode struct {
+	SinkOptions
+
+	// Output overrides the destination, it defaults to os.Stdout
+	Output io.Writer
+
+	writer WriterSink
+}
+
+// Init resolves the console options
+func (console *ConsoleMode) Init(cfg SinkConfig) error {
+	if err := console.Apply(cfg); err != nil {
+		return err
+	}
+	console.writer.SinkOptions = console.SinkOptions
+	console.writer.Writer = console.Output
+	if console.writer.Writer == nil {
+		console.writer.Writer = os.Stdout
+	}
+	return nil
+}
+
+// Write prints the entry on the console
+func (console *ConsoleMode) Write(entry *logrus.Entry) error {
+	return console.writer.Write(entry)
+}
+
+// sinkHook fans the logrus entries out to the registered sinks
+type sinkHook struct {
+	sinks []Sink
+}
+
+// Levels returns every level, the sinks filter on their own level
+func (hook *sinkHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire writes the entry to every sink accepting its level, the audit entries
+// to every sink
+func (hook *sinkHook) Fire(entry *logrus.Entry) error {
+	audit := entry.Data[consts.ContextAudit] == true
+	var errs []error
+	for _, sink := range hook.sinks {
+		if entry.Level > sink.Level() && !audit {
+			continue
+		}
+		if err := sink.Write(entry); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// discardFormatter skips formatting for the logrus output, the sinks do
+// their own formatting.
+type discardFormatter struct{}
+
+// Format returns no data
+func (discardFormatter) Format(*logrus.Entry) ([]byte, error) {
+	return nil, nil
+}
@@ -0,0 +1,74 @@
+package logger_test
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+	"gitlab.com/tuneverse/toolkit/core/logger"
+)
+
+// memorySink is a custom sink collecting the messages it receives
+type memorySink struct {
+	logger.SinkOptions
+	mu       sync.Mutex
+	messages []string
+}
+
+func (sink *memorySink) Init(cfg logger.SinkConfig) error {
+	return sink.Apply(cfg)
+}
+
+func (sink *memorySink) Write(entry *logrus.Entry) error {
+	sink.mu.Lock()
+	defer sink.mu.Unlock()
+	sink.messages = append(sink.messages, entry.Message)
+	return nil
+}
+
+func TestSinks(t *testing.T) {
+	t.Run("sinks filter on their own level", func(t *testing.T) {
+		var console bytes.Buffer
+		custom := &memorySink{}
+
+		logger.InitLogger(&logger.ClientOptions{
+			Service:  "service",
+			LogLevel: "debug",
+		}, &logger.ConsoleMode{
+			SinkOptions: logger.SinkOptions{LogLevel: "warn"},
+			Output:      &console,
+		}, custom)
+
+		logger.Log().Debug("debug message")
+		logger.Log().Warn("warn message")
+
+		require.Equal(t, []string{"debug message", "warn message"}, custom.messages)
+		require.NotContains(t, console.String(), "debug message")
+		require.Contains(t, console.String(), "warn message")
+	})
+
+	t.Run("each sink uses its own formatter", func(t *testing.T) {
+		var text, json bytes.Buffer
+
+		logger.InitLogger(&logger.ClientOptions{
+			Service:  "service",
+			LogLevel: "info",
+		}, &logger.ConsoleMode{Output: &text}, &logger.WriterSink{
+			SinkOptions: logger.SinkOptions{Formatter: &logrus.JSONFormatter{}},
+			Writer:      &json,
+		})
+
+		logger.Log().Info("formatted")
+
+		require.True(t, strings.HasPrefix(json.String(), "{"))
+		require.Contains(t, text.String(), "msg=formatted")
+	})
+
+	t.Run("writer sink requires a writer", func(t *testing.T) {
+		sink := &logger.WriterSink{}
+		require.Error(t, sink.Init(logger.SinkConfig{}))
+	})
+}
@@ -0,0 +1,127 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"runtime"
+
+	"github.com/sirupsen/logrus"
+)
+
+// SlogHandler is a slog.Handler writing through a Logger, so the records of
+// the code and the libraries logging with log/slog get the request fields,
+// the redaction and the sinks of the toolkit logger. Groups are flattened
+// into dotted field names, e.g. "db.table".
+type SlogHandler struct {
+	log    *Logger
+	fields map[string]interface{}
+	prefix string
+}
+
+// NewSlogHandler returns a handler writing through log. A handler built on
+// Log() follows the default logger and, for the records logged with a request
+// context, the logger LogMiddleware was given.
+//
+//	slog.SetDefault(slog.New(logger.NewSlogHandler(logger.Log())))
+func NewSlogHandler(log *Logger) *SlogHandler {
+	return &SlogHandler{
+		log:    log,
+		fields: map[string]interface{}{},
+	}
+}
+
+// Enabled reports whether the logger may write records of the level
+func (h *SlogHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	c := h.log.WithContext(ctx).resolve()
+	return !c.closed.Load() && c.levels.mayEnable(slogLevel(level))
+}
+
+// Handle writes the record with the fields of the handler and of ctx
+func (h *SlogHandler) Handle(ctx context.Context, record slog.Record) error {
+	log := h.log
+	if ctx != nil {
+		log = log.WithContext(ctx)
+	}
+	fields := log.entryFields()
+	for key, value := range h.fields {
+		fields[key] = value
+	}
+	record.Attrs(func(attr slog.Attr) bool {
+		addAttr(fields, h.prefix, attr)
+		return true
+	})
+	log.write(slogLevel(record.Level), fields, record.Message, record.Message, func() (runtime.Frame, bool) {
+		if record.PC == 0 {
+			return runtime.Frame{}, false
+		}
+		frame, _ := runtime.CallersFrames([]uintptr{record.PC}).Next()
+		return frame, true
+	})
+	return nil
+}
+
+// WithAttrs returns a handler adding the attributes to every record
+func (h *SlogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	fields := make(map[string]interface{}, len(h.fields)+len(attrs))
+	for key, value := range h.fields {
+		fields[key] = value
+	}
+	for _, attr := range attrs {
+		addAttr(fields, h.prefix, attr)
+	}
+	return &SlogHandler{
+		log:    h.log,
+		fields: fields,
+		prefix: h.prefix,
+	}
+}
+
+// WithGroup returns a handler nesting the next attributes under name
+func (h *SlogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &SlogHandler{
+		log:    h.log,
+		fields: h.fields,
+		prefix: h.prefix + name + ".",
+	}
+}
+
+// addAttr adds an attribute to the fields, groups are flattened
+func addAttr(fields map[string]interface{}, prefix string, attr slog.Attr) {
+	attr.Value = attr.Value.Resolve()
+	if attr.Equal(slog.Attr{}) {
+		return
+	}
+	if attr.Value.Kind() == slog.KindGroup {
+		if attr.Key != "" {
+			prefix += attr.Key + "."
+		}
+		for _, member := range attr.Value.Group() {
+			addAttr(fields, prefix, member)
+		}
+		return
+	}
+	value := attr.Value.Any()
+	if err, ok := value.(error); ok {
+		value = err.Error()
+	}
+	fields[prefix+attr.Key] = value
+}
+
+// slogLevel maps a slog level to the closest logrus level
+func slogLevel(level slog.Level) logrus.Level {
+	switch {
+	case level >= slog.LevelError:
+		return logrus.ErrorLevel
+	case level >= slog.LevelWarn:
+		return logrus.WarnLevel
+	case level >= slog.LevelInfo:
+		return logrus.InfoLevel
+	case level >= slog.LevelDebug:
+		return logrus.DebugLevel
+	default:
+		return logrus.TraceLevel
+	}
+}
@@ -0,0 +1,78 @@
+package logger_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	stdlog "log"
+	"log/slog"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gitlab.com/tuneverse/toolkit/consts"
+	"gitlab.com/tuneverse/toolkit/core/logger"
+)
+
+func TestSlogHandler(t *testing.T) {
+	sink := &fieldSink{}
+	log, err := logger.New(&logger.ClientOptions{
+		Service:  "service",
+		LogLevel: "info",
+	}, &logger.ConsoleMode{Output: io.Discard}, sink)
+	require.NoError(t, err)
+	slogger := slog.New(logger.NewSlogHandler(log))
+
+	t.Run("attributes, groups and context fields", func(t *testing.T) {
+		sink.reset()
+		ctx := context.WithValue(context.Background(), consts.LogData, map[string]interface{}{
+			consts.ContextRequestID: "req-1",
+		})
+		slogger.With("component", "cache").
+			WithGroup("db").
+			InfoContext(ctx, "query failed", "table", "partners", slog.Group("pool", "size", 4), "err", errors.New("timeout"))
+
+		entries := sink.all()
+		require.Len(t, entries, 1)
+		entry := entries[0]
+		require.Equal(t, "query failed", entry[consts.ContextMessage])
+		require.Equal(t, "req-1", entry[consts.ContextRequestID])
+		require.Equal(t, "cache", entry["component"])
+		require.Equal(t, "partners", entry["db.table"])
+		require.EqualValues(t, 4, entry["db.pool.size"])
+		require.Equal(t, "timeout", entry["db.err"])
+		require.Equal(t, "slog_test.go", entry["file"])
+	})
+
+	t.Run("levels", func(t *testing.T) {
+		sink.reset()
+		require.False(t, slogger.Enabled(context.Background(), slog.LevelDebug))
+		slogger.Debug("dropped")
+		slogger.Warn("kept")
+		slogger.Error("kept")
+		require.Len(t, sink.all(), 2)
+	})
+
+	t.Run("default logger", func(t *testing.T) {
+		defaultSlog := slog.Default()
+		defer func() {
+			slog.SetDefault(defaultSlog)
+			stdlog.SetOutput(os.Stderr)
+			stdlog.SetFlags(stdlog.LstdFlags)
+		}()
+		defaultSink := &fieldSink{}
+		logger.InitLogger(&logger.ClientOptions{
+			Service:     "default",
+			LogLevel:    "info",
+			SlogDefault: true,
+		}, &logger.ConsoleMode{Output: io.Discard}, defaultSink)
+
+		slog.Info("from slog")
+		stdlog.Print("from log")
+
+		entries := defaultSink.all()
+		require.Len(t, entries, 2)
+		require.Equal(t, "from slog", entries[0][consts.ContextMessage])
+		require.Equal(t, "from log", entries[1][consts.ContextMessage])
+	})
+}
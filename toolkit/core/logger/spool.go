@@ -0,0 +1,194 @@
+package logger
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+const (
+	// spoolFile receives the records that could not be delivered
+	spoolFile = "spool.ndjson"
+	// replayFile holds the records being replayed, it is left on disk when
+	// a replay is interrupted and picked up again on the next one
+	replayFile = "replay.ndjson"
+)
+
+// errSpoolFull is returned when a batch would grow the spool over its cap
+var errSpoolFull = errors.New("log spool is full")
+
+// spool is a write-ahead file of undelivered records, one JSON document per
+// line. It lives on disk so the records survive a restart of the service.
+type spool struct {
+	dir     string
+	maxSize int64
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+}
+
+// openSpool opens the spool in dir, the records left by a previous run are
+// kept
+func openSpool(dir string, maxSize int64) (*spool, error) {
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return nil, err
+	}
+	sp := &spool{
+		dir:     dir,
+		maxSize: maxSize,
+	}
+	if err := sp.open(); err != nil {
+		return nil, err
+	}
+	sp.size = sp.diskSize()
+	return sp, nil
+}
+
+// diskSize returns the size of the spool and replay files
+func (sp *spool) diskSize() int64 {
+	var size int64
+	for _, name := range []string{spoolFile, replayFile} {
+		if info, err := os.Stat(filepath.Join(sp.dir, name)); err == nil {
+			size += info.Size()
+		}
+	}
+	return size
+}
+
+// append writes the records at the end of the spool. The whole batch is
+// rejected with errSpoolFull when it does not fit under the size cap.
+func (sp *spool) append(records []map[string]interface{}) error {
+	var data []byte
+	for _, record := range records {
+		line, err := json.Marshal(record)
+		if err != nil {
+			return fmt.Errorf("encoding spooled record failed: %w", err)
+		}
+		data = append(data, line...)
+		data = append(data, '\n')
+	}
+
+	sp.mu.Lock()
+	defer sp.mu.Unlock()
+	if sp.size+int64(len(data)) > sp.maxSize {
+		return errSpoolFull
+	}
+	if err := sp.open(); err != nil {
+		return err
+	}
+	if _, err := sp.file.Write(data); err != nil {
+		return err
+	}
+	sp.size += int64(len(data))
+	return sp.file.Sync()
+}
+
+// empty reports whether there is nothing to replay
+func (sp *spool) empty() bool {
+	sp.mu.Lock()
+	defer sp.mu.Unlock()
+	return sp.size == 0
+}
+
+// take returns the path of the file to replay. The spool file is moved
+// aside so new records can be appended while the replay runs.
+func (sp *spool) take() (string, bool) {
+	sp.mu.Lock()
+	defer sp.mu.Unlock()
+
+	replay := filepath.Join(sp.dir, replayFile)
+	if _, err := os.Stat(replay); err == nil {
+		return replay, true
+	}
+	if sp.file == nil {
+		return "", false
+	}
+	info, err := sp.file.Stat()
+	if err != nil || info.Size() == 0 {
+		return "", false
+	}
+	if err := sp.file.Close(); err != nil {
+		return "", false
+	}
+	// the spool file is reopened by the next append
+	sp.file = nil
+	if err := os.Rename(filepath.Join(sp.dir, spoolFile), replay); err != nil {
+		return "", false
+	}
+	return replay, true
+}
+
+// open opens the spool file for appending, sp.mu must be held
+func (sp *spool) open() error {
+	if sp.file != nil {
+		return nil
+	}
+	file, err := os.OpenFile(filepath.Join(sp.dir, spoolFile), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	sp.file = file
+	return nil
+}
+
+// close closes the spool file, the next append opens it again
+func (sp *spool) close() error {
+	sp.mu.Lock()
+	defer sp.mu.Unlock()
+	if sp.file == nil {
+		return nil
+	}
+	err := sp.file.Close()
+	sp.file = nil
+	return err
+}
+
+// done records the end of a replay. The replayed lines are removed, the
+// remaining ones are kept for the next replay.
+func (sp *spool) done(path string, remaining [][]byte) error {
+	var err error
+	if len(remaining) == 0 {
+		err = os.Remove(path)
+	} else {
+		tmp := path + ".tmp"
+		var data []byte
+		for _, line := range remaining {
+			data = append(data, line...)
+			data = append(data, '\n')
+		}
+		if err = os.WriteFile(tmp, data, 0o600); err == nil {
+			err = os.Rename(tmp, path)
+		}
+	}
+
+	sp.mu.Lock()
+	defer sp.mu.Unlock()
+	sp.size = sp.diskSize()
+	return err
+}
+
+// readLines returns the complete records of a spool file
+func readLines(path string) ([][]byte, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var lines [][]byte
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		// a line cut by a crash is not valid JSON and is skipped
+		if !json.Valid(scanner.Bytes()) {
+			continue
+		}
+		lines = append(lines, append([]byte{}, scanner.Bytes()...))
+	}
+	return lines, scanner.Err()
+}
@@ -0,0 +1,176 @@
+// Package trace implements the W3C trace context
+// (https://www.w3.org/TR/trace-context/). A span context is parsed from the
+// traceparent and tracestate headers on ingress, carried through
+// context.Context and injected into the outbound requests, so a request can be
+// followed through every service it reaches.
+package trace
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// HeaderTraceparent carries the version, trace id, parent span id and flags
+	HeaderTraceparent = "traceparent"
+	// HeaderTracestate carries vendor specific trace data
+	HeaderTracestate = "tracestate"
+
+	// version is the only traceparent version produced
+	version = "00"
+	// FlagSampled is the trace flag marking a sampled trace
+	FlagSampled byte = 0x01
+)
+
+// SpanContext identifies a span of a trace
+type SpanContext struct {
+	// TraceID is the 32 hex digits id shared by every span of the trace
+	TraceID string
+	// SpanID is the 16 hex digits id of this span
+	SpanID string
+	// ParentID is the span id of the caller, empty for a root span
+	ParentID string
+	// Flags are the trace flags, see FlagSampled
+	Flags byte
+	// State is the tracestate header, propagated unchanged
+	State string
+}
+
+// New returns the root span of a new trace
+func New() SpanContext {
+	return SpanContext{
+		TraceID: randomID(16),
+		SpanID:  randomID(8),
+		Flags:   FlagSampled,
+	}
+}
+
+// Child returns a new span of the same trace, with this span as parent
+func (sc SpanContext) Child() SpanContext {
+	return SpanContext{
+		TraceID:  sc.TraceID,
+		SpanID:   randomID(8),
+		ParentID: sc.SpanID,
+		Flags:    sc.Flags,
+		State:    sc.State,
+	}
+}
+
+// IsValid reports whether the span has a trace id and a span id
+func (sc SpanContext) IsValid() bool {
+	return validID(sc.TraceID, 32) && validID(sc.SpanID, 16)
+}
+
+// Traceparent renders the traceparent header of the span
+func (sc SpanContext) Traceparent() string {
+	return fmt.Sprintf("%s-%s-%s-%02x", version, sc.TraceID, sc.SpanID, sc.Flags)
+}
+
+// Parse parses the traceparent and tracestate headers. The returned span is
+// the caller span: its SpanID is the parent id found in traceparent.
+func Parse(traceparent, tracestate string) (SpanContext, bool) {
+	parts := strings.Split(strings.TrimSpace(traceparent), "-")
+	if len(parts) < 4 {
+		return SpanContext{}, false
+	}
+	ver, traceID, spanID, flags := parts[0], parts[1], parts[2], parts[3]
+	// version ff is forbidden, version 00 has exactly four fields
+	if !validID(ver, 2) || ver == "ff" || (ver == version && len(parts) != 4) {
+		return SpanContext{}, false
+	}
+	if !validID(traceID, 32) || !validID(spanID, 16) || !validID(flags, 2) {
+		return SpanContext{}, false
+	}
+	decoded, _ := hex.DecodeString(flags)
+	return SpanContext{
+		TraceID: traceID,
+		SpanID:  spanID,
+		Flags:   decoded[0],
+		State:   strings.TrimSpace(tracestate),
+	}, true
+}
+
+// Extract reads the caller span from the request headers
+func Extract(header http.Header) (SpanContext, bool) {
+	return Parse(header.Get(HeaderTraceparent), header.Get(HeaderTracestate))
+}
+
+// Inject writes the span of ctx into the outbound request headers. Nothing is
+// written when ctx carries no span.
+func Inject(ctx context.Context, header http.Header) {
+	sc, ok := FromContext(ctx)
+	if !ok {
+		return
+	}
+	header.Set(HeaderTraceparent, sc.Traceparent())
+	if sc.State != "" {
+		header.Set(HeaderTracestate, sc.State)
+	}
+}
+
+// spanKey is the context key of the current span
+type spanKey struct{}
+
+// NewContext returns a copy of ctx carrying the span
+func NewContext(ctx context.Context, sc SpanContext) context.Context {
+	return context.WithValue(ctx, spanKey{}, sc)
+}
+
+// FromContext returns the span carried by ctx. A *gin.Context is searched
+// through its request context, handlers can pass either.
+func FromContext(ctx context.Context) (SpanContext, bool) {
+	if ctx == nil {
+		return SpanContext{}, false
+	}
+	if sc, ok := ctx.Value(spanKey{}).(SpanContext); ok {
+		return sc, true
+	}
+	if gc, ok := ctx.(*gin.Context); ok && gc.Request != nil {
+		sc, ok := gc.Request.Context().Value(spanKey{}).(SpanContext)
+		return sc, ok
+	}
+	return SpanContext{}, false
+}
+
+// randomID returns n random bytes as hex digits
+func randomID(n int) string {
+	id := make([]byte, n)
+	for {
+		_, _ = rand.Read(id)
+		// an id of zeros is invalid
+		for _, b := range id {
+			if b != 0 {
+				return hex.EncodeToString(id)
+			}
+		}
+	}
+}
+
+// validID reports whether id is made of size lowercase hex digits and is not
+// all zeros
+func validID(id string, size int) bool {
+	if len(id) != size {
+		return false
+	}
+	zero := true
+	for _, c := range id {
+		switch {
+		case c >= '0' && c <= '9':
+			if c != '0' {
+				zero = false
+			}
+		case c >= 'a' && c <= 'f':
+			zero = false
+		default:
+			return false
+		}
+	}
+	// flags and version may be zero, ids may not
+	return !zero || size == 2
+}
@@ -0,0 +1,86 @@
+package trace
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name        string
+		traceparent string
+		valid       bool
+	}{
+		{"valid", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", true},
+		{"future version with extra fields", "01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00-extra", true},
+		{"forbidden version", "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", false},
+		{"extra fields in version 00", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", false},
+		{"zero trace id", "00-00000000000000000000000000000000-00f067aa0ba902b7-01", false},
+		{"zero span id", "00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01", false},
+		{"uppercase", "00-4BF92F3577B34DA6A3CE929D0E0E4736-00F067AA0BA902B7-01", false},
+		{"short trace id", "00-4bf92f3577b34da6-00f067aa0ba902b7-01", false},
+		{"empty", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, ok := Parse(tt.traceparent, "")
+			require.Equal(t, tt.valid, ok)
+		})
+	}
+
+	sc, ok := Parse("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", " vendor=value ")
+	require.True(t, ok)
+	require.Equal(t, SpanContext{
+		TraceID: "4bf92f3577b34da6a3ce929d0e0e4736",
+		SpanID:  "00f067aa0ba902b7",
+		Flags:   FlagSampled,
+		State:   "vendor=value",
+	}, sc)
+}
+
+func TestSpans(t *testing.T) {
+	root := New()
+	require.True(t, root.IsValid())
+	require.Empty(t, root.ParentID)
+
+	child := root.Child()
+	require.True(t, child.IsValid())
+	require.Equal(t, root.TraceID, child.TraceID)
+	require.Equal(t, root.SpanID, child.ParentID)
+	require.NotEqual(t, root.SpanID, child.SpanID)
+
+	parsed, ok := Parse(child.Traceparent(), "")
+	require.True(t, ok)
+	require.Equal(t, child.TraceID, parsed.TraceID)
+	require.Equal(t, child.SpanID, parsed.SpanID)
+}
+
+func TestPropagation(t *testing.T) {
+	sc := New()
+	sc.State = "vendor=value"
+
+	header := http.Header{}
+	Inject(context.Background(), header)
+	require.Empty(t, header.Get(HeaderTraceparent))
+
+	Inject(NewContext(context.Background(), sc), header)
+	require.Equal(t, sc.Traceparent(), header.Get(HeaderTraceparent))
+	require.Equal(t, "vendor=value", header.Get(HeaderTracestate))
+
+	extracted, ok := Extract(header)
+	require.True(t, ok)
+	require.Equal(t, sc.TraceID, extracted.TraceID)
+
+	// a gin context is searched through its request
+	gc, _ := gin.CreateTestContext(httptest.NewRecorder())
+	gc.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+	gc.Request = gc.Request.WithContext(NewContext(gc.Request.Context(), sc))
+	fromGin, ok := FromContext(gc)
+	require.True(t, ok)
+	require.Equal(t, sc, fromGin)
+}
@@ -1,53 +1,72 @@
 package middleware
 
 import (
-	"context"
-	"fmt"
-	"runtime/debug"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"gitlab.com/tuneverse/toolkit/consts"
 	"gitlab.com/tuneverse/toolkit/core/logger"
+	"gitlab.com/tuneverse/toolkit/core/trace"
 	"gitlab.com/tuneverse/toolkit/utils"
 )
 
-func LogMiddleware(inp map[string]interface{}) gin.HandlerFunc {
-	return func(c *gin.Context) {
-		trace := true
+// LogOptions configures LogMiddleware
+type LogOptions struct {
+	// Logger writes the request logs, it defaults to logger.Log(). Services
+	// sharing a binary pass their own logger to keep their settings apart.
+	Logger *logger.Logger
+}
+
+// LogMiddleware logs the start and the completion of every request and binds
+// a logger carrying the request fields to the request context. The panics of
+// the handlers are left to Recovery, registered after it.
+func LogMiddleware(inp map[string]interface{}, option ...LogOptions) gin.HandlerFunc {
+	opt := LogOptions{}
+	if len(option) > 0 {
+		opt = option[0]
+	}
+	log := opt.Logger
+	if log == nil {
+		log = logger.Log()
+	}
 
+	return func(c *gin.Context) {
 		fields := map[string]interface{}{
 			consts.ContextRequestURI:         utils.ConstructURL(c.Request),
 			consts.ContextRequestMethod:      c.Request.Method,
 			consts.ContextRequestIP:          c.ClientIP(),
 			consts.ContextRequestID:          utils.GetRequestIDFromRequest(c.Request),
 			consts.ContextRequestURITemplate: utils.GetRequestRoute(c),
-			consts.ContextService:            logger.GetService(),
+			consts.ContextService:            log.Service(),
+		}
+
+		// continue the trace of the caller or start a new one
+		span := trace.New()
+		if parent, ok := trace.Extract(c.Request.Header); ok {
+			span = parent.Child()
+			fields[consts.ContextParentSpanID] = span.ParentID
 		}
+		fields[consts.ContextTraceID] = span.TraceID
+		fields[consts.ContextSpanID] = span.SpanID
+		c.Header(trace.HeaderTraceparent, span.Traceparent())
 
-		if logger.GetRequestDumpStatus() {
+		if log.IncludeRequestDump() {
 			if req, err := utils.GetRequestDump(c.Request); err == nil {
 				fields[consts.ContextRequestDump] = *req
 			}
 		}
-		ctx := c.Request.Context()
-		ctx = context.WithValue(ctx, consts.LogData, fields)
+		// every request gets its own logger, handlers reach it through the
+		// request context with logger.Log().WithContext(ctx)
+		reqLog := log.WithFields(fields)
+		ctx := logger.NewContext(trace.NewContext(c.Request.Context(), span), reqLog)
+		reqLog = reqLog.WithContext(ctx)
 		c.Request = c.Request.WithContext(ctx)
 
-		defer func() {
-			if trace {
-				traceMsg := fmt.Sprintf("Stacktrace: %v", string(debug.Stack()))
-				logger.
-					Log().
-					WithContext(ctx).
-					Panic(traceMsg)
-			}
-		}()
 		ww := utils.NewResponseWriterWrapper(c.Writer)
 		c.Writer = ww
 
 		start := time.Now()
-		logger.Log().WithContext(ctx).Info("started handling request")
+		reqLog.Info("started handling request")
 
 		c.Next()
 
@@ -55,14 +74,11 @@ func LogMiddleware(inp map[string]interface{}) gin.HandlerFunc {
 			consts.ContextRequestStatus:    c.Writer.Status(),
 			consts.ContextRequestTimetaken: time.Since(start).String(), // Convert duration to a string
 		}
-		if logger.GetResponseDumpStatus() {
+		if log.IncludeResponseDump() {
 			fields[consts.ContextResponseDump] = ww.GetResponseData()
 		}
-		logger.
-			Log().
-			WithContext(ctx).
+		reqLog.
 			WithFields(fields).
 			Info("completed handling request")
-		trace = false
 	}
 }
@@ -0,0 +1,195 @@
+package middleware_test
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+	"gitlab.com/tuneverse/toolkit/consts"
+	"gitlab.com/tuneverse/toolkit/core/logger"
+	"gitlab.com/tuneverse/toolkit/core/trace"
+	"gitlab.com/tuneverse/toolkit/middleware"
+	"gitlab.com/tuneverse/toolkit/utils"
+)
+
+// recordSink keeps the fields of every entry written by the logger
+type recordSink struct {
+	logger.SinkOptions
+	mu      sync.Mutex
+	entries []logrus.Fields
+}
+
+func (sink *recordSink) Init(cfg logger.SinkConfig) error {
+	return sink.Apply(cfg)
+}
+
+func (sink *recordSink) Write(entry *logrus.Entry) error {
+	fields := make(logrus.Fields, len(entry.Data))
+	for key, value := range entry.Data {
+		fields[key] = value
+	}
+	sink.mu.Lock()
+	defer sink.mu.Unlock()
+	sink.entries = append(sink.entries, fields)
+	return nil
+}
+
+func TestLogMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	sink := &recordSink{}
+	logger.InitLogger(&logger.ClientOptions{
+		Service:  "service",
+		LogLevel: "info",
+	}, &logger.ConsoleMode{Output: io.Discard}, sink)
+
+	router := gin.New()
+	router.Use(middleware.LogMiddleware(map[string]interface{}{}))
+	router.GET("/items/:id", func(c *gin.Context) {
+		logger.Log().
+			WithContext(c.Request.Context()).
+			With("handler_id", c.Param("id")).
+			Info("handling")
+		c.Status(http.StatusOK)
+	})
+
+	// downstream records the traceparent of the outbound calls
+	var outbound string
+	downstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		outbound = r.Header.Get(trace.HeaderTraceparent)
+	}))
+	defer downstream.Close()
+	router.GET("/calls", func(c *gin.Context) {
+		resp, err := utils.APIRequestWithContext(c, http.MethodGet, downstream.URL, nil, nil)
+		require.NoError(t, err)
+		resp.Body.Close()
+		logger.Log().WithContext(c).Info("called downstream")
+		c.Status(http.StatusOK)
+	})
+
+	// the request fields of the scoped logger are part of the context fields,
+	// ContextWithFields overrides them
+	var requestFields map[string]interface{}
+	router.GET("/partners/:partner_id", func(c *gin.Context) {
+		requestFields = logger.FieldsFromContext(c.Request.Context())
+		ctx := logger.ContextWithFields(c.Request.Context(), "partner_id", c.Param("partner_id"), consts.ContextService, "billing")
+		logger.Log().WithContext(ctx).Info("partner found")
+		c.Status(http.StatusOK)
+	})
+
+	t.Run("context fields", func(t *testing.T) {
+		sink.mu.Lock()
+		sink.entries = nil
+		sink.mu.Unlock()
+
+		req := httptest.NewRequest(http.MethodGet, "/partners/p-1", nil)
+		req.Header.Set(consts.ContextRequestID, "req-1")
+		router.ServeHTTP(httptest.NewRecorder(), req)
+
+		require.Equal(t, "req-1", requestFields[consts.ContextRequestID])
+		require.Equal(t, http.MethodGet, requestFields[consts.ContextRequestMethod])
+		require.Equal(t, "/partners/:partner_id", requestFields[consts.ContextRequestURITemplate])
+		require.Equal(t, "service", requestFields[consts.ContextService])
+
+		sink.mu.Lock()
+		defer sink.mu.Unlock()
+		require.Len(t, sink.entries, 3)
+		found := sink.entries[1]
+		require.Equal(t, "partner found", found[consts.ContextMessage])
+		require.Equal(t, "req-1", found[consts.ContextRequestID])
+		require.Equal(t, "p-1", found["partner_id"])
+		require.Equal(t, "billing", found[consts.ContextService])
+		sink.entries = nil
+	})
+
+	t.Run("each request gets its own scoped logger", func(t *testing.T) {
+		var wg sync.WaitGroup
+		for i := 0; i < 50; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/items/%d", i), nil)
+				req.Header.Set(consts.ContextRequestID, fmt.Sprint(i))
+				router.ServeHTTP(httptest.NewRecorder(), req)
+			}(i)
+		}
+		wg.Wait()
+
+		sink.mu.Lock()
+		defer sink.mu.Unlock()
+		require.Len(t, sink.entries, 150)
+		for _, fields := range sink.entries {
+			if id, ok := fields["handler_id"]; ok {
+				require.Equal(t, id, fields[consts.ContextRequestID])
+			}
+			if fields[consts.ContextMessage] != "completed handling request" {
+				require.NotContains(t, fields, consts.ContextRequestStatus)
+			}
+		}
+	})
+
+	t.Run("trace context is continued and propagated", func(t *testing.T) {
+		sink.mu.Lock()
+		sink.entries = nil
+		sink.mu.Unlock()
+
+		req := httptest.NewRequest(http.MethodGet, "/calls", nil)
+		req.Header.Set(trace.HeaderTraceparent, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		span, ok := trace.Parse(outbound, "")
+		require.True(t, ok)
+		require.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", span.TraceID)
+		require.Equal(t, rec.Header().Get(trace.HeaderTraceparent), outbound)
+
+		sink.mu.Lock()
+		defer sink.mu.Unlock()
+		require.Len(t, sink.entries, 3)
+		for _, fields := range sink.entries {
+			require.Equal(t, span.TraceID, fields[consts.ContextTraceID])
+			require.Equal(t, span.SpanID, fields[consts.ContextSpanID])
+			require.Equal(t, "00f067aa0ba902b7", fields[consts.ContextParentSpanID])
+		}
+	})
+}
+
+func TestLogMiddlewareInstances(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	sinks := map[string]*recordSink{}
+	for _, service := range []string{"partner", "utility"} {
+		sink := &recordSink{}
+		log, err := logger.New(&logger.ClientOptions{
+			Service:  service,
+			LogLevel: "info",
+		}, &logger.ConsoleMode{Output: io.Discard}, sink)
+		require.NoError(t, err)
+		sinks[service] = sink
+
+		group := router.Group("/" + service)
+		group.Use(middleware.LogMiddleware(map[string]interface{}{}, middleware.LogOptions{Logger: log}))
+		group.GET("/ping", func(c *gin.Context) {
+			logger.Log().WithContext(c).Info("pong")
+			c.Status(http.StatusOK)
+		})
+	}
+
+	for _, service := range []string{"partner", "utility"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/"+service+"/ping", nil))
+	}
+
+	for service, sink := range sinks {
+		sink.mu.Lock()
+		require.Len(t, sink.entries, 3, service)
+		for _, fields := range sink.entries {
+			require.Equal(t, service, fields[consts.ContextService])
+		}
+		sink.mu.Unlock()
+	}
+}
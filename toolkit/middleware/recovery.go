@@ -0,0 +1,141 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"runtime/debug"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gitlab.com/tuneverse/toolkit/consts"
+	"gitlab.com/tuneverse/toolkit/core/logger"
+	"gitlab.com/tuneverse/toolkit/models/api"
+	"gitlab.com/tuneverse/toolkit/utils"
+)
+
+// RecoveryOptions configures Recovery
+type RecoveryOptions struct {
+	// Logger writes the panics, it defaults to the logger of the request
+	// context set by LogMiddleware, then to logger.Log()
+	Logger *logger.Logger
+	// ContextErrorResponse is the context key of the localized errors, the
+	// ErrorLocaleOptions.ContextErrorResponse of ErrorLocalization. It
+	// defaults to consts.ContextErrorResponses.
+	ContextErrorResponse string
+	// OnPanic is called with every recovered panic once it is logged, e.g.
+	// to count the panics of a service. It is not called for the broken
+	// connections.
+	OnPanic func(c *gin.Context, recovered interface{})
+}
+
+// Recovery recovers the panics of the handlers. The panic is logged once
+// with its stack and the request fields, and the request is answered with a
+// 500 api.Response carrying the localized internal_server_error entry set by
+// ErrorLocalization, or a generic message when there is none.
+// Register it after LogMiddleware so the request is still logged as completed.
+func Recovery(option ...RecoveryOptions) gin.HandlerFunc {
+	opt := RecoveryOptions{}
+	if len(option) > 0 {
+		opt = option[0]
+	}
+	contextErrorResponse := consts.ContextErrorResponses
+	if opt.ContextErrorResponse != "" {
+		contextErrorResponse = opt.ContextErrorResponse
+	}
+
+	return func(c *gin.Context) {
+		defer func() {
+			recovered := recover()
+			if recovered == nil {
+				return
+			}
+
+			ctx := c.Request.Context()
+			log := logger.FromContext(ctx)
+			if opt.Logger != nil {
+				log = opt.Logger.WithContext(ctx)
+			}
+			log = log.WithFields(map[string]interface{}{
+				consts.ContextRequestURI:         utils.ConstructURL(c.Request),
+				consts.ContextRequestMethod:      c.Request.Method,
+				consts.ContextRequestID:          utils.GetRequestIDFromRequest(c.Request),
+				consts.ContextRequestURITemplate: utils.GetRequestRoute(c),
+				consts.ContextPanic:              fmt.Sprint(recovered),
+			})
+
+			// the client went away, there is nobody to answer and no bug
+			// to trace
+			if isBrokenConnection(recovered) {
+				log.Warn("connection lost while handling request")
+				c.Abort()
+				return
+			}
+
+			log.With(consts.ContextStack, string(debug.Stack())).Error("recovered from panic")
+			if opt.OnPanic != nil {
+				opt.OnPanic(c, recovered)
+			}
+
+			// the handler already answered, the status can not be changed
+			if c.Writer.Written() {
+				c.Abort()
+				return
+			}
+			message, code := internalServerError(c, contextErrorResponse)
+			c.AbortWithStatusJSON(http.StatusInternalServerError, api.Response{
+				Status:  consts.Failure,
+				Message: message,
+				Code:    code,
+				Data:    map[string]string{},
+				Errors:  map[string]interface{}{},
+			})
+		}()
+		c.Next()
+	}
+}
+
+// internalServerError returns the message and the code of the localized
+// internal_server_error entry, the status text and 500 when it is missing
+func internalServerError(c *gin.Context, contextErrorResponse string) (string, int) {
+	message, code := http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError
+
+	// utils.GetContext panics on a missing key
+	value, _ := c.Get(contextErrorResponse)
+	errorData, ok := value.(map[string]interface{})
+	if !ok {
+		return message, code
+	}
+	errorsMap, _ := errorData[consts.Errors].(map[string]interface{})
+	allErrorMap, _ := errorsMap[consts.AllError].(map[string]interface{})
+	entry, _ := allErrorMap[consts.InternalServerErr].(map[string]interface{})
+	if localized, ok := entry[consts.Message].(string); ok && localized != "" {
+		message = localized
+	}
+	if errorCode, ok := entry[consts.ErrorCode].(float64); ok {
+		code = int(errorCode)
+	}
+	return message, code
+}
+
+// isBrokenConnection reports whether the panic comes from writing to a client
+// which closed the connection, or from a handler aborting with
+// http.ErrAbortHandler
+func isBrokenConnection(recovered interface{}) bool {
+	err, ok := recovered.(error)
+	if !ok {
+		return false
+	}
+	if errors.Is(err, http.ErrAbortHandler) {
+		return true
+	}
+	var opErr *net.OpError
+	var syscallErr *os.SyscallError
+	if errors.As(err, &opErr) && errors.As(opErr, &syscallErr) {
+		msg := strings.ToLower(syscallErr.Error())
+		return strings.Contains(msg, "broken pipe") || strings.Contains(msg, "connection reset by peer")
+	}
+	return false
+}
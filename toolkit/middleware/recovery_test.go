@@ -0,0 +1,156 @@
+package middleware_test
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+	"gitlab.com/tuneverse/toolkit/consts"
+	"gitlab.com/tuneverse/toolkit/core/logger"
+	"gitlab.com/tuneverse/toolkit/middleware"
+	"gitlab.com/tuneverse/toolkit/models/api"
+)
+
+func TestRecovery(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	sink := &recordSink{}
+	logger.InitLogger(&logger.ClientOptions{
+		Service:  "service",
+		LogLevel: "info",
+	}, &logger.ConsoleMode{Output: io.Discard}, sink)
+
+	var panics []interface{}
+	router := gin.New()
+	router.Use(middleware.LogMiddleware(map[string]interface{}{}))
+	router.Use(middleware.Recovery(middleware.RecoveryOptions{
+		OnPanic: func(c *gin.Context, recovered interface{}) {
+			panics = append(panics, recovered)
+		},
+	}))
+	router.GET("/panic", func(c *gin.Context) {
+		panic("nil map")
+	})
+	router.GET("/localized", func(c *gin.Context) {
+		c.Set(consts.ContextErrorResponses, map[string]interface{}{
+			consts.Errors: map[string]interface{}{
+				consts.AllError: map[string]interface{}{
+					consts.InternalServerErr: map[string]interface{}{
+						consts.Message:   "Erreur interne du serveur",
+						consts.ErrorCode: float64(5000),
+					},
+				},
+			},
+		})
+		panic("nil map")
+	})
+	router.GET("/written", func(c *gin.Context) {
+		c.String(http.StatusOK, "partial")
+		panic("nil map")
+	})
+	router.GET("/aborted", func(c *gin.Context) {
+		panic(http.ErrAbortHandler)
+	})
+
+	serve := func(path string) (*httptest.ResponseRecorder, []logrus.Fields) {
+		sink.mu.Lock()
+		sink.entries = nil
+		sink.mu.Unlock()
+		panics = nil
+
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set(consts.ContextRequestID, "req-1")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		sink.mu.Lock()
+		defer sink.mu.Unlock()
+		return rec, sink.entries
+	}
+	stacks := func(entries []logrus.Fields) []logrus.Fields {
+		var logged []logrus.Fields
+		for _, fields := range entries {
+			if _, ok := fields[consts.ContextStack]; ok {
+				logged = append(logged, fields)
+			}
+		}
+		return logged
+	}
+	decode := func(rec *httptest.ResponseRecorder) api.Response {
+		var resp api.Response
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		return resp
+	}
+
+	t.Run("panic is answered with a failure response", func(t *testing.T) {
+		rec, entries := serve("/panic")
+		require.Equal(t, http.StatusInternalServerError, rec.Code)
+		resp := decode(rec)
+		require.Equal(t, consts.Failure, resp.Status)
+		require.Equal(t, http.StatusText(http.StatusInternalServerError), resp.Message)
+		require.Equal(t, http.StatusInternalServerError, resp.Code)
+		require.Equal(t, []interface{}{"nil map"}, panics)
+
+		logged := stacks(entries)
+		require.Len(t, logged, 1)
+		require.Equal(t, "nil map", logged[0][consts.ContextPanic])
+		require.Equal(t, "req-1", logged[0][consts.ContextRequestID])
+		require.Equal(t, "/panic", logged[0][consts.ContextRequestURITemplate])
+		require.Contains(t, logged[0][consts.ContextStack], "runtime/debug.Stack")
+
+		// the request is still logged as completed
+		completed := entries[len(entries)-1]
+		require.Equal(t, "completed handling request", completed[consts.ContextMessage])
+		require.Equal(t, http.StatusInternalServerError, completed[consts.ContextRequestStatus])
+	})
+
+	t.Run("localized internal server error", func(t *testing.T) {
+		rec, _ := serve("/localized")
+		require.Equal(t, http.StatusInternalServerError, rec.Code)
+		resp := decode(rec)
+		require.Equal(t, "Erreur interne du serveur", resp.Message)
+		require.Equal(t, 5000, resp.Code)
+	})
+
+	t.Run("written response is kept", func(t *testing.T) {
+		rec, entries := serve("/written")
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Equal(t, "partial", rec.Body.String())
+		require.Len(t, stacks(entries), 1)
+		require.Len(t, panics, 1)
+	})
+
+	t.Run("aborted handler is not traced", func(t *testing.T) {
+		_, entries := serve("/aborted")
+		require.Empty(t, stacks(entries))
+		require.Empty(t, panics)
+	})
+
+	t.Run("custom logger", func(t *testing.T) {
+		custom := &recordSink{}
+		log, err := logger.New(&logger.ClientOptions{
+			Service:  "partner",
+			LogLevel: "info",
+		}, &logger.ConsoleMode{Output: io.Discard}, custom)
+		require.NoError(t, err)
+
+		router := gin.New()
+		router.Use(middleware.Recovery(middleware.RecoveryOptions{Logger: log}))
+		router.GET("/panic", func(c *gin.Context) {
+			panic(errors.New("store closed"))
+		})
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/panic", nil))
+		require.Equal(t, http.StatusInternalServerError, rec.Code)
+
+		custom.mu.Lock()
+		defer custom.mu.Unlock()
+		require.Len(t, stacks(custom.entries), 1)
+		require.Equal(t, "store closed", custom.entries[0][consts.ContextPanic])
+	})
+}
@@ -1,12 +1,14 @@
 package utils
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"strings"
 
 	log "github.com/sirupsen/logrus"
+	"gitlab.com/tuneverse/toolkit/core/trace"
 )
 
 var HTTPClient = &http.Client{}
@@ -14,6 +16,13 @@ var HTTPClient = &http.Client{}
 // For api request
 func APIRequest(method string, url string, headers map[string]interface{},
 	body map[string]interface{}) (*http.Response, error) {
+	return APIRequestWithContext(context.Background(), method, url, headers, body)
+}
+
+// APIRequestWithContext sends the request bound to ctx. The trace context of
+// ctx is propagated in the traceparent and tracestate headers.
+func APIRequestWithContext(ctx context.Context, method string, url string, headers map[string]interface{},
+	body map[string]interface{}) (*http.Response, error) {
 
 	jsonData, err := json.Marshal(body)
 	if err != nil {
@@ -23,12 +32,13 @@ func APIRequest(method string, url string, headers map[string]interface{},
 	// Create a strings.Reader from the JSON string
 	reader := strings.NewReader(string(jsonData))
 
-	request, err := http.NewRequest(method, url, reader)
+	request, err := http.NewRequestWithContext(ctx, method, url, reader)
 	if err != nil {
 		log.Errorf("unable to connect API server %v %v", url, err)
 		return nil, err
 	}
 	request.Header.Add("Content-Type", "application/json")
+	trace.Inject(ctx, request.Header)
 	SETHeaders(*request, headers)
 
 	response, err := HTTPClient.Do(request)
@@ -1,6 +1,8 @@
 package utils
 
 import (
+	"errors"
+	"fmt"
 	"reflect"
 
 	"github.com/dgrijalva/jwt-go"
@@ -28,3 +30,21 @@ func GenerateJWTAuthToken(jwtTokenKey string, jwtTokenPayload interface{}) (stri
 
 	return tokenString, nil
 }
+
+// VerifyJWTAuthToken checks a token signed by GenerateJWTAuthToken: the HS256
+// signature with jwtTokenKey and the exp, iat and nbf claims when present
+func VerifyJWTAuthToken(jwtTokenKey string, tokenString string) error {
+	if jwtTokenKey == "" {
+		return errors.New("no token key configured")
+	}
+	if tokenString == "" {
+		return errors.New("missing token")
+	}
+	_, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		if token.Method != jwt.SigningMethodHS256 {
+			return nil, fmt.Errorf("unexpected signing method %v", token.Header["alg"])
+		}
+		return []byte(jwtTokenKey), nil
+	})
+	return err
+}
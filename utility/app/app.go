@@ -57,6 +57,12 @@ func Run() {
 		log = logger.InitLogger(clientOpt, db)
 	}
 
+	// SIGUSR1 raises the log level to debug, SIGUSR2 restores it
+	stopLevelSignals := logger.HandleLevelSignals(logger.LevelSignalOptions{
+		RevertAfter: consts.LogLevelRevertAfter,
+	})
+	defer stopLevelSignals()
+
 	// database connection
 	pgsqlDB, err := driver.ConnectDB(cfg.Db)
 	if err != nil {
@@ -73,7 +79,8 @@ func Run() {
 	// middleware initialization
 	m := middlewares.NewMiddlewares(cfg)
 	api := router.Group("/api")
-	api.Use(middleware.LogMiddleware(map[string]interface{}{}))
+	api.Use(middleware.LogMiddleware(map[string]interface{}{}, middleware.LogOptions{Logger: log}))
+	api.Use(middleware.Recovery(middleware.RecoveryOptions{Logger: log}))
 	api.Use(middleware.APIVersionGuard(middleware.VersionOptions{
 		AcceptedVersions: cfg.AcceptedVersions,
 	}))
@@ -105,6 +112,12 @@ func Run() {
 		},
 	))
 
+	// runtime log level control, restricted to the tokens signed with the
+	// logger secret
+	levels := logger.LevelHandler(logger.LevelHandlerOptions{Authorize: logger.AuthorizeToken(consts.LoggerSecret)})
+	api.GET("/:version/admin/log-level", levels)
+	api.PUT("/:version/admin/log-level", levels)
+
 	// complete user related initialization
 	{
 
@@ -208,6 +221,13 @@ func launch(cfg *entities.EnvConfig, router *gin.Engine) {
 	if err := srv.Shutdown(ctx); err != nil {
 		log.Fatal("Server Shutdown:", err)
 	}
+
+	// deliver the logs of the last requests before exiting
+	logCtx, logCancel := context.WithTimeout(context.Background(), consts.LogCloseTimeout)
+	defer logCancel()
+	if err := logger.Log().Close(logCtx); err != nil {
+		log.Printf("closing the logger failed: %s", err.Error())
+	}
 	// catching ctx.Done(). timeout of 5 seconds.
 	<-ctx.Done()
 	log.Println("timeout of 5 seconds.")
@@ -3,6 +3,7 @@ package consts
 import (
 	"errors"
 	"os"
+	"time"
 )
 
 // Constants defining fundamental properties and settings of the application.
@@ -29,6 +30,12 @@ const (
 	LogMaxAge    = 7
 	LogMaxSize   = 1024 * 1024 * 10
 	LogMaxBackup = 5
+
+	// LogLevelRevertAfter restores the configured log level after a raise by signal
+	LogLevelRevertAfter = 15 * time.Minute
+
+	// LogCloseTimeout bounds the draining of the log sinks on shutdown
+	LogCloseTimeout = 5 * time.Second
 )
 
 // Default identifier for generating language labels.